@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"backend/config"
+	"backend/internal/aircraft"
+	"backend/internal/controller"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/spf13/cobra"
+)
+
+var aircraftCmd = &cobra.Command{
+	Use:   "aircraft",
+	Short: "Manage aircraft configuration templates",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var aircraftList = &cobra.Command{
+	Use:   "list",
+	Short: "List the aircraft templates",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, t := range aircraft.Templates() {
+			fmt.Printf("%-6s %-32s %d seats\n", t.Type, t.Name, len(t.Seats()))
+			for _, c := range t.Cabins {
+				fmt.Printf("       %-8s rows %d-%d  %s\n", c.Cabin, c.Rows.From, c.Rows.To, c.Layout)
+			}
+		}
+	},
+}
+
+var aircraftApply = &cobra.Command{
+	Use:   "apply",
+	Short: "Create a flight's seats from an aircraft template",
+	Run: func(cmd *cobra.Command, args []string) {
+		flightID, _ := cmd.Flags().GetInt64("flight")
+		aircraftType, _ := cmd.Flags().GetString("type")
+
+		repos := openStorage(cmd.Context(), config.LoadConfig())
+		created, err := controller.NewSeatController(repos.seats).ApplyAircraft(cmd.Context(), flightID, aircraftType)
+		if err != nil {
+			log.Fatalf("Failed to apply aircraft template: %v", err)
+		}
+		fmt.Printf("created %d seats on flight %d from %s\n", created, flightID, aircraftType)
+	},
+}
+
+func init() {
+	aircraftApply.Flags().Int64("flight", 0, "id of the flight to create the seats on")
+	aircraftApply.Flags().String("type", "", "aircraft type, see aircraft list")
+	aircraftApply.MarkFlagRequired("flight")
+	aircraftApply.MarkFlagRequired("type")
+
+	aircraftCmd.AddCommand(aircraftList, aircraftApply)
+	rootCmd.AddCommand(aircraftCmd)
+}
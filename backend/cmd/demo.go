@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// seedDemoData fills an empty storage with a flight, seats and vouchers, so the
+// API is usable right away in demo mode.
+func seedDemoData(ctx context.Context, repos *repositories) error {
+	depDate := repos.clock.Now().AddDate(0, 0, 7).Truncate(24 * time.Hour)
+	if err := repos.flights.Create(ctx, &models.CreateBulkFlight{
+		FlightNumbers: []string{"DEMO100"},
+		DepDate:       depDate,
+	}); err != nil {
+		return err
+	}
+
+	flights, err := repos.flights.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	flightID := flights[len(flights)-1].ID
+
+	cabins := []struct {
+		cabin   string
+		rows    []int
+		columns string
+	}{
+		{cabin: "FIRST", rows: []int{1}, columns: "AD"},
+		{cabin: "BUSINESS", rows: []int{2, 3}, columns: "ACDF"},
+		{cabin: "ECONOMY", rows: []int{10, 11, 12, 13, 14}, columns: "ABCDEF"},
+	}
+
+	for _, c := range cabins {
+		var labels []string
+		for _, row := range c.rows {
+			for _, col := range c.columns {
+				labels = append(labels, fmt.Sprintf("%d%c", row, col))
+			}
+		}
+
+		if err := repos.seats.Create(ctx, &models.CreateBulkSeat{
+			FlightID: flightID,
+			Cabin:    c.cabin,
+			Labels:   labels,
+		}); err != nil {
+			return err
+		}
+
+		for i := 1; i <= 3; i++ {
+			if err := repos.vouchers.Create(ctx, &models.CreateNewVoucher{
+				Code:     fmt.Sprintf("DEMO-%s-%d", c.cabin, i),
+				FlightID: flightID,
+				Cabin:    c.cabin,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
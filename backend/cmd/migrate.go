@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"backend/config"
+	"backend/internal/clock"
+	"backend/pkg/db"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database schema migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var migrateUp = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator()
+
+		applied, err := migrator.Up(cmd.Context())
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	},
+}
+
+var migrateDown = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the latest applied migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		steps, _ := cmd.Flags().GetInt("steps")
+		migrator := newMigrator()
+
+		rolled, err := migrator.Down(cmd.Context(), steps)
+		for _, m := range rolled {
+			fmt.Printf("rolled back %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to roll back migrations: %v", err)
+		}
+		if len(rolled) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	},
+}
+
+var migrateStatus = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator()
+
+		statuses, err := migrator.Status(cmd.Context())
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Unknown:
+				state = "applied (unknown to this build)"
+			case s.Modified:
+				state = "applied (modified since)"
+			case s.Applied:
+				state = "applied " + s.AppliedAt
+			}
+			fmt.Printf("%4d  %-40s %s\n", s.Version, s.Name, state)
+		}
+	},
+}
+
+var migrateRedo = &cobra.Command{
+	Use:   "redo",
+	Short: "Roll back and re-apply the latest migration",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator()
+
+		m, err := migrator.Redo(cmd.Context())
+		if err != nil {
+			log.Fatalf("Failed to redo migration: %v", err)
+		}
+		fmt.Printf("redone %d_%s\n", m.Version, m.Name)
+	},
+}
+
+func newMigrator() *db.Migrator {
+	cfg := config.LoadConfig()
+	sqlConnection, driver := openDatabase(cfg)
+
+	return db.NewMigrator(sqlConnection, driver, driver.Migrations(), clock.System{})
+}
+
+func init() {
+	migrateDown.Flags().Int("steps", 1, "number of migrations to roll back")
+
+	migrateCmd.AddCommand(migrateUp, migrateDown, migrateStatus, migrateRedo)
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -6,8 +6,7 @@ import (
 	"backend/delivery/http/handler"
 	"backend/delivery/http/middleware"
 	"backend/internal/controller"
-	"backend/internal/repository"
-	"backend/pkg/db"
+	"backend/internal/scheduler"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	_ "github.com/joho/godotenv/autoload"
@@ -28,17 +27,20 @@ var server = &cobra.Command{
 		// init config
 		cfg := config.LoadConfig()
 
-		// init open connection
-		sqlConnection, err := db.NewSQLiteConnection(cfg.DBPath)
-		if err != nil {
-			log.Fatalf("Failed to init database connection: %v", err)
+		// demo mode serves seeded in-memory data, no database file needed
+		demo, _ := cmd.Flags().GetBool("demo")
+		if demo {
+			cfg.DBDriver = memoryDriver
 		}
 
-		// execute to insert database schema
-		if _, err := sqlConnection.Exec(db.SCHEMA); err != nil {
-			log.Fatalf("Failed to init database schema: %v", err)
+		// repository (data layer)
+		repos := openStorage(cmd.Context(), cfg)
+		if demo {
+			if err := seedDemoData(cmd.Context(), repos); err != nil {
+				log.Fatalf("Failed to seed demo data: %v", err)
+			}
+			log.Info("Successfully seeded demo data!")
 		}
-		log.Info("Successfully initialized database schema!")
 
 		// init fiber
 		app := fiber.New(fiber.Config{})
@@ -46,29 +48,31 @@ var server = &cobra.Command{
 		// middleware modules
 		middleware.Middleware(app)
 
-		// repository (data layer)
-		flightsRepository := repository.NewFlightsRepository(sqlConnection)
-		seatsRepository := repository.NewSeatRepository(sqlConnection)
-		vouchersRepository := repository.NewVouchersRepository(sqlConnection)
-
 		// controller (business layer)
-		flightsController := controller.NewFlightsController(flightsRepository)
-		seatsController := controller.NewSeatController(seatsRepository)
-		vouchersController := controller.NewVouchersController(vouchersRepository)
+		flightsController := controller.NewFlightsController(repos.flights)
+		seatsController := controller.NewSeatController(repos.seats)
+		vouchersController := controller.NewVouchersController(repos.vouchers, cfg.SeatHoldTTL, newVoucherSigner(cfg), newRedeemLimiter(cfg, repos), repos.clock)
+		campaignsController := controller.NewCampaignsController(repos.campaigns)
+
+		// background jobs
+		go scheduler.New(vouchersController, cfg.SchedulerInterval, repos.clock, nil).Run(cmd.Context())
 
 		// handler (presentation layer)
 		flightsHandler := handler.NewFlightsHandler(flightsController)
 		seatsHandler := handler.NewSeatsHandler(seatsController)
 		vouchersHandler := handler.NewVouchersHandler(vouchersController)
+		campaignsHandler := handler.NewCampaignsHandler(campaignsController)
 
 		// setup routes
-		http.Routes(app, flightsHandler, seatsHandler, vouchersHandler)
+		http.Routes(app, flightsHandler, seatsHandler, vouchersHandler, campaignsHandler)
 
 		app.Listen(":" + cfg.Port)
 	},
 }
 
 func init() {
+	server.Flags().Bool("demo", false, "serve seeded in-memory demo data instead of a database")
+
 	rootCmd.AddCommand(server)
 }
 
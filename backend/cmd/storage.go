@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"backend/config"
+	"backend/internal/clock"
+	"backend/internal/repository"
+	"backend/internal/repository/memory"
+	"backend/pkg/db"
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// memoryDriver keeps all data in process, nothing survives a restart.
+const memoryDriver = "memory"
+
+type repositories struct {
+	flights   repository.FlightsRepository
+	seats     repository.SeatRepository
+	vouchers  repository.VouchersRepository
+	campaigns repository.CampaignsRepository
+	throttle  repository.ThrottleRepository // shared throttle state, nil on memory storage
+	clock     clock.Clock
+}
+
+// openDatabase connects to the SQL database selected by DB_DRIVER.
+func openDatabase(cfg *config.Config) (*sql.DB, db.Driver) {
+	driver, err := db.ParseDriver(cfg.DBDriver)
+	if err != nil {
+		log.Fatalf("Failed to init database connection: %v", err)
+	}
+
+	var sqlConnection *sql.DB
+	switch driver {
+	case db.Postgres:
+		sqlConnection, err = db.NewPostgresConnection(cfg.DatabaseURL)
+	default:
+		sqlConnection, err = db.NewSQLiteConnection(cfg.DBPath)
+	}
+	if err != nil {
+		log.Fatalf("Failed to init database connection: %v", err)
+	}
+
+	return sqlConnection, driver
+}
+
+// openStorage returns the repositories for DB_DRIVER, migrating SQL databases first.
+func openStorage(ctx context.Context, cfg *config.Config) *repositories {
+	if strings.EqualFold(strings.TrimSpace(cfg.DBDriver), memoryDriver) {
+		log.Info("Using in-memory storage, data is lost on restart!")
+		return newMemoryRepositories()
+	}
+
+	sqlConnection, driver := openDatabase(cfg)
+
+	// apply pending schema migrations
+	applied, err := db.NewMigrator(sqlConnection, driver, driver.Migrations(), clock.System{}).Up(ctx)
+	if err != nil {
+		log.Fatalf("Failed to migrate database schema: %v", err)
+	}
+	log.Infof("Successfully migrated database schema, %d migration(s) applied!", len(applied))
+
+	return newRepositories(sqlConnection, driver)
+}
+
+func newRepositories(sqlConnection *sql.DB, driver db.Driver) *repositories {
+	clk := clock.System{}
+	if driver == db.Postgres {
+		return &repositories{
+			flights:   repository.NewPostgresFlightsRepository(sqlConnection),
+			seats:     repository.NewPostgresSeatRepository(sqlConnection, clk),
+			vouchers:  repository.NewPostgresVouchersRepository(sqlConnection, clk),
+			campaigns: repository.NewPostgresCampaignsRepository(sqlConnection, clk),
+			throttle:  repository.NewPostgresThrottleRepository(sqlConnection),
+			clock:     clk,
+		}
+	}
+
+	return &repositories{
+		flights:   repository.NewFlightsRepository(sqlConnection),
+		seats:     repository.NewSeatRepository(sqlConnection, clk),
+		vouchers:  repository.NewVouchersRepository(sqlConnection, clk),
+		campaigns: repository.NewCampaignsRepository(sqlConnection, clk),
+		throttle:  repository.NewThrottleRepository(sqlConnection),
+		clock:     clk,
+	}
+}
+
+func newMemoryRepositories() *repositories {
+	clk := clock.System{}
+	store := memory.NewStore(clk)
+
+	return &repositories{
+		flights:   memory.NewFlightsRepository(store),
+		seats:     memory.NewSeatRepository(store),
+		vouchers:  memory.NewVouchersRepository(store),
+		campaigns: memory.NewCampaignsRepository(store),
+		clock:     clk,
+	}
+}
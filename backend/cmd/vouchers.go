@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"backend/config"
+	"backend/internal/controller"
+	"backend/internal/models"
+	"backend/internal/repository/memory"
+	"backend/internal/scheduler"
+	"backend/internal/seating"
+	"backend/internal/throttle"
+	"backend/internal/vouchercode"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/spf13/cobra"
+)
+
+var vouchersCmd = &cobra.Command{
+	Use:   "vouchers",
+	Short: "Manage vouchers",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var vouchersGenerate = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a batch of unique vouchers for a flight and cabin",
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		flightID, _ := flags.GetInt64("flight")
+		cabin, _ := flags.GetString("cabin")
+		count, _ := flags.GetInt("count")
+		prefix, _ := flags.GetString("prefix")
+		length, _ := flags.GetInt("length")
+		alphabet, _ := flags.GetString("alphabet")
+		checkDigit, _ := flags.GetBool("check-digit")
+		signed, _ := flags.GetBool("signed")
+		expiresAt, _ := flags.GetString("expires-at")
+		seatStrategy, _ := flags.GetString("seat-strategy")
+		format, _ := flags.GetString("format")
+		output, _ := flags.GetString("output")
+		campaignID, _ := flags.GetInt64("campaign")
+		maxRedemptions, _ := flags.GetInt("max-redemptions")
+		groupSize, _ := flags.GetInt("group-size")
+		status, _ := flags.GetString("status")
+
+		if format != "json" && format != "csv" {
+			log.Fatalf("Unknown format %q, use json or csv", format)
+		}
+		if status != models.VoucherIssued && status != models.VoucherActive {
+			log.Fatalf("Unknown status %q, use ISSUED or ACTIVE", status)
+		}
+		if seatStrategy != "" {
+			if _, err := seating.Lookup(seatStrategy); err != nil {
+				log.Fatalf("Invalid seat strategy: %v", err)
+			}
+		}
+
+		cfg := config.LoadConfig()
+		repos := openStorage(cmd.Context(), cfg)
+		batch, err := controller.NewVouchersController(repos.vouchers, cfg.SeatHoldTTL, newVoucherSigner(cfg), nil, repos.clock).CreateBatch(cmd.Context(), &models.CreateVoucherBatch{
+			FlightID:     flightID,
+			Cabin:        cabin,
+			ExpiresAt:    sql.NullString{String: expiresAt, Valid: expiresAt != ""},
+			SeatStrategy: sql.NullString{String: seatStrategy, Valid: seatStrategy != ""},
+			Count:        count,
+			Pattern: models.VoucherCodePattern{
+				Prefix:     prefix,
+				Length:     length,
+				Alphabet:   alphabet,
+				CheckDigit: checkDigit,
+			},
+			Signed:     signed,
+			CampaignID: sql.NullInt64{Int64: campaignID, Valid: campaignID > 0},
+
+			MaxRedemptions: maxRedemptions,
+			GroupSize:      groupSize,
+			Status:         status,
+		})
+		if err != nil {
+			log.Fatalf("Failed to generate vouchers: %v", err)
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				log.Fatalf("Failed to create %s: %v", output, err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if format == "csv" {
+			err = vouchercode.WriteCSV(w, batch)
+		} else {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(batch)
+		}
+		if err != nil {
+			log.Fatalf("Failed to export vouchers: %v", err)
+		}
+	},
+}
+
+var vouchersExpire = &cobra.Command{
+	Use:   "expire",
+	Short: "Mark expired vouchers and release expired seat holds once",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.LoadConfig()
+		repos := openStorage(cmd.Context(), cfg)
+		vc := controller.NewVouchersController(repos.vouchers, cfg.SeatHoldTTL, newVoucherSigner(cfg), nil, repos.clock)
+
+		events, err := scheduler.New(vc, cfg.SchedulerInterval, repos.clock, nil).RunOnce(cmd.Context())
+		if err != nil {
+			log.Fatalf("Failed to expire vouchers: %v", err)
+		}
+		log.Infof("%d change(s)", len(events))
+	},
+}
+
+// newVoucherSigner returns nil when no signing keys are configured.
+func newVoucherSigner(cfg *config.Config) *vouchercode.Signer {
+	if len(cfg.VoucherSigningKeys) == 0 {
+		if cfg.VoucherSignedOnly {
+			log.Fatal("VOUCHER_SIGNED_ONLY needs VOUCHER_SIGNING_KEYS")
+		}
+		return nil
+	}
+
+	signer, err := vouchercode.NewSigner(cfg.VoucherSigningKeys, cfg.VoucherSigningKeyID)
+	if err != nil {
+		log.Fatalf("Failed to load voucher signing keys: %v", err)
+	}
+	signer.RequireSigned = cfg.VoucherSignedOnly
+
+	return signer
+}
+
+// newRedeemLimiter throttles redemptions in process, or in the database with
+// REDEEM_THROTTLE_STORE=db so instances share it.
+func newRedeemLimiter(cfg *config.Config, repos *repositories) *throttle.Limiter {
+	store := memory.NewThrottleRepository()
+	switch cfg.RedeemThrottleStore {
+	case "memory":
+	case "db":
+		if repos.throttle == nil {
+			log.Fatal("REDEEM_THROTTLE_STORE=db needs a SQL database")
+		}
+		store = repos.throttle
+	default:
+		log.Fatalf("Unknown REDEEM_THROTTLE_STORE %q, use memory or db", cfg.RedeemThrottleStore)
+	}
+
+	return throttle.New(throttle.Config{
+		Window:          cfg.RedeemRateWindow,
+		IPLimit:         cfg.RedeemIPLimit,
+		PrefixLimit:     cfg.RedeemPrefixLimit,
+		PrefixLength:    cfg.RedeemPrefixLength,
+		LockoutFailures: cfg.RedeemLockoutFailures,
+		LockoutDuration: cfg.RedeemLockoutDuration,
+		LockoutMax:      cfg.RedeemLockoutMax,
+	}, store, repos.clock)
+}
+
+func init() {
+	flags := vouchersGenerate.Flags()
+	flags.Int64("flight", 0, "id of the flight the vouchers are for")
+	flags.String("cabin", "", "cabin of the vouchers, ECONOMY|BUSINESS|FIRST")
+	flags.Int("count", 0, "number of vouchers to generate")
+	flags.String("prefix", "", "prefix of every code, e.g. GA-")
+	flags.Int("length", vouchercode.DefaultLength, "number of random characters after the prefix")
+	flags.String("alphabet", vouchercode.DefaultAlphabet, "characters the codes are made of")
+	flags.Bool("check-digit", false, "append a check character to every code")
+	flags.Bool("signed", false, "sign the codes with VOUCHER_SIGNING_KEYS instead of using the pattern")
+	flags.String("expires-at", "", "expiry of the vouchers, RFC3339")
+	flags.String("seat-strategy", "", "seat strategy of the vouchers, overrides the flight's")
+	flags.String("format", "json", "export format, json|csv")
+	flags.String("output", "", "file to export to, stdout by default")
+	flags.Int64("campaign", 0, "campaign the vouchers are issued for, its quota and window apply")
+	flags.Int("max-redemptions", 1, "times every voucher can be redeemed")
+	flags.Int("group-size", 1, "adjacent seats assigned per redemption")
+	flags.String("status", models.VoucherActive, "status of the vouchers, ISSUED ones wait to be activated")
+	vouchersGenerate.MarkFlagRequired("flight")
+	vouchersGenerate.MarkFlagRequired("cabin")
+	vouchersGenerate.MarkFlagRequired("count")
+
+	vouchersCmd.AddCommand(vouchersGenerate)
+	vouchersCmd.AddCommand(vouchersExpire)
+	rootCmd.AddCommand(vouchersCmd)
+}
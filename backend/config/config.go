@@ -1,10 +1,30 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
-	Port   string
-	DBPath string
+	Port                  string
+	DBDriver              string            // sqlite|postgres
+	DBPath                string            // SQLite database file
+	DatabaseURL           string            // PostgreSQL connection string
+	SeatHoldTTL           time.Duration     // how long a held seat waits for confirmation
+	SchedulerInterval     time.Duration     // how often vouchers are expired and seat holds reclaimed
+	VoucherSigningKeys    map[string]string // signing keys by key id, none disables signed codes
+	VoucherSigningKeyID   string            // key id new codes are signed with, the first key by default
+	VoucherSignedOnly     bool              // reject plain voucher codes
+	RedeemRateWindow      time.Duration     // window of the redemption rate limits
+	RedeemIPLimit         int               // redemption attempts per client IP and window, 0 disables
+	RedeemPrefixLimit     int               // redemption attempts per code prefix and window, 0 disables
+	RedeemPrefixLength    int               // characters of the code the prefix limit groups by
+	RedeemLockoutFailures int               // unknown codes per client IP and window before a lockout, 0 disables
+	RedeemLockoutDuration time.Duration     // first lockout, doubled on every next one
+	RedeemLockoutMax      time.Duration     // longest lockout
+	RedeemThrottleStore   string            // memory|db, db shares the state between instances
 }
 
 func LoadConfig() *Config {
@@ -13,13 +33,80 @@ func LoadConfig() *Config {
 		port = "8080"
 	}
 
+	dbDriver := os.Getenv("DB_DRIVER")
+	if dbDriver == "" {
+		dbDriver = "sqlite"
+	}
+
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
 		dbPath = "./bookcabin.db"
 	}
 
+	signingKeys, signingKeyID := signingKeysEnv("VOUCHER_SIGNING_KEYS")
+	if id := os.Getenv("VOUCHER_SIGNING_KEY_ID"); id != "" {
+		signingKeyID = id
+	}
+
+	redeemThrottleStore := os.Getenv("REDEEM_THROTTLE_STORE")
+	if redeemThrottleStore == "" {
+		redeemThrottleStore = "memory"
+	}
+
 	return &Config{
-		Port:   port,
-		DBPath: dbPath,
+		Port:                  port,
+		DBDriver:              dbDriver,
+		DBPath:                dbPath,
+		DatabaseURL:           os.Getenv("DATABASE_URL"),
+		SeatHoldTTL:           durationEnv("SEAT_HOLD_TTL", 5*time.Minute),
+		SchedulerInterval:     durationEnv("SCHEDULER_INTERVAL", durationEnv("SEAT_HOLD_SWEEP_INTERVAL", time.Minute)),
+		VoucherSigningKeys:    signingKeys,
+		VoucherSigningKeyID:   signingKeyID,
+		VoucherSignedOnly:     os.Getenv("VOUCHER_SIGNED_ONLY") == "true",
+		RedeemRateWindow:      durationEnv("REDEEM_RATE_WINDOW", time.Minute),
+		RedeemIPLimit:         intEnv("REDEEM_IP_LIMIT", 20),
+		RedeemPrefixLimit:     intEnv("REDEEM_PREFIX_LIMIT", 100),
+		RedeemPrefixLength:    intEnv("REDEEM_PREFIX_LENGTH", 4),
+		RedeemLockoutFailures: intEnv("REDEEM_LOCKOUT_FAILURES", 5),
+		RedeemLockoutDuration: durationEnv("REDEEM_LOCKOUT_DURATION", time.Minute),
+		RedeemLockoutMax:      durationEnv("REDEEM_LOCKOUT_MAX", time.Hour),
+		RedeemThrottleStore:   redeemThrottleStore,
+	}
+}
+
+// durationEnv parses a duration such as 90s or 5m, falling back to def when
+// unset or invalid.
+func durationEnv(name string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+// intEnv parses a non-negative number, falling back to def when unset or invalid.
+func intEnv(name string, def int) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
+
+// signingKeysEnv parses keys such as K2025:secret,K2024:oldsecret and returns
+// them with the id of the first one.
+func signingKeysEnv(name string) (map[string]string, string) {
+	keys := map[string]string{}
+	first := ""
+	for _, pair := range strings.Split(os.Getenv(name), ",") {
+		id, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		if first == "" {
+			first = id
+		}
+		keys[id] = key
 	}
+	return keys, first
 }
@@ -0,0 +1,12 @@
+package dto
+
+type CreateCampaignRequest struct {
+	Name             string  `json:"name" validate:"required"`
+	StartsAt         string  `json:"starts_at" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	EndsAt           string  `json:"ends_at" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	FlightIDs        []int64 `json:"flight_ids" validate:"required,min=1,dive,gt=0"`
+	Cabin            string  `json:"cabin" validate:"required,oneof=ECONOMY BUSINESS FIRST"` // ECONOMY|BUSINESS|FIRST
+	Quota            int     `json:"quota" validate:"required,gt=0"`
+	SeatStrategy     string  `json:"seat_strategy,omitempty" validate:"omitempty,seat_strategy"`
+	VoucherExpiresAt string  `json:"voucher_expires_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
@@ -1,6 +1,26 @@
 package dto
 
 type CreateBulkFlightRequest struct {
-	FlightNumbers []string `json:"flight_numbers" validate:"required,min=1,dive,required"` // e.g. ["GA133", "GA125"]
-	DepDate       string   `json:"dep_date" validate:"required,datetime=2006-01-02"`       // departure date in YYYY-MM-DD format
+	FlightNumbers []string `json:"flight_numbers" validate:"required,min=1,dive,required"`                         // e.g. ["GA133", "GA125"]
+	DepDate       string   `json:"dep_date" validate:"required_without=DepartureAt,omitempty,datetime=2006-01-02"` // departure date in YYYY-MM-DD format
+	SeatStrategy  string   `json:"seat_strategy,omitempty" validate:"omitempty,seat_strategy"`
+	Origin        string   `json:"origin,omitempty" validate:"required_with=Destination,omitempty,airport"` // IATA code, e.g. CGK
+	Destination   string   `json:"destination,omitempty" validate:"required_with=Origin,omitempty,airport"`
+	DepartureAt   string   `json:"departure_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04"` // local time at the origin
+	ArrivalAt     string   `json:"arrival_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04"`   // local time at the destination
+}
+
+// UpdateFlightRequest changes a flight, fields left out keep their value.
+// Changing only dep_date keeps the local departure and arrival times.
+type UpdateFlightRequest struct {
+	FlightNo    *string `json:"flight_no,omitempty" validate:"omitempty,min=1"`
+	DepDate     *string `json:"dep_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Origin      *string `json:"origin,omitempty" validate:"omitempty,airport"`
+	Destination *string `json:"destination,omitempty" validate:"omitempty,airport"`
+	DepartureAt *string `json:"departure_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04"`
+	ArrivalAt   *string `json:"arrival_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04"`
+}
+
+type DeleteFlightQuery struct {
+	Force bool `query:"force"` // delete even when vouchers of the flight hold seats
 }
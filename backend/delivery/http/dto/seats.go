@@ -1,7 +1,34 @@
 package dto
 
 type CreateBulkSeatRequest struct {
-	FlightID int64    `json:"flight_id" validate:"required,gt=0"`
-	Cabin    string   `json:"cabin" validate:"required,oneof=ECONOMY BUSINESS FIRST"`
-	Labels   []string `json:"labels" validate:"required,min=1,dive,required"`
+	FlightID    int64         `json:"flight_id" validate:"required,gt=0"`
+	Cabin       string        `json:"cabin" validate:"required,oneof=ECONOMY BUSINESS FIRST"`
+	Labels      []string      `json:"labels" validate:"omitempty,min=1,dive,required"` // e.g. ["1A", "10-25:ABCDEF", "1A-4D"]
+	Seats       []SeatRequest `json:"seats" validate:"omitempty,dive"`                 // seats with explicit attributes
+	SkipRows    []int         `json:"skip_rows,omitempty" validate:"omitempty,dive,gt=0"`
+	SkipColumns []string      `json:"skip_columns,omitempty" validate:"omitempty,dive,len=1,alpha"`
+}
+
+type SeatRequest struct {
+	Label        string `json:"label" validate:"required"`
+	Position     string `json:"position,omitempty" validate:"omitempty,oneof=WINDOW MIDDLE AISLE"` // derived from the label when empty
+	ExitRow      bool   `json:"exit_row,omitempty"`
+	Bulkhead     bool   `json:"bulkhead,omitempty"`
+	ExtraLegroom bool   `json:"extra_legroom,omitempty"`
+	Reclining    *bool  `json:"reclining,omitempty"` // defaults to true
+	PowerOutlet  bool   `json:"power_outlet,omitempty"`
+	Blocked      bool   `json:"blocked,omitempty"`
+}
+
+type ApplyAircraftRequest struct {
+	FlightID     int64  `json:"flight_id" validate:"required,gt=0"`
+	AircraftType string `json:"aircraft_type" validate:"required,aircraft_type"` // e.g. A320
+}
+
+// AdjacentSeatsQuery is the query string of a group seating dry run.
+type AdjacentSeatsQuery struct {
+	Cabin    string `query:"cabin" validate:"required,oneof=ECONOMY BUSINESS FIRST"`
+	Count    int    `query:"count" validate:"required,gt=0,lte=9"`
+	Strategy string `query:"strategy" validate:"omitempty,seat_strategy"` // defaults to the flight's strategy
+	Limit    int    `query:"limit" validate:"omitempty,gt=0,lte=50"`      // defaults to 5
 }
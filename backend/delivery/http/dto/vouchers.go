@@ -1,24 +1,109 @@
 package dto
 
 type AssignVoucherRequest struct {
-	VoucherCode string `json:"voucher_code" validate:"required,min=1"`
+	VoucherCode string           `json:"voucher_code" validate:"required,min=1"`
+	Preferences *SeatPreferences `json:"preferences,omitempty"`
+	Waitlist    bool             `json:"waitlist,omitempty"` // wait for a seat when the cabin is full
+}
+
+type HoldSeatRequest struct {
+	Preferences *SeatPreferences `json:"preferences,omitempty"`
+}
+
+// SeatChangeRequest says who changes an assignment and why, for the history.
+type SeatChangeRequest struct {
+	ChangedBy string `json:"changed_by" validate:"required"` // e.g. an agent id
+	Reason    string `json:"reason" validate:"required"`
+	FromSeat  string `json:"from_seat,omitempty" validate:"omitempty,seat_label"` // the seat to change, when the voucher has several
+}
+
+// RevokeVoucherRequest says who revokes a voucher and why, for the history.
+type RevokeVoucherRequest struct {
+	ChangedBy string `json:"changed_by" validate:"required"` // e.g. an agent id
+	Reason    string `json:"reason" validate:"required"`
+}
+
+// ChangeStatusRequest moves a voucher to a status set by hand, redemptions
+// and expiry move it on their own.
+type ChangeStatusRequest struct {
+	RevokeVoucherRequest
+	Status string `json:"status" validate:"required,oneof=ACTIVE CHECKED_IN REVOKED CANCELLED"`
+}
+
+type MoveSeatRequest struct {
+	SeatChangeRequest
+	SeatLabel string `json:"seat_label" validate:"required,seat_label"` // e.g. 14C
+}
+
+type ReshuffleSeatRequest struct {
+	SeatChangeRequest
+	Preferences *SeatPreferences `json:"preferences,omitempty"`
+}
+
+type SeatPreferences struct {
+	Position string `json:"position,omitempty" validate:"omitempty,oneof=WINDOW MIDDLE AISLE"`
+	Zone     string `json:"zone,omitempty" validate:"omitempty,oneof=FRONT REAR"`
+	ExitRow  bool   `json:"exit_row,omitempty"`                                  // opt-in to exit row seats
+	NearSeat string `json:"near_seat,omitempty" validate:"omitempty,seat_label"` // e.g. 14C
 }
 
 type CreateNewVoucherRequest struct {
-	Code      string  `json:"code" validate:"required,min=1"`
-	FlightID  int64   `json:"flight_id" validate:"required,gt=0"`
-	Cabin     string  `json:"cabin" validate:"required,oneof=ECONOMY BUSINESS FIRST"` // ECONOMY|BUSINESS|FIRST
-	ExpiresAt *string `json:"expires_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Code         string  `json:"code" validate:"required,min=1"`
+	FlightID     int64   `json:"flight_id" validate:"required,gt=0"`
+	Cabin        string  `json:"cabin" validate:"required,oneof=ECONOMY BUSINESS FIRST"` // ECONOMY|BUSINESS|FIRST
+	ExpiresAt    *string `json:"expires_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	SeatStrategy *string `json:"seat_strategy,omitempty" validate:"omitempty,seat_strategy"`
+	CampaignID   int64   `json:"campaign_id,omitempty" validate:"omitempty,gt=0"` // quota and window checked, defaults taken from it
+
+	// ExpiresBeforeDeparture like "2h" or "24h" expires the voucher that long
+	// before the flight's scheduled departure, following it when it moves.
+	ExpiresBeforeDeparture *string `json:"expires_before_departure,omitempty" validate:"omitempty,excluded_with=ExpiresAt,positive_duration"`
+
+	MaxRedemptions int `json:"max_redemptions,omitempty" validate:"omitempty,gt=0,lte=1000"` // once by default
+	GroupSize      int `json:"group_size,omitempty" validate:"omitempty,gt=0,lte=9"`         // adjacent seats per redemption
+
+	Status string `json:"status,omitempty" validate:"omitempty,oneof=ISSUED ACTIVE"` // ISSUED vouchers wait to be activated, ACTIVE by default
+}
+
+// CreateVoucherBatchRequest mints Count vouchers with codes made of Prefix and
+// Length characters of Alphabet, by default without 0/O and 1/I.
+type CreateVoucherBatchRequest struct {
+	FlightID     int64   `json:"flight_id" validate:"required,gt=0"`
+	Cabin        string  `json:"cabin" validate:"required,oneof=ECONOMY BUSINESS FIRST"` // ECONOMY|BUSINESS|FIRST
+	Count        int     `json:"count" validate:"required,gt=0,lte=10000"`
+	Prefix       string  `json:"prefix,omitempty"`
+	Length       int     `json:"length,omitempty" validate:"omitempty,gte=4,lte=32"`
+	Alphabet     string  `json:"alphabet,omitempty"`
+	CheckDigit   bool    `json:"check_digit,omitempty"`
+	Signed       bool    `json:"signed,omitempty"` // signed codes, the pattern fields are ignored
+	ExpiresAt    *string `json:"expires_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	SeatStrategy *string `json:"seat_strategy,omitempty" validate:"omitempty,seat_strategy"`
+	Format       string  `json:"format,omitempty" validate:"omitempty,oneof=json csv"` // json (default) or csv
+	CampaignID   int64   `json:"campaign_id,omitempty" validate:"omitempty,gt=0"`
+
+	MaxRedemptions int    `json:"max_redemptions,omitempty" validate:"omitempty,gt=0,lte=1000"`
+	GroupSize      int    `json:"group_size,omitempty" validate:"omitempty,gt=0,lte=9"`
+	Status         string `json:"status,omitempty" validate:"omitempty,oneof=ISSUED ACTIVE"`
 }
 
 type Voucher struct {
-	ID         int64   `json:"id"`
-	Code       string  `json:"code"`
-	FlightID   int64   `json:"flight_id"`
-	Cabin      string  `json:"cabin"`
-	ExpiresAt  *string `json:"expires_at,omitempty"` // voucher time periode
-	Redeemed   int64   `json:"redeemed"`             // redeemed is used to flag or mark the voucher is used or not!
-	RedeemedAt *string `json:"redeemed_at,omitempty"`
+	ID           int64   `json:"id"`
+	Code         string  `json:"code"`
+	FlightID     int64   `json:"flight_id"`
+	Cabin        string  `json:"cabin"`
+	ExpiresAt    *string `json:"expires_at,omitempty"` // voucher time periode
+	Redeemed     int64   `json:"redeemed"`             // redeemed is used to flag or mark the voucher is used or not!
+	RedeemedAt   *string `json:"redeemed_at,omitempty"`
+	SeatStrategy *string `json:"seat_strategy,omitempty"`
+	CampaignID   *int64  `json:"campaign_id,omitempty"`
+
+	ExpiresBeforeDeparture *string `json:"expires_before_departure,omitempty"` // e.g. 2h0m0s
+
+	MaxRedemptions int `json:"max_redemptions"`
+	GroupSize      int `json:"group_size"`
+	Redemptions    int `json:"redemptions"`
+
+	Status string `json:"status"` // ISSUED|ACTIVE|REDEEMED|CHECKED_IN|REVOKED|EXPIRED|CANCELLED
 }
 
 type Vouchers = []Voucher
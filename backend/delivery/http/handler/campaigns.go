@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"backend/delivery/http/dto"
+	"backend/delivery/http/validator"
+	"backend/internal/controller"
+	"backend/internal/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CampaignsHandler interface {
+	Create(c *fiber.Ctx) error
+	GetAll(c *fiber.Ctx) error
+	GetReport(c *fiber.Ctx) error
+}
+
+type campaignsHandler struct {
+	cc controller.CampaignsController
+}
+
+func NewCampaignsHandler(campaignsController controller.CampaignsController) CampaignsHandler {
+	return &campaignsHandler{cc: campaignsController}
+}
+
+func (ch *campaignsHandler) Create(c *fiber.Ctx) error {
+	p := new(dto.CreateCampaignRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	// the validator has checked both are RFC3339
+	startsAt, _ := time.Parse(time.RFC3339, p.StartsAt)
+	endsAt, _ := time.Parse(time.RFC3339, p.EndsAt)
+
+	campaign, err := ch.cc.Create(c.Context(), &models.CreateCampaign{
+		Name:             p.Name,
+		StartsAt:         startsAt,
+		EndsAt:           endsAt,
+		FlightIDs:        p.FlightIDs,
+		Cabin:            p.Cabin,
+		Quota:            p.Quota,
+		SeatStrategy:     p.SeatStrategy,
+		VoucherExpiresAt: p.VoucherExpiresAt,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusCreated,
+		Data:       campaign,
+	})
+}
+
+func (ch *campaignsHandler) GetAll(c *fiber.Ctx) error {
+	campaigns, err := ch.cc.GetAll(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       campaigns,
+	})
+}
+
+func (ch *campaignsHandler) GetReport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       "id must be a campaign id",
+		})
+	}
+
+	report, err := ch.cc.Report(c.Context(), int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       report,
+	})
+}
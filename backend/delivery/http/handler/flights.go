@@ -3,6 +3,7 @@ package handler
 import (
 	"backend/delivery/http/dto"
 	"backend/delivery/http/validator"
+	"backend/internal/airports"
 	"backend/internal/controller"
 	"backend/internal/models"
 	"time"
@@ -13,6 +14,10 @@ import (
 type FlightsHandler interface {
 	GetAll(c *fiber.Ctx) error
 	Create(c *fiber.Ctx) error
+	GetByID(c *fiber.Ctx) error
+	GetAirports(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
 }
 
 type flightsHandler struct {
@@ -39,17 +44,26 @@ func (fh *flightsHandler) Create(c *fiber.Ctx) error {
 		})
 	}
 
-	depDate, err := time.Parse("2006-01-02", p.DepDate)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
-			StatusCode: fiber.StatusBadRequest,
-			Data:       "invalid date format, expected YYYY-MM-DD",
-		})
+	var depDate time.Time
+	if p.DepDate != "" {
+		var err error
+		if depDate, err = time.Parse("2006-01-02", p.DepDate); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+				StatusCode: fiber.StatusBadRequest,
+				Data:       "invalid date format, expected YYYY-MM-DD",
+			})
+		}
 	}
 
+	departureAt, arrivalAt := wallClock(&p.DepartureAt), wallClock(&p.ArrivalAt)
 	if err := fh.fc.Create(c.Context(), &models.CreateBulkFlight{
 		FlightNumbers: p.FlightNumbers,
 		DepDate:       depDate,
+		SeatStrategy:  p.SeatStrategy,
+		Origin:        p.Origin,
+		Destination:   p.Destination,
+		DepartureAt:   departureAt,
+		ArrivalAt:     arrivalAt,
 	}); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
 			StatusCode: fiber.StatusBadRequest,
@@ -74,3 +88,134 @@ func (fh *flightsHandler) GetAll(c *fiber.Ctx) error {
 		Data:       flights,
 	})
 }
+
+func (fh *flightsHandler) GetByID(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       "id must be a flight id",
+		})
+	}
+
+	flight, err := fh.fc.GetByID(c.Context(), int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       flight,
+	})
+}
+
+func (fh *flightsHandler) GetAirports(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       airports.All(),
+	})
+}
+
+func (fh *flightsHandler) Update(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       "id must be a flight id",
+		})
+	}
+
+	p := new(dto.UpdateFlightRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	uf := &models.UpdateFlight{
+		ID:          int64(id),
+		FlightNo:    p.FlightNo,
+		Origin:      p.Origin,
+		Destination: p.Destination,
+		DepartureAt: wallClock(p.DepartureAt),
+		ArrivalAt:   wallClock(p.ArrivalAt),
+	}
+	if p.DepDate != nil {
+		depDate, err := time.Parse("2006-01-02", *p.DepDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+				StatusCode: fiber.StatusBadRequest,
+				Data:       "invalid date format, expected YYYY-MM-DD",
+			})
+		}
+		uf.DepDate = &depDate
+	}
+
+	flight, err := fh.fc.Update(c.Context(), uf)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       flight,
+	})
+}
+
+func (fh *flightsHandler) Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       "id must be a flight id",
+		})
+	}
+
+	q := new(dto.DeleteFlightQuery)
+	if err := c.QueryParser(q); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	deleted, err := fh.fc.Delete(c.Context(), int64(id), q.Force)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       deleted,
+	})
+}
+
+// wallClock parses a validated local time like 2025-10-04T08:30, nil when
+// it was left out.
+func wallClock(local *string) *time.Time {
+	if local == nil || *local == "" {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02T15:04", *local)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -3,8 +3,10 @@ package handler
 import (
 	"backend/delivery/http/dto"
 	"backend/delivery/http/validator"
+	"backend/internal/aircraft"
 	"backend/internal/controller"
 	"backend/internal/models"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,6 +14,10 @@ import (
 type SeatsHandler interface {
 	GetAll(c *fiber.Ctx) error
 	Create(c *fiber.Ctx) error
+	GetAircraft(c *fiber.Ctx) error
+	ApplyAircraft(c *fiber.Ctx) error
+	GetSeatMap(c *fiber.Ctx) error
+	GetAdjacentSeats(c *fiber.Ctx) error
 }
 
 type seatsHandler struct {
@@ -40,10 +46,41 @@ func (sh *seatsHandler) Create(c *fiber.Ctx) error {
 		})
 	}
 
+	if len(p.Labels) == 0 && len(p.Seats) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       "Labels is required",
+		})
+	}
+
+	seats := make(models.Seats, 0, len(p.Seats))
+	for _, s := range p.Seats {
+		reclining := true
+		if s.Reclining != nil {
+			reclining = *s.Reclining
+		}
+
+		seats = append(seats, models.Seat{
+			Label: s.Label,
+			SeatAttributes: models.SeatAttributes{
+				Position:     s.Position,
+				ExitRow:      s.ExitRow,
+				Bulkhead:     s.Bulkhead,
+				ExtraLegroom: s.ExtraLegroom,
+				Reclining:    reclining,
+				PowerOutlet:  s.PowerOutlet,
+				Blocked:      s.Blocked,
+			},
+		})
+	}
+
 	if err := sh.sc.Create(c.Context(), &models.CreateBulkSeat{
-		FlightID: p.FlightID,
-		Cabin:    p.Cabin,
-		Labels:   p.Labels,
+		FlightID:    p.FlightID,
+		Cabin:       p.Cabin,
+		Labels:      p.Labels,
+		Seats:       seats,
+		SkipRows:    p.SkipRows,
+		SkipColumns: p.SkipColumns,
 	}); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
 			StatusCode: fiber.StatusBadRequest,
@@ -69,3 +106,107 @@ func (sh *seatsHandler) GetAll(c *fiber.Ctx) error {
 		Data:       seats,
 	})
 }
+
+func (sh *seatsHandler) GetAircraft(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       aircraft.Templates(),
+	})
+}
+
+func (sh *seatsHandler) ApplyAircraft(c *fiber.Ctx) error {
+	p := new(dto.ApplyAircraftRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	created, err := sh.sc.ApplyAircraft(c.Context(), p.FlightID, p.AircraftType)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusCreated,
+		Data:       fmt.Sprintf("success to create %d seats from %s", created, p.AircraftType),
+	})
+}
+
+func (sh *seatsHandler) GetSeatMap(c *fiber.Ctx) error {
+	flightID, err := c.ParamsInt("id")
+	if err != nil || flightID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       "id must be a flight id",
+		})
+	}
+
+	seatMap, err := sh.sc.SeatMap(c.Context(), int64(flightID))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       seatMap,
+	})
+}
+
+func (sh *seatsHandler) GetAdjacentSeats(c *fiber.Ctx) error {
+	flightID, err := c.ParamsInt("id")
+	if err != nil || flightID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       "id must be a flight id",
+		})
+	}
+
+	q := new(dto.AdjacentSeatsQuery)
+	if err := c.QueryParser(q); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(q); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	adjacent, err := sh.sc.FindAdjacent(c.Context(), &models.FindAdjacentSeats{
+		FlightID: int64(flightID),
+		Cabin:    q.Cabin,
+		Count:    q.Count,
+		Strategy: q.Strategy,
+		Limit:    q.Limit,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       adjacent,
+	})
+}
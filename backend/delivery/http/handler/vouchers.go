@@ -5,15 +5,36 @@ import (
 	"backend/delivery/http/validator"
 	"backend/internal/controller"
 	"backend/internal/models"
+	"backend/internal/throttle"
+	"backend/internal/vouchercode"
 	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type VouchersHandler interface {
 	Create(c *fiber.Ctx) error
+	CreateBatch(c *fiber.Ctx) error
 	Assigns(c *fiber.Ctx) error
 	GetAll(c *fiber.Ctx) error
+	GetByCode(c *fiber.Ctx) error
+	Hold(c *fiber.Ctx) error
+	ConfirmHold(c *fiber.Ctx) error
+	ReleaseHold(c *fiber.Ctx) error
+	Unassign(c *fiber.Ctx) error
+	Move(c *fiber.Ctx) error
+	Reshuffle(c *fiber.Ctx) error
+	GetSeatChanges(c *fiber.Ctx) error
+	GetWaitlist(c *fiber.Ctx) error
+	Revoke(c *fiber.Ctx) error
+	ChangeStatus(c *fiber.Ctx) error
+	GetStatusChanges(c *fiber.Ctx) error
 }
 
 type vouchersHandler struct {
@@ -47,11 +68,29 @@ func (vh *vouchersHandler) Create(c *fiber.Ctx) error {
 		expiresAt = sql.NullString{String: *p.ExpiresAt, Valid: true}
 	}
 
+	var seatStrategy sql.NullString
+	if p.SeatStrategy != nil && *p.SeatStrategy != "" {
+		seatStrategy = sql.NullString{String: *p.SeatStrategy, Valid: true}
+	}
+
+	var expiresBefore time.Duration
+	if p.ExpiresBeforeDeparture != nil && *p.ExpiresBeforeDeparture != "" {
+		expiresBefore, _ = time.ParseDuration(*p.ExpiresBeforeDeparture) // checked by the validator
+	}
+
 	if err := vh.vc.Create(c.Context(), &models.CreateNewVoucher{
-		Code:      p.Code,
-		FlightID:  p.FlightID,
-		Cabin:     p.Cabin,
-		ExpiresAt: expiresAt,
+		Code:         p.Code,
+		FlightID:     p.FlightID,
+		Cabin:        p.Cabin,
+		ExpiresAt:    expiresAt,
+		SeatStrategy: seatStrategy,
+		CampaignID:   campaignID(p.CampaignID),
+
+		ExpiresBeforeDeparture: expiresBefore,
+
+		MaxRedemptions: p.MaxRedemptions,
+		GroupSize:      p.GroupSize,
+		Status:         p.Status,
 	}); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
 			StatusCode: fiber.StatusBadRequest,
@@ -65,6 +104,71 @@ func (vh *vouchersHandler) Create(c *fiber.Ctx) error {
 	})
 }
 
+func (vh *vouchersHandler) CreateBatch(c *fiber.Ctx) error {
+	p := new(dto.CreateVoucherBatchRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	var expiresAt sql.NullString
+	if p.ExpiresAt != nil && *p.ExpiresAt != "" {
+		expiresAt = sql.NullString{String: *p.ExpiresAt, Valid: true}
+	}
+
+	var seatStrategy sql.NullString
+	if p.SeatStrategy != nil && *p.SeatStrategy != "" {
+		seatStrategy = sql.NullString{String: *p.SeatStrategy, Valid: true}
+	}
+
+	batch, err := vh.vc.CreateBatch(c.Context(), &models.CreateVoucherBatch{
+		FlightID:     p.FlightID,
+		Cabin:        p.Cabin,
+		ExpiresAt:    expiresAt,
+		SeatStrategy: seatStrategy,
+		Count:        p.Count,
+
+		MaxRedemptions: p.MaxRedemptions,
+		GroupSize:      p.GroupSize,
+		Status:         p.Status,
+		Pattern: models.VoucherCodePattern{
+			Prefix:     p.Prefix,
+			Length:     p.Length,
+			Alphabet:   p.Alphabet,
+			CheckDigit: p.CheckDigit,
+		},
+		Signed:     p.Signed,
+		CampaignID: campaignID(p.CampaignID),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if p.Format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="vouchers-%d-%s.csv"`, batch.FlightID, strings.ToLower(batch.Cabin)))
+		c.Status(fiber.StatusCreated)
+		return vouchercode.WriteCSV(c, batch)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusCreated,
+		Data:       batch,
+	})
+}
+
 func (vh *vouchersHandler) Assigns(c *fiber.Ctx) error {
 	p := new(dto.AssignVoucherRequest)
 	if err := c.BodyParser(&p); err != nil {
@@ -83,20 +187,268 @@ func (vh *vouchersHandler) Assigns(c *fiber.Ctx) error {
 
 	voucher, err := vh.vc.Assigns(c.Context(), &models.AssignsRandomVoucher{
 		VoucherCode: p.VoucherCode,
+		Preferences: toSeatPreferences(p.Preferences),
+		ClientIP:    c.IP(),
+		Waitlist:    p.Waitlist,
 	})
 	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusCreated,
+		Data:       voucher,
+	})
+}
+
+// redeemError answers a throttled redemption with 429 and when to retry, a
+// voucher put on the waitlist with 202 and its place, any other error with 400.
+func redeemError(c *fiber.Ctx, err error) error {
+	var waitlisted *models.WaitlistedError
+	if errors.As(err, &waitlisted) {
+		return c.Status(fiber.StatusAccepted).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusAccepted,
+			Data:       waitlisted.Entry,
+		})
+	}
+
+	var limited *throttle.LimitedError
+	if errors.As(err, &limited) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		return c.Status(fiber.StatusTooManyRequests).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusTooManyRequests,
+			Data:       err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusBadRequest,
+		Data:       err.Error(),
+	})
+}
+
+func toSeatPreferences(p *dto.SeatPreferences) *models.SeatPreferences {
+	if p == nil {
+		return nil
+	}
+
+	return &models.SeatPreferences{
+		Position: p.Position,
+		Zone:     p.Zone,
+		ExitRow:  p.ExitRow,
+		NearSeat: p.NearSeat,
+	}
+}
+
+func (vh *vouchersHandler) Hold(c *fiber.Ctx) error {
+	p := new(dto.HoldSeatRequest)
+	// the body is optional, preferences only
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&p); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+				StatusCode: fiber.StatusBadRequest,
+				Data:       err.Error(),
+			})
+		}
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
 			StatusCode: fiber.StatusBadRequest,
-			Data:       err.Error(),
+			Data:       validator.FormatValidationErrors(err),
 		})
 	}
 
+	hold, err := vh.vc.Hold(c.Context(), &models.AssignsRandomVoucher{
+		VoucherCode: c.Params("code"),
+		Preferences: toSeatPreferences(p.Preferences),
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(dto.JsonResponses{
 		StatusCode: fiber.StatusCreated,
+		Data:       hold,
+	})
+}
+
+func (vh *vouchersHandler) ConfirmHold(c *fiber.Ctx) error {
+	voucher, err := vh.vc.ConfirmHold(c.Context(), &models.AssignsRandomVoucher{
+		VoucherCode: c.Params("code"),
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusCreated,
+		Data:       voucher,
+	})
+}
+
+func (vh *vouchersHandler) ReleaseHold(c *fiber.Ctx) error {
+	if err := vh.vc.ReleaseHold(c.Context(), &models.AssignsRandomVoucher{
+		VoucherCode: c.Params("code"),
+		ClientIP:    c.IP(),
+	}); err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       "success to release the seat hold",
+	})
+}
+
+func (vh *vouchersHandler) Unassign(c *fiber.Ctx) error {
+	p := new(dto.SeatChangeRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	change, err := vh.vc.Unassign(c.Context(), &models.ChangeVoucherSeat{
+		VoucherCode: c.Params("code"),
+		ChangedBy:   p.ChangedBy,
+		Reason:      p.Reason,
+		FromSeat:    p.FromSeat,
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       change,
+	})
+}
+
+func (vh *vouchersHandler) Move(c *fiber.Ctx) error {
+	p := new(dto.MoveSeatRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	voucher, err := vh.vc.Move(c.Context(), &models.ChangeVoucherSeat{
+		VoucherCode: c.Params("code"),
+		SeatLabel:   p.SeatLabel,
+		ChangedBy:   p.ChangedBy,
+		Reason:      p.Reason,
+		FromSeat:    p.FromSeat,
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
 		Data:       voucher,
 	})
 }
 
+func (vh *vouchersHandler) Reshuffle(c *fiber.Ctx) error {
+	p := new(dto.ReshuffleSeatRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	voucher, err := vh.vc.Reshuffle(c.Context(), &models.ChangeVoucherSeat{
+		VoucherCode: c.Params("code"),
+		Preferences: toSeatPreferences(p.Preferences),
+		ChangedBy:   p.ChangedBy,
+		Reason:      p.Reason,
+		FromSeat:    p.FromSeat,
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       voucher,
+	})
+}
+
+func (vh *vouchersHandler) GetSeatChanges(c *fiber.Ctx) error {
+	changes, err := vh.vc.GetSeatChanges(c.Context(), &models.AssignsRandomVoucher{
+		VoucherCode: c.Params("code"),
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       changes,
+	})
+}
+
+func (vh *vouchersHandler) GetByCode(c *fiber.Ctx) error {
+	status, err := vh.vc.GetByCode(c.Context(), &models.AssignsRandomVoucher{
+		VoucherCode: c.Params("code"),
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       status,
+	})
+}
+
+func (vh *vouchersHandler) GetWaitlist(c *fiber.Ctx) error {
+	entry, err := vh.vc.GetWaitlist(c.Context(), &models.AssignsRandomVoucher{
+		VoucherCode: c.Params("code"),
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       entry,
+	})
+}
+
 func (vh *vouchersHandler) GetAll(c *fiber.Ctx) error {
 	rows, err := vh.vc.GetAll(c.Context())
 
@@ -117,6 +469,11 @@ func (vh *vouchersHandler) GetAll(c *fiber.Ctx) error {
 				Cabin:      v.Cabin,
 				Redeemed:   v.Redeemed,
 				RedeemedAt: v.RedeemedAt,
+
+				MaxRedemptions: v.MaxRedemptions,
+				GroupSize:      v.GroupSize,
+				Redemptions:    v.Redemptions,
+				Status:         v.Status,
 			}
 
 			if v.ExpiresAt.Valid {
@@ -124,6 +481,21 @@ func (vh *vouchersHandler) GetAll(c *fiber.Ctx) error {
 				voucher.ExpiresAt = &expiresAt
 			}
 
+			if v.SeatStrategy.Valid {
+				seatStrategy := v.SeatStrategy.String
+				voucher.SeatStrategy = &seatStrategy
+			}
+
+			if v.ExpiresBeforeDeparture.Valid {
+				expiresBefore := (time.Duration(v.ExpiresBeforeDeparture.Int64) * time.Second).String()
+				voucher.ExpiresBeforeDeparture = &expiresBefore
+			}
+
+			if v.CampaignID.Valid {
+				campaignID := v.CampaignID.Int64
+				voucher.CampaignID = &campaignID
+			}
+
 			vouchers = append(vouchers, voucher)
 		}
 	}
@@ -133,3 +505,79 @@ func (vh *vouchersHandler) GetAll(c *fiber.Ctx) error {
 		Data:       vouchers,
 	})
 }
+
+// campaignID maps an absent campaign_id, sent as 0, to NULL.
+func campaignID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
+
+func (vh *vouchersHandler) Revoke(c *fiber.Ctx) error {
+	p := new(dto.RevokeVoucherRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	return vh.changeStatus(c, models.VoucherRevoked, p)
+}
+
+func (vh *vouchersHandler) ChangeStatus(c *fiber.Ctx) error {
+	p := new(dto.ChangeStatusRequest)
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	return vh.changeStatus(c, p.Status, &p.RevokeVoucherRequest)
+}
+
+func (vh *vouchersHandler) changeStatus(c *fiber.Ctx, status string, p *dto.RevokeVoucherRequest) error {
+	if err := validator.ValidateStruct(p); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.JsonResponses{
+			StatusCode: fiber.StatusBadRequest,
+			Data:       validator.FormatValidationErrors(err),
+		})
+	}
+
+	change, err := vh.vc.ChangeStatus(c.Context(), &models.ChangeVoucherStatus{
+		VoucherCode: c.Params("code"),
+		To:          status,
+		ChangedBy:   p.ChangedBy,
+		Reason:      p.Reason,
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       change,
+	})
+}
+
+func (vh *vouchersHandler) GetStatusChanges(c *fiber.Ctx) error {
+	changes, err := vh.vc.GetStatusChanges(c.Context(), &models.AssignsRandomVoucher{
+		VoucherCode: c.Params("code"),
+		ClientIP:    c.IP(),
+	})
+	if err != nil {
+		return redeemError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.JsonResponses{
+		StatusCode: fiber.StatusOK,
+		Data:       changes,
+	})
+}
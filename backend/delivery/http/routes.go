@@ -11,6 +11,7 @@ func Routes(
 	flightsHandler handler.FlightsHandler,
 	seatsHandler handler.SeatsHandler,
 	vouchersHandler handler.VouchersHandler,
+	campaignsHandler handler.CampaignsHandler,
 ) {
 
 	api := app.Group("/api")
@@ -20,15 +21,42 @@ func Routes(
 	flights := v1.Group("/flights")
 	flights.Post("/", flightsHandler.Create)
 	flights.Get("/", flightsHandler.GetAll)
+	flights.Get("/airports", flightsHandler.GetAirports)
+	flights.Get("/:id", flightsHandler.GetByID)
+	flights.Patch("/:id", flightsHandler.Update)
+	flights.Delete("/:id", flightsHandler.Delete)
+	flights.Get("/:id/seatmap", seatsHandler.GetSeatMap)
+	flights.Get("/:id/adjacent-seats", seatsHandler.GetAdjacentSeats)
 
 	// seats
 	seats := v1.Group("/seats")
 	seats.Get("/", seatsHandler.GetAll)
 	seats.Post("/", seatsHandler.Create)
+	seats.Get("/aircraft", seatsHandler.GetAircraft)
+	seats.Post("/aircraft", seatsHandler.ApplyAircraft)
 
 	// vouchers
 	vouchers := v1.Group("/vouchers")
 	vouchers.Post("/", vouchersHandler.Create)
 	vouchers.Get("/", vouchersHandler.GetAll)
 	vouchers.Post("/assigns", vouchersHandler.Assigns)
+	vouchers.Post("/batch", vouchersHandler.CreateBatch)
+	vouchers.Get("/:code", vouchersHandler.GetByCode)
+	vouchers.Post("/:code/hold", vouchersHandler.Hold)
+	vouchers.Post("/:code/hold/confirm", vouchersHandler.ConfirmHold)
+	vouchers.Delete("/:code/hold", vouchersHandler.ReleaseHold)
+	vouchers.Delete("/:code/assignment", vouchersHandler.Unassign)
+	vouchers.Post("/:code/assignment/move", vouchersHandler.Move)
+	vouchers.Post("/:code/assignment/reshuffle", vouchersHandler.Reshuffle)
+	vouchers.Get("/:code/assignment/changes", vouchersHandler.GetSeatChanges)
+	vouchers.Get("/:code/waitlist", vouchersHandler.GetWaitlist)
+	vouchers.Post("/:code/revoke", vouchersHandler.Revoke)
+	vouchers.Post("/:code/status", vouchersHandler.ChangeStatus)
+	vouchers.Get("/:code/status/changes", vouchersHandler.GetStatusChanges)
+
+	// campaigns
+	campaigns := v1.Group("/campaigns")
+	campaigns.Post("/", campaignsHandler.Create)
+	campaigns.Get("/", campaignsHandler.GetAll)
+	campaigns.Get("/:id/report", campaignsHandler.GetReport)
 }
@@ -1,8 +1,12 @@
 package validator
 
 import (
+	"backend/internal/aircraft"
+	"backend/internal/airports"
+	"backend/internal/seating"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -11,6 +15,31 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New(validator.WithRequiredStructEnabled())
+
+	validate.RegisterValidation("seat_strategy", func(fl validator.FieldLevel) bool {
+		_, err := seating.Lookup(fl.Field().String())
+		return err == nil
+	})
+
+	validate.RegisterValidation("seat_label", func(fl validator.FieldLevel) bool {
+		_, _, ok := seating.ParseLabel(fl.Field().String())
+		return ok
+	})
+
+	validate.RegisterValidation("aircraft_type", func(fl validator.FieldLevel) bool {
+		_, err := aircraft.Lookup(fl.Field().String())
+		return err == nil
+	})
+
+	validate.RegisterValidation("positive_duration", func(fl validator.FieldLevel) bool {
+		d, err := time.ParseDuration(fl.Field().String())
+		return err == nil && d > 0
+	})
+
+	validate.RegisterValidation("airport", func(fl validator.FieldLevel) bool {
+		_, err := airports.Lookup(fl.Field().String())
+		return err == nil
+	})
 }
 
 func ValidateStruct(s any) error {
@@ -34,6 +63,8 @@ func formatFieldError(e validator.FieldError) string {
 	switch e.Tag() {
 	case "required":
 		return fmt.Sprintf("%s is required", field)
+	case "required_without":
+		return fmt.Sprintf("%s is required without %s", field, e.Param())
 	case "min":
 		return fmt.Sprintf("%s must have at least %s items", field, e.Param())
 	case "max":
@@ -50,6 +81,20 @@ func formatFieldError(e validator.FieldError) string {
 		return fmt.Sprintf("%s must be one of: %s", field, e.Param())
 	case "datetime":
 		return fmt.Sprintf("%s must be in format %s", field, e.Param())
+	case "seat_strategy":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(seating.Names(), " "))
+	case "seat_label":
+		return fmt.Sprintf("%s must be a seat label like 12A", field)
+	case "aircraft_type":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(aircraft.Types(), " "))
+	case "airport":
+		return fmt.Sprintf("%s must be a known IATA airport code, see /api/v1/flights/airports", field)
+	case "excluded_with":
+		return fmt.Sprintf("%s can not be given with %s", field, e.Param())
+	case "positive_duration":
+		return fmt.Sprintf("%s must be a duration like 2h or 90m", field)
+	case "required_with":
+		return fmt.Sprintf("%s is required with %s", field, e.Param())
 	case "email":
 		return fmt.Sprintf("%s must be a valid email", field)
 	case "url":
@@ -0,0 +1,200 @@
+// Package aircraft holds the registry of aircraft configuration templates used
+// to generate a flight's full seat map in one go.
+package aircraft
+
+import (
+	"backend/internal/models"
+	"backend/internal/seating"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+//go:embed templates/*.json
+var templateFiles embed.FS
+
+var cabins = []string{"FIRST", "BUSINESS", "ECONOMY"}
+
+// Template is an aircraft type's seating configuration, one layout per cabin.
+type Template struct {
+	Type   string        `json:"type"` // e.g. A320
+	Name   string        `json:"name"`
+	Cabins []CabinLayout `json:"cabins"`
+}
+
+// CabinLayout describes the rows of a cabin. Layout lists the column letters
+// left to right with a dash for every aisle, e.g. "ABC-DEF" for 3-3.
+type CabinLayout struct {
+	Cabin            string   `json:"cabin"`
+	Rows             RowRange `json:"rows"`
+	Layout           string   `json:"layout"`
+	SkipRows         []int    `json:"skip_rows,omitempty"` // e.g. no row 13
+	ExitRows         []int    `json:"exit_rows,omitempty"`
+	BulkheadRows     []int    `json:"bulkhead_rows,omitempty"`
+	ExtraLegroomRows []int    `json:"extra_legroom_rows,omitempty"`
+	NonRecliningRows []int    `json:"non_reclining_rows,omitempty"` // e.g. the row in front of an exit
+	PowerOutlet      bool     `json:"power_outlet,omitempty"`
+}
+
+type RowRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+var templates = map[string]Template{}
+
+func init() {
+	files, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, f := range files {
+		data, err := templateFiles.ReadFile(path.Join("templates", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var t Template
+		if err := json.Unmarshal(data, &t); err != nil {
+			panic(fmt.Sprintf("aircraft template %s: %v", f.Name(), err))
+		}
+		if err := t.Validate(); err != nil {
+			panic(fmt.Sprintf("aircraft template %s: %v", f.Name(), err))
+		}
+		templates[strings.ToUpper(t.Type)] = t
+	}
+}
+
+// Types lists the registered aircraft types, e.g. for validation messages.
+func Types() []string {
+	types := make([]string, 0, len(templates))
+	for t := range templates {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Templates lists the registered templates ordered by type.
+func Templates() []Template {
+	list := make([]Template, 0, len(templates))
+	for _, t := range Types() {
+		list = append(list, templates[t])
+	}
+	return list
+}
+
+func Lookup(aircraftType string) (Template, error) {
+	t, ok := templates[strings.ToUpper(strings.TrimSpace(aircraftType))]
+	if !ok {
+		return Template{}, fmt.Errorf("unknown aircraft type %q", aircraftType)
+	}
+	return t, nil
+}
+
+// Validate checks the cabins are known, the layouts parse and no row is used
+// by two cabins.
+func (t Template) Validate() error {
+	if t.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+	if len(t.Cabins) == 0 {
+		return fmt.Errorf("%s has no cabins", t.Type)
+	}
+
+	taken := map[int]string{}
+	for _, c := range t.Cabins {
+		if !slices.Contains(cabins, c.Cabin) {
+			return fmt.Errorf("%s has unknown cabin %q", t.Type, c.Cabin)
+		}
+		if c.Rows.From <= 0 || c.Rows.To < c.Rows.From {
+			return fmt.Errorf("%s %s has invalid rows %d-%d", t.Type, c.Cabin, c.Rows.From, c.Rows.To)
+		}
+		if _, err := parseLayout(c.Layout); err != nil {
+			return fmt.Errorf("%s %s: %v", t.Type, c.Cabin, err)
+		}
+		for row := c.Rows.From; row <= c.Rows.To; row++ {
+			if other, ok := taken[row]; ok {
+				return fmt.Errorf("%s row %d is in both %s and %s", t.Type, row, other, c.Cabin)
+			}
+			taken[row] = c.Cabin
+		}
+	}
+
+	return nil
+}
+
+// Seats generates every seat of the aircraft with its cabin and attributes.
+func (t Template) Seats() models.Seats {
+	var seats models.Seats
+	for _, c := range t.Cabins {
+		columns, _ := parseLayout(c.Layout)
+
+		for row := c.Rows.From; row <= c.Rows.To; row++ {
+			if slices.Contains(c.SkipRows, row) {
+				continue
+			}
+
+			for _, col := range columns {
+				seats = append(seats, models.Seat{
+					Label: fmt.Sprintf("%d%s", row, col.letter),
+					Cabin: c.Cabin,
+					SeatAttributes: models.SeatAttributes{
+						Row:          row,
+						Column:       col.letter,
+						Position:     col.position,
+						ExitRow:      slices.Contains(c.ExitRows, row),
+						Bulkhead:     slices.Contains(c.BulkheadRows, row),
+						ExtraLegroom: slices.Contains(c.ExtraLegroomRows, row),
+						Reclining:    !slices.Contains(c.NonRecliningRows, row),
+						PowerOutlet:  c.PowerOutlet,
+					},
+				})
+			}
+		}
+	}
+
+	return seats
+}
+
+type column struct {
+	letter   string
+	position string
+}
+
+// parseLayout resolves the position of every column: the outer seats are
+// windows, the seats next to a dash are aisles and the rest are middles.
+func parseLayout(layout string) ([]column, error) {
+	groups := strings.Split(strings.ToUpper(strings.TrimSpace(layout)), "-")
+
+	seen := map[rune]bool{}
+	var columns []column
+	for g, group := range groups {
+		if group == "" {
+			return nil, fmt.Errorf("invalid layout %q", layout)
+		}
+
+		for i, r := range group {
+			if r < 'A' || r > 'Z' || seen[r] {
+				return nil, fmt.Errorf("invalid layout %q", layout)
+			}
+			seen[r] = true
+
+			position := seating.PositionMiddle
+			switch {
+			case g == 0 && i == 0, g == len(groups)-1 && i == len(group)-1:
+				position = seating.PositionWindow
+			case i == 0, i == len(group)-1:
+				position = seating.PositionAisle
+			}
+			columns = append(columns, column{letter: string(r), position: position})
+		}
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,87 @@
+// Package airports holds the bundled airport reference table, mapping IATA
+// codes to the airport's IANA timezone so flight times can be kept local.
+package airports
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// the timezone database ships with the binary, hosts without one still
+	// resolve every airport
+	_ "time/tzdata"
+)
+
+//go:embed airports.csv
+var airportsFile string
+
+type Airport struct {
+	Code     string `json:"code"` // IATA code, e.g. CGK
+	Name     string `json:"name"`
+	City     string `json:"city"`
+	Country  string `json:"country"`  // ISO 3166 alpha-2 code
+	Timezone string `json:"timezone"` // IANA name, e.g. Asia/Jakarta
+
+	location *time.Location
+}
+
+var airports = map[string]Airport{}
+
+func init() {
+	records, err := csv.NewReader(strings.NewReader(airportsFile)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("airports: %v", err))
+	}
+
+	for _, r := range records[1:] {
+		a := Airport{Code: r[0], Name: r[1], City: r[2], Country: r[3], Timezone: r[4]}
+		if a.location, err = time.LoadLocation(a.Timezone); err != nil {
+			panic(fmt.Sprintf("airport %s: %v", a.Code, err))
+		}
+		if _, ok := airports[a.Code]; ok {
+			panic(fmt.Sprintf("airport %s is listed twice", a.Code))
+		}
+		airports[a.Code] = a
+	}
+}
+
+// Codes lists the known IATA codes, e.g. for validation messages.
+func Codes() []string {
+	codes := make([]string, 0, len(airports))
+	for code := range airports {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// All lists the airports ordered by code.
+func All() []Airport {
+	list := make([]Airport, 0, len(airports))
+	for _, code := range Codes() {
+		list = append(list, airports[code])
+	}
+	return list
+}
+
+func Lookup(code string) (Airport, error) {
+	a, ok := airports[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Airport{}, fmt.Errorf("unknown airport %q", code)
+	}
+	return a, nil
+}
+
+// Location is the airport's timezone.
+func (a Airport) Location() *time.Location {
+	return a.location
+}
+
+// At places a wall clock time read at the airport, whatever the location of
+// t, in the airport's timezone.
+func (a Airport) At(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, a.location)
+}
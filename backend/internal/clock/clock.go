@@ -0,0 +1,61 @@
+// Package clock tells the time to everything that depends on it, so expiry
+// and timestamps can be tested without waiting for the wall clock.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the wall clock, in UTC.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// OrSystem returns c, or the wall clock when c is nil.
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System{}
+	}
+	return c
+}
+
+// Format writes t the one way timestamps are stored: RFC3339 in UTC, to the
+// second, so they compare as text.
+func Format(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Fake is a clock set and moved by hand, safe for concurrent use.
+type Fake struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func NewFake(t time.Time) *Fake {
+	return &Fake{t: t.UTC()}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.t
+}
+
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = t.UTC()
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = f.t.Add(d)
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+)
+
+type CampaignsController interface {
+	Create(ctx context.Context, cc *models.CreateCampaign) (*models.Campaign, error)
+	GetAll(ctx context.Context) (models.Campaigns, error)
+	Report(ctx context.Context, id int64) (*models.CampaignReport, error)
+}
+
+type campaignsController struct {
+	cr repository.CampaignsRepository
+}
+
+func NewCampaignsController(cr repository.CampaignsRepository) CampaignsController {
+	return &campaignsController{
+		cr: cr,
+	}
+}
+
+func (cc *campaignsController) Create(ctx context.Context, campaign *models.CreateCampaign) (*models.Campaign, error) {
+	if !campaign.EndsAt.After(campaign.StartsAt) {
+		return nil, errors.New("campaign must end after it starts")
+	}
+	if campaign.Quota <= 0 {
+		return nil, errors.New("campaign quota must be greater than 0")
+	}
+	voucherExpiresAt, err := utcTime(campaign.VoucherExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	campaign.VoucherExpiresAt = voucherExpiresAt
+
+	c, err := cc.cr.Create(ctx, campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (cc *campaignsController) GetAll(ctx context.Context) (models.Campaigns, error) {
+	campaigns, err := cc.cr.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+func (cc *campaignsController) Report(ctx context.Context, id int64) (*models.CampaignReport, error) {
+	report, err := cc.cr.Report(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -1,14 +1,20 @@
 package controller
 
 import (
+	"backend/internal/airports"
 	"backend/internal/models"
 	"backend/internal/repository"
 	"context"
+	"errors"
+	"time"
 )
 
 type FlightsController interface {
 	Create(ctx context.Context, flights *models.CreateBulkFlight) error
 	GetAll(ctx context.Context) (models.Flights, error)
+	GetByID(ctx context.Context, id int64) (*models.Flight, error)
+	Update(ctx context.Context, uf *models.UpdateFlight) (*models.Flight, error)
+	Delete(ctx context.Context, id int64, force bool) (*models.DeletedFlight, error)
 }
 
 type flightsController struct {
@@ -22,6 +28,17 @@ func NewFlightsController(fr repository.FlightsRepository) FlightsController {
 }
 
 func (fc *flightsController) Create(ctx context.Context, flights *models.CreateBulkFlight) error {
+	if err := checkDepDate(flights.DepDate, flights.DepartureAt); err != nil {
+		return err
+	}
+
+	f := &models.Flight{DepDate: flights.DepDate, Origin: flights.Origin, Destination: flights.Destination}
+	if err := schedule(f, flights.DepartureAt, flights.ArrivalAt); err != nil {
+		return err
+	}
+	flights.DepDate, flights.Origin, flights.Destination = f.DepDate, f.Origin, f.Destination
+	flights.DepartureAt, flights.ArrivalAt = f.DepartureAt, f.ArrivalAt
+
 	if err := fc.fr.Create(ctx, flights); err != nil {
 		return err
 	}
@@ -37,3 +54,118 @@ func (fc *flightsController) GetAll(ctx context.Context) (models.Flights, error)
 
 	return flights, nil
 }
+
+func (fc *flightsController) GetByID(ctx context.Context, id int64) (*models.Flight, error) {
+	return fc.fr.GetByID(ctx, id)
+}
+
+func (fc *flightsController) Update(ctx context.Context, uf *models.UpdateFlight) (*models.Flight, error) {
+	if uf.FlightNo == nil && uf.DepDate == nil && uf.Origin == nil && uf.Destination == nil && uf.DepartureAt == nil && uf.ArrivalAt == nil {
+		return nil, errors.New("nothing to change on the flight!")
+	}
+	if uf.DepDate != nil {
+		if err := checkDepDate(*uf.DepDate, uf.DepartureAt); err != nil {
+			return nil, err
+		}
+	}
+
+	flight, err := fc.fr.GetByID(ctx, uf.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	departure, arrival := flight.DepartureAt, flight.ArrivalAt
+	if uf.DepDate != nil && departure != nil && uf.DepartureAt == nil {
+		// moving the date keeps the local times
+		days := int(uf.DepDate.Sub(flight.DepDate).Hours() / 24)
+		moved, landed := departure.AddDate(0, 0, days), arrival.AddDate(0, 0, days)
+		departure, arrival = &moved, &landed
+	}
+	if uf.DepartureAt != nil {
+		departure = uf.DepartureAt
+	}
+	if uf.ArrivalAt != nil {
+		arrival = uf.ArrivalAt
+	}
+
+	if uf.FlightNo != nil {
+		flight.FlightNo = *uf.FlightNo
+	}
+	if uf.DepDate != nil {
+		flight.DepDate = *uf.DepDate
+	}
+	if uf.Origin != nil {
+		flight.Origin = *uf.Origin
+	}
+	if uf.Destination != nil {
+		flight.Destination = *uf.Destination
+	}
+	if err := schedule(flight, departure, arrival); err != nil {
+		return nil, err
+	}
+
+	if err := fc.fr.Update(ctx, flight); err != nil {
+		return nil, err
+	}
+
+	return flight, nil
+}
+
+func (fc *flightsController) Delete(ctx context.Context, id int64, force bool) (*models.DeletedFlight, error) {
+	return fc.fr.Delete(ctx, id, force)
+}
+
+// checkDepDate refuses a departure date that is not the local date of the
+// departure given with it.
+func checkDepDate(depDate time.Time, departure *time.Time) error {
+	if depDate.IsZero() || departure == nil {
+		return nil
+	}
+	if depDate.Format(time.DateOnly) != departure.Format(time.DateOnly) {
+		return errors.New("dep_date must be the local date of the departure!")
+	}
+	return nil
+}
+
+// schedule checks the flight's route and places the departure and arrival,
+// read as wall clock times, in the timezones of their airports. A scheduled
+// flight's departure date follows its local departure.
+func schedule(f *models.Flight, departure, arrival *time.Time) error {
+	f.DepartureAt, f.DepartureTimezone, f.ArrivalAt, f.ArrivalTimezone = nil, "", nil, ""
+	if f.Origin == "" && f.Destination == "" {
+		if departure != nil || arrival != nil {
+			return errors.New("flight times need an origin and a destination!")
+		}
+		return nil
+	}
+
+	origin, err := airports.Lookup(f.Origin)
+	if err != nil {
+		return err
+	}
+	destination, err := airports.Lookup(f.Destination)
+	if err != nil {
+		return err
+	}
+	if origin.Code == destination.Code {
+		return errors.New("flight must arrive at another airport than it departs from!")
+	}
+	f.Origin, f.Destination = origin.Code, destination.Code
+
+	switch {
+	case departure == nil && arrival == nil:
+		return nil
+	case departure == nil || arrival == nil:
+		return errors.New("flight needs both a departure and an arrival time!")
+	}
+
+	dep, arr := origin.At(*departure), destination.At(*arrival)
+	if !arr.After(dep) {
+		return errors.New("flight must arrive after it departs!")
+	}
+	f.DepartureAt, f.DepartureTimezone = &dep, origin.Timezone
+	f.ArrivalAt, f.ArrivalTimezone = &arr, destination.Timezone
+	f.DepDate = time.Date(dep.Year(), dep.Month(), dep.Day(), 0, 0, 0, 0, time.UTC)
+
+	return nil
+}
@@ -1,16 +1,29 @@
 package controller
 
 import (
+	"backend/internal/aircraft"
 	"backend/internal/models"
 	"backend/internal/repository"
+	"backend/internal/seating"
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 )
 
 type SeatController interface {
 	Create(ctx context.Context, cbs *models.CreateBulkSeat) error
 	GetAll(ctx context.Context) (*models.Seats, error)
+	ApplyAircraft(ctx context.Context, flightID int64, aircraftType string) (int, error)
+	SeatMap(ctx context.Context, flightID int64) (*models.SeatMap, error)
+	FindAdjacent(ctx context.Context, fas *models.FindAdjacentSeats) (*models.AdjacentSeats, error)
 }
 
+// defaultAdjacentOptions is how many group seating options a dry run lists
+// when no limit is asked for.
+const defaultAdjacentOptions = 5
+
 type seatController struct {
 	sr repository.SeatRepository
 }
@@ -22,13 +35,66 @@ func NewSeatController(sr repository.SeatRepository) SeatController {
 }
 
 func (sc *seatController) Create(ctx context.Context, cbs *models.CreateBulkSeat) error {
-	if err := sc.sr.Create(ctx, cbs); err != nil {
+	existing, err := sc.sr.GetByFlight(ctx, cbs.FlightID)
+	if err != nil {
+		return err
+	}
+
+	labels, err := seating.ExpandLabels(cbs.Labels, cbs.SkipRows, cbs.SkipColumns)
+	if err != nil {
+		return err
+	}
+
+	seats := make(models.Seats, 0, len(labels)+len(cbs.Seats))
+	for _, l := range labels {
+		seats = append(seats, models.Seat{Label: l, Cabin: cbs.Cabin, SeatAttributes: models.SeatAttributes{Reclining: true}})
+	}
+	for _, s := range cbs.Seats {
+		s.Cabin = cbs.Cabin
+		seats = append(seats, s)
+	}
+
+	if err := checkDuplicateLabels(seats, *existing); err != nil {
+		return err
+	}
+
+	if err := sc.sr.Create(ctx, &models.CreateBulkSeat{
+		FlightID: cbs.FlightID,
+		Cabin:    cbs.Cabin,
+		Seats:    seating.DeriveAttributes(seats, *existing),
+	}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// checkDuplicateLabels lists every label that is repeated in the request or
+// already exists on the flight, all of them at once rather than failing on the
+// first UNIQUE(flight_id, label) violation.
+func checkDuplicateLabels(seats models.Seats, existing models.Seats) error {
+	taken := map[string]bool{}
+	for _, s := range existing {
+		taken[strings.ToUpper(s.Label)] = true
+	}
+
+	var duplicates []string
+	seen := map[string]int{}
+	for _, s := range seats {
+		label := strings.ToUpper(strings.TrimSpace(s.Label))
+		seen[label]++
+		if taken[label] && seen[label] == 1 || !taken[label] && seen[label] == 2 {
+			duplicates = append(duplicates, label)
+		}
+	}
+
+	if len(duplicates) > 0 {
+		return fmt.Errorf("duplicate seat labels: %s", strings.Join(duplicates, ", "))
+	}
+
+	return nil
+}
+
 func (sc *seatController) GetAll(ctx context.Context) (*models.Seats, error) {
 	seats, err := sc.sr.GetAll(ctx)
 	if err != nil {
@@ -37,3 +103,145 @@ func (sc *seatController) GetAll(ctx context.Context) (*models.Seats, error) {
 
 	return seats, nil
 }
+
+// ApplyAircraft creates the flight's whole seat map from an aircraft template,
+// all seats in a single transaction, and returns how many were created.
+func (sc *seatController) ApplyAircraft(ctx context.Context, flightID int64, aircraftType string) (int, error) {
+	template, err := aircraft.Lookup(aircraftType)
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := sc.sr.GetByFlight(ctx, flightID)
+	if err != nil {
+		return 0, err
+	}
+	if len(*existing) > 0 {
+		return 0, errors.New("flight already has seats!")
+	}
+
+	seats := template.Seats()
+	if err := sc.sr.Create(ctx, &models.CreateBulkSeat{
+		FlightID: flightID,
+		Seats:    seats,
+	}); err != nil {
+		return 0, err
+	}
+
+	return len(seats), nil
+}
+
+// SeatMap groups the flight's seats by cabin and row for rendering. Voucher
+// codes are masked, the seat map is not the place to leak redeemable codes.
+func (sc *seatController) SeatMap(ctx context.Context, flightID int64) (*models.SeatMap, error) {
+	seats, err := sc.sr.GetOccupancy(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(seats, func(i, j int) bool {
+		if seats[i].Row != seats[j].Row {
+			return seats[i].Row < seats[j].Row
+		}
+		return seats[i].Column < seats[j].Column
+	})
+
+	seatMap := &models.SeatMap{FlightID: flightID, Cabins: []models.SeatMapCabin{}}
+	cabins := map[string]int{}
+	for _, s := range seats {
+		idx, ok := cabins[s.Cabin]
+		if !ok {
+			idx = len(seatMap.Cabins)
+			cabins[s.Cabin] = idx
+			seatMap.Cabins = append(seatMap.Cabins, models.SeatMapCabin{Cabin: s.Cabin})
+		}
+		cabin := &seatMap.Cabins[idx]
+
+		if n := len(cabin.Rows); n == 0 || cabin.Rows[n-1].Row != s.Row {
+			cabin.Rows = append(cabin.Rows, models.SeatMapRow{Row: s.Row})
+		}
+		row := &cabin.Rows[len(cabin.Rows)-1]
+
+		seat := models.SeatMapSeat{Seat: s.Seat, Status: models.SeatFree}
+		switch {
+		case s.Assigned:
+			seat.Status = models.SeatAssigned
+			seat.VoucherCode = maskCode(s.VoucherCode)
+		case s.Held:
+			seat.Status = models.SeatHeld
+		case s.Blocked:
+			seat.Status = models.SeatBlocked
+		}
+		row.Seats = append(row.Seats, seat)
+	}
+
+	for i := range seatMap.Cabins {
+		seatMap.Cabins[i].Columns = cabinColumns(seatMap.Cabins[i])
+	}
+
+	return seatMap, nil
+}
+
+// FindAdjacent is a dry run of a group redemption: it lists the seats a group
+// of fas.Count would be given, best first, using the same allocator as
+// redeeming a group voucher. Nothing is held or assigned.
+func (sc *seatController) FindAdjacent(ctx context.Context, fas *models.FindAdjacentSeats) (*models.AdjacentSeats, error) {
+	pool, flightStrategy, err := sc.sr.GetCabinPool(ctx, fas.FlightID, fas.Cabin)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := seating.Resolve(fas.Strategy, flightStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := fas.Limit
+	if limit <= 0 {
+		limit = defaultAdjacentOptions
+	}
+
+	result := &models.AdjacentSeats{
+		FlightID: fas.FlightID,
+		Cabin:    fas.Cabin,
+		Count:    fas.Count,
+		Strategy: strategy.Name(),
+		Options:  []models.AdjacentSeatsOption{},
+	}
+	for _, group := range seating.RankGroups(strategy, pool, nil, fas.Count) {
+		if len(result.Options) == limit {
+			break
+		}
+
+		option := models.AdjacentSeatsOption{Arrangement: group.Arrangement}
+		for _, s := range group.Seats {
+			option.Seats = append(option.Seats, models.AssignedSeat{SeatID: s.ID, SeatLabel: s.Label})
+		}
+		result.Options = append(result.Options, option)
+	}
+
+	return result, nil
+}
+
+func cabinColumns(cabin models.SeatMapCabin) []string {
+	seen := map[string]bool{}
+	columns := []string{}
+	for _, row := range cabin.Rows {
+		for _, s := range row.Seats {
+			if s.Column != "" && !seen[s.Column] {
+				seen[s.Column] = true
+				columns = append(columns, s.Column)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// maskCode keeps the first and last two characters of a voucher code, e.g. V2***X2.
+func maskCode(code string) string {
+	if len(code) <= 4 {
+		return strings.Repeat("*", len(code))
+	}
+	return code[:2] + strings.Repeat("*", len(code)-4) + code[len(code)-2:]
+}
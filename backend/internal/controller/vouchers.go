@@ -1,28 +1,109 @@
 package controller
 
 import (
+	"backend/internal/clock"
 	"backend/internal/models"
 	"backend/internal/repository"
+	"backend/internal/throttle"
+	"backend/internal/vouchercode"
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
 )
 
 type VouchersController interface {
 	Create(ctx context.Context, cnv *models.CreateNewVoucher) error
+	CreateBatch(ctx context.Context, cvb *models.CreateVoucherBatch) (*models.VoucherBatch, error)
 	Assigns(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, error)
 	GetAll(ctx context.Context) (*models.Vouchers, error)
+	GetByCode(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherStatus, error)
+	Hold(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherHold, error)
+	ConfirmHold(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, error)
+	ReleaseHold(ctx context.Context, arv *models.AssignsRandomVoucher) error
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) ([]models.VoucherHold, error)
+	ExpireVouchers(ctx context.Context, now time.Time) ([]models.VoucherStatusChange, error)
+	PruneThrottle(ctx context.Context) (int64, error)
+	Unassign(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.SeatChange, error)
+	Move(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error)
+	Reshuffle(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error)
+	GetSeatChanges(ctx context.Context, arv *models.AssignsRandomVoucher) ([]models.SeatChange, error)
+	GetWaitlist(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.WaitlistEntry, error)
+	ChangeStatus(ctx context.Context, cvs *models.ChangeVoucherStatus) (*models.VoucherStatusChange, error)
+	GetStatusChanges(ctx context.Context, arv *models.AssignsRandomVoucher) ([]models.VoucherStatusChange, error)
 }
 
 type vouchersController struct {
-	vr repository.VouchersRepository
+	vr      repository.VouchersRepository
+	holdTTL time.Duration
+	signer  *vouchercode.Signer // nil when signed codes are not configured
+	limiter *throttle.Limiter   // nil when redemptions are not throttled
+	clock   clock.Clock
 }
 
-func NewVouchersController(vr repository.VouchersRepository) VouchersController {
+func NewVouchersController(vr repository.VouchersRepository, holdTTL time.Duration, signer *vouchercode.Signer, limiter *throttle.Limiter, clk clock.Clock) VouchersController {
 	return &vouchersController{
-		vr: vr,
+		vr:      vr,
+		holdTTL: holdTTL,
+		signer:  signer,
+		limiter: limiter,
+		clock:   clock.OrSystem(clk),
 	}
 }
 
+// checkCode verifies a signed code before the database is queried, so forged
+// or altered codes never reach it. Plain codes pass unless signing is required.
+func (vc *vouchersController) checkCode(code string) (*vouchercode.Claims, error) {
+	if vc.signer == nil {
+		return nil, nil
+	}
+
+	if !vouchercode.IsSigned(code) {
+		if vc.signer.RequireSigned {
+			return nil, vouchercode.ErrNotSigned
+		}
+		return nil, nil
+	}
+
+	claims, err := vc.signer.Verify(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
 func (vc *vouchersController) Create(ctx context.Context, cnv *models.CreateNewVoucher) error {
+	if err := checkUses(cnv.MaxRedemptions, cnv.GroupSize); err != nil {
+		return err
+	}
+	expiresAt, err := utcTime(cnv.ExpiresAt.String)
+	if err != nil {
+		return err
+	}
+	cnv.ExpiresAt.String = expiresAt
+
+	claims, err := vc.checkCode(cnv.Code)
+	if err != nil {
+		return err
+	}
+
+	// a signed code carries the voucher, it must not be stored with other fields
+	if claims != nil {
+		if cnv.ExpiresBeforeDeparture > 0 {
+			return errors.New("signed vouchers can not expire relative to departure!")
+		}
+
+		expiresAt := ""
+		if !claims.ExpiresAt.IsZero() {
+			expiresAt = claims.ExpiresAt.Format(time.RFC3339)
+		}
+		if claims.FlightID != cnv.FlightID || claims.Cabin != cnv.Cabin || !sameTime(expiresAt, cnv.ExpiresAt.String) {
+			return vouchercode.ErrSignedClaim
+		}
+	}
+
 	if err := vc.vr.Create(ctx, cnv); err != nil {
 		return err
 	}
@@ -30,8 +111,138 @@ func (vc *vouchersController) Create(ctx context.Context, cnv *models.CreateNewV
 	return nil
 }
 
+func (vc *vouchersController) CreateBatch(ctx context.Context, cvb *models.CreateVoucherBatch) (*models.VoucherBatch, error) {
+	if cvb.Count <= 0 {
+		return nil, errors.New("voucher count must be greater than 0")
+	}
+	if err := checkUses(cvb.MaxRedemptions, cvb.GroupSize); err != nil {
+		return nil, err
+	}
+	expiresAt, err := utcTime(cvb.ExpiresAt.String)
+	if err != nil {
+		return nil, err
+	}
+	cvb.ExpiresAt.String = expiresAt
+
+	newCode, err := vc.codeGenerator(cvb)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := vc.vr.CreateBatch(ctx, cvb, newCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// checkUses rejects negative counts, zero means the default of one.
+func checkUses(maxRedemptions, groupSize int) error {
+	if maxRedemptions < 0 {
+		return errors.New("max redemptions must not be negative")
+	}
+	if groupSize < 0 {
+		return errors.New("group size must not be negative")
+	}
+	return nil
+}
+
+// codeGenerator returns what mints the batch's codes: the signer, or the
+// random pattern when the batch is not signed.
+func (vc *vouchersController) codeGenerator(cvb *models.CreateVoucherBatch) (func() (string, error), error) {
+	if !cvb.Signed {
+		if vc.signer != nil && vc.signer.RequireSigned {
+			return nil, vouchercode.ErrNotSigned
+		}
+
+		pattern, err := vouchercode.Normalize(cvb.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		cvb.Pattern = pattern
+
+		return func() (string, error) { return vouchercode.Generate(pattern) }, nil
+	}
+
+	if vc.signer == nil {
+		return nil, errors.New("voucher signing keys are not configured")
+	}
+
+	claims := vouchercode.Claims{FlightID: cvb.FlightID, Cabin: cvb.Cabin}
+	if cvb.ExpiresAt.Valid {
+		expiresAt, err := time.Parse(time.RFC3339, cvb.ExpiresAt.String)
+		if err != nil {
+			return nil, err
+		}
+		claims.ExpiresAt = expiresAt
+	}
+
+	return func() (string, error) { return vc.signer.Sign(claims) }, nil
+}
+
+// utcTime rewrites an RFC3339 timestamp in UTC, the form every timestamp is
+// stored in so they compare as text. Empty stays empty.
+func utcTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q, use RFC3339!", value)
+	}
+	return clock.Format(t), nil
+}
+
+// sameTime compares two RFC3339 timestamps, or two empty strings.
+func sameTime(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	return errA == nil && errB == nil && ta.Equal(tb)
+}
+
+// throttled runs anything done by voucher code by the limiter: the attempt is
+// counted first, and unknown or forged codes count towards the client's
+// lockout. The code is checked before the redemption runs.
+func (vc *vouchersController) throttled(ctx context.Context, clientIP, code string, redeem func() error) error {
+	if vc.limiter == nil {
+		return vc.checked(code, redeem)
+	}
+
+	if err := vc.limiter.Allow(ctx, clientIP, code); err != nil {
+		return err
+	}
+
+	// the redemption's own result wins over a failure to record it
+	err := vc.checked(code, redeem)
+	switch {
+	case errors.Is(err, repository.ErrVoucherNotFound), errors.Is(err, vouchercode.ErrForged), errors.Is(err, vouchercode.ErrNotSigned):
+		vc.limiter.Fail(ctx, clientIP)
+	case err == nil:
+		vc.limiter.Succeed(ctx, clientIP)
+	}
+
+	return err
+}
+
+// checked runs redeem once the code passed checkCode.
+func (vc *vouchersController) checked(code string, redeem func() error) error {
+	if _, err := vc.checkCode(code); err != nil {
+		return err
+	}
+	return redeem()
+}
+
 func (vc *vouchersController) Assigns(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, error) {
-	voucher, err := vc.vr.Assigns(ctx, arv)
+	var voucher *models.VoucherAssigment
+	err := vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		var err error
+		voucher, err = vc.vr.Assigns(ctx, arv)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -45,5 +256,269 @@ func (vc *vouchersController) GetAll(ctx context.Context) (*models.Vouchers, err
 		return nil, err
 	}
 
+	now := vc.clock.Now()
+	for i := range *vouchers {
+		(*vouchers)[i].Status = voucherStatus(&(*vouchers)[i], now)
+	}
+
 	return vouchers, nil
 }
+
+// GetByCode shows a passenger their own voucher. Lookups are throttled like
+// redemptions, or they would be a way to guess codes.
+func (vc *vouchersController) GetByCode(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherStatus, error) {
+	var details *models.VoucherDetails
+	err := vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		var err error
+		details, err = vc.vr.GetByCode(ctx, arv.VoucherCode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.VoucherStatus{
+		Code:            details.Code,
+		Status:          voucherStatus(&details.Voucher, vc.clock.Now()),
+		FlightID:        details.FlightID,
+		FlightNo:        details.FlightNo,
+		DepDate:         details.DepDate,
+		Cabin:           details.Cabin,
+		ExpiresAt:       details.ExpiresAt.String,
+		Seats:           details.Seats,
+		RedemptionsLeft: max(details.MaxRedemptions-details.Redemptions, 0),
+	}
+	if len(details.Seats) > 0 {
+		status.SeatLabel = details.Seats[0].SeatLabel
+	}
+	if status.Status != models.VoucherActive {
+		status.RedemptionsLeft = 0
+	}
+
+	return status, nil
+}
+
+// voucherStatus tells where the voucher is in its life at now. An issued or
+// active voucher past its expiry is reported expired before it is marked so,
+// every other status sticks.
+func voucherStatus(v *models.Voucher, now time.Time) string {
+	if v.Status != models.VoucherIssued && v.Status != models.VoucherActive {
+		return v.Status
+	}
+	if v.ExpiresAt.Valid && v.ExpiresAt.String != "" {
+		if t, err := time.Parse(time.RFC3339, v.ExpiresAt.String); err == nil && now.After(t) {
+			return models.VoucherExpired
+		}
+	}
+	return v.Status
+}
+
+// ExpirySweeper is who the history names for vouchers expired by
+// ExpireVouchers.
+const ExpirySweeper = "scheduler"
+
+// statusTransitions lists the statuses a voucher can be moved to by hand.
+// Redemptions move it from ACTIVE to REDEEMED and back when seats are given
+// back, which the repository does as part of the seat change.
+var statusTransitions = map[string][]string{
+	models.VoucherIssued:   {models.VoucherActive, models.VoucherRevoked, models.VoucherExpired, models.VoucherCancelled},
+	models.VoucherActive:   {models.VoucherRevoked, models.VoucherExpired, models.VoucherCancelled},
+	models.VoucherRedeemed: {models.VoucherCheckedIn, models.VoucherRevoked, models.VoucherCancelled},
+}
+
+func checkTransition(from, to string) error {
+	if !slices.Contains(statusTransitions[from], to) {
+		return fmt.Errorf("voucher can not go from %s to %s!", from, to)
+	}
+	return nil
+}
+
+// ChangeStatus moves the voucher to cvs.To when its current status allows it.
+// Revoking or cancelling a voucher frees the seats it holds.
+func (vc *vouchersController) ChangeStatus(ctx context.Context, cvs *models.ChangeVoucherStatus) (*models.VoucherStatusChange, error) {
+	var change *models.VoucherStatusChange
+	err := vc.throttled(ctx, cvs.ClientIP, cvs.VoucherCode, func() error {
+		details, err := vc.vr.GetByCode(ctx, cvs.VoucherCode)
+		if err != nil {
+			return err
+		}
+		if err := checkTransition(details.Status, cvs.To); err != nil {
+			return err
+		}
+		cvs.From = details.Status
+
+		change, err = vc.vr.ChangeStatus(ctx, cvs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (vc *vouchersController) GetStatusChanges(ctx context.Context, arv *models.AssignsRandomVoucher) ([]models.VoucherStatusChange, error) {
+	var changes []models.VoucherStatusChange
+	err := vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		var err error
+		changes, err = vc.vr.GetStatusChanges(ctx, arv.VoucherCode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (vc *vouchersController) Hold(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherHold, error) {
+	var hold *models.VoucherHold
+	err := vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		var err error
+		hold, err = vc.vr.Hold(ctx, &models.HoldVoucherSeat{
+			VoucherCode: arv.VoucherCode,
+			Preferences: arv.Preferences,
+			TTL:         vc.holdTTL,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (vc *vouchersController) ConfirmHold(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, error) {
+	var voucher *models.VoucherAssigment
+	err := vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		var err error
+		voucher, err = vc.vr.ConfirmHold(ctx, arv.VoucherCode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return voucher, nil
+}
+
+func (vc *vouchersController) ReleaseHold(ctx context.Context, arv *models.AssignsRandomVoucher) error {
+	return vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		return vc.vr.ReleaseHold(ctx, arv.VoucherCode)
+	})
+}
+
+func (vc *vouchersController) ReleaseExpiredHolds(ctx context.Context, now time.Time) ([]models.VoucherHold, error) {
+	return vc.vr.ReleaseExpiredHolds(ctx, now)
+}
+
+// ExpireVouchers marks the issued and active vouchers past their expiry at
+// now as expired. A voucher whose status changes meanwhile is left alone.
+func (vc *vouchersController) ExpireVouchers(ctx context.Context, now time.Time) ([]models.VoucherStatusChange, error) {
+	vouchers, err := vc.vr.ExpiringVouchers(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.VoucherStatusChange{}
+	for _, v := range vouchers {
+		if err := checkTransition(v.Status, models.VoucherExpired); err != nil {
+			return nil, err
+		}
+
+		change, err := vc.vr.ChangeStatus(ctx, &models.ChangeVoucherStatus{
+			VoucherCode: v.Code,
+			From:        v.Status,
+			To:          models.VoucherExpired,
+			ChangedBy:   ExpirySweeper,
+			Reason:      "expired at " + v.ExpiresAt.String,
+		})
+		if errors.Is(err, repository.ErrStatusChanged) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+
+	return changes, nil
+}
+
+func (vc *vouchersController) PruneThrottle(ctx context.Context) (int64, error) {
+	if vc.limiter == nil {
+		return 0, nil
+	}
+
+	return vc.limiter.Prune(ctx)
+}
+
+func (vc *vouchersController) Unassign(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.SeatChange, error) {
+	var change *models.SeatChange
+	err := vc.throttled(ctx, cvs.ClientIP, cvs.VoucherCode, func() error {
+		var err error
+		change, err = vc.vr.Unassign(ctx, cvs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (vc *vouchersController) Move(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error) {
+	var voucher *models.VoucherAssigment
+	err := vc.throttled(ctx, cvs.ClientIP, cvs.VoucherCode, func() error {
+		var err error
+		voucher, err = vc.vr.Move(ctx, cvs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return voucher, nil
+}
+
+func (vc *vouchersController) Reshuffle(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error) {
+	var voucher *models.VoucherAssigment
+	err := vc.throttled(ctx, cvs.ClientIP, cvs.VoucherCode, func() error {
+		var err error
+		voucher, err = vc.vr.Reshuffle(ctx, cvs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return voucher, nil
+}
+
+func (vc *vouchersController) GetSeatChanges(ctx context.Context, arv *models.AssignsRandomVoucher) ([]models.SeatChange, error) {
+	var changes []models.SeatChange
+	err := vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		var err error
+		changes, err = vc.vr.GetSeatChanges(ctx, arv.VoucherCode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (vc *vouchersController) GetWaitlist(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.WaitlistEntry, error) {
+	var entry *models.WaitlistEntry
+	err := vc.throttled(ctx, arv.ClientIP, arv.VoucherCode, func() error {
+		var err error
+		entry, err = vc.vr.GetWaitlist(ctx, arv.VoucherCode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	CreateCampaign struct {
+		Name             string    `json:"name"`
+		StartsAt         time.Time `json:"starts_at"` // vouchers can be issued from
+		EndsAt           time.Time `json:"ends_at"`   // until
+		FlightIDs        []int64   `json:"flight_ids"`
+		Cabin            string    `json:"cabin"` // ECONOMY|BUSINESS|FIRST
+		Quota            int       `json:"quota"` // vouchers the campaign may issue
+		SeatStrategy     string    `json:"seat_strategy"`
+		VoucherExpiresAt string    `json:"voucher_expires_at"` // default expiry of its vouchers
+	}
+
+	// Campaign groups the vouchers handed out for one airline promotion.
+	Campaign struct {
+		ID               int64     `json:"id"`
+		Name             string    `json:"name"`
+		StartsAt         time.Time `json:"starts_at"`
+		EndsAt           time.Time `json:"ends_at"`
+		FlightIDs        []int64   `json:"flight_ids"`
+		Cabin            string    `json:"cabin"`
+		Quota            int       `json:"quota"`
+		SeatStrategy     string    `json:"seat_strategy,omitempty"` // overrides the flight's strategy, empty means the flight's
+		VoucherExpiresAt string    `json:"voucher_expires_at,omitempty"`
+	}
+
+	Campaigns = []Campaign
+
+	// CampaignReport sums up how a campaign's vouchers are redeemed.
+	CampaignReport struct {
+		Campaign
+		Issued         int                    `json:"issued"`
+		Redeemed       int                    `json:"redeemed"`
+		QuotaLeft      int                    `json:"quota_left"`
+		RedemptionRate float64                `json:"redemption_rate"` // redeemed out of issued, 0 to 1
+		Flights        []CampaignFlightReport `json:"flights"`
+	}
+
+	CampaignFlightReport struct {
+		FlightID int64  `json:"flight_id"`
+		FlightNo string `json:"flight_no"`
+		Issued   int    `json:"issued"`
+		Redeemed int    `json:"redeemed"`
+	}
+)
+
+// CheckWindow tells whether vouchers can be issued at now.
+func (c *Campaign) CheckWindow(now time.Time) error {
+	if now.Before(c.StartsAt) {
+		return errors.New("campaign not started!")
+	}
+	if !now.Before(c.EndsAt) {
+		return errors.New("campaign ended!")
+	}
+	return nil
+}
+
+// CheckQuota tells whether count more vouchers fit next to the issued ones.
+func (c *Campaign) CheckQuota(issued, count int) error {
+	if issued+count > c.Quota {
+		return fmt.Errorf("campaign quota exceeded, %d of %d vouchers left!", max(c.Quota-issued, 0), c.Quota)
+	}
+	return nil
+}
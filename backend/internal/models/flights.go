@@ -3,14 +3,51 @@ package models
 import "time"
 
 type CreateBulkFlight struct {
-	FlightNumbers []string  `json:"flight_numbers"` // e.g. ["GA133", "GA125"]
-	DepDate       time.Time `json:"dep_date"`       // departure date
+	FlightNumbers []string   `json:"flight_numbers"` // e.g. ["GA133", "GA125"]
+	DepDate       time.Time  `json:"dep_date"`       // departure date, the local date of DepartureAt when scheduled
+	SeatStrategy  string     `json:"seat_strategy"`  // default seat assignment strategy for the flight's vouchers
+	Origin        string     `json:"origin"`         // IATA code of the departure airport
+	Destination   string     `json:"destination"`    // IATA code of the arrival airport
+	DepartureAt   *time.Time `json:"departure_at"`   // the wall clock at the origin, placed in its timezone by the controller
+	ArrivalAt     *time.Time `json:"arrival_at"`     // the wall clock at the destination
 }
 
 type Flight struct {
-	ID       int64     `json:"id"`
-	FlightNo string    `json:"flight_no"` // flight number, e.g. "GA133, GA125"
-	DepDate  time.Time `json:"dep_date"`  // departure date
+	ID                int64      `json:"id"`
+	FlightNo          string     `json:"flight_no"`                    // flight number, e.g. "GA133, GA125"
+	DepDate           time.Time  `json:"dep_date"`                     // departure date
+	SeatStrategy      string     `json:"seat_strategy,omitempty"`      // empty means the default strategy
+	Origin            string     `json:"origin,omitempty"`             // IATA code of the departure airport
+	Destination       string     `json:"destination,omitempty"`        // IATA code of the arrival airport
+	DepartureAt       *time.Time `json:"departure_at,omitempty"`       // scheduled, local to the origin
+	DepartureTimezone string     `json:"departure_timezone,omitempty"` // IANA name, e.g. Asia/Jakarta
+	ArrivalAt         *time.Time `json:"arrival_at,omitempty"`         // scheduled, local to the destination
+	ArrivalTimezone   string     `json:"arrival_timezone,omitempty"`
 }
 
 type Flights = []Flight
+
+// UpdateFlight changes a flight, nil keeps the current value. Departure and
+// arrival are wall clock times at their airports.
+type UpdateFlight struct {
+	ID          int64      `json:"id"`
+	FlightNo    *string    `json:"flight_no,omitempty"`
+	DepDate     *time.Time `json:"dep_date,omitempty"`
+	Origin      *string    `json:"origin,omitempty"`
+	Destination *string    `json:"destination,omitempty"`
+	DepartureAt *time.Time `json:"departure_at,omitempty"`
+	ArrivalAt   *time.Time `json:"arrival_at,omitempty"`
+}
+
+// DeletedFlight is a removed flight with the vouchers and seat assignments
+// that were deleted along with it.
+type DeletedFlight struct {
+	Flight
+	Vouchers        []string         `json:"vouchers"` // codes of every voucher of the flight
+	SeatAssignments []SeatAssignment `json:"seat_assignments"`
+}
+
+type SeatAssignment struct {
+	VoucherCode string `json:"voucher_code"`
+	SeatLabel   string `json:"seat_label"`
+}
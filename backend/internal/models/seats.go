@@ -5,12 +5,92 @@ type Seat struct {
 	FlightID int64  `json:"flight_id"`
 	Label    string `json:"label"`
 	Cabin    string `json:"cabin"`
+	SeatAttributes
 }
 
 type Seats = []Seat
 
+// SeatAttributes describe where a seat is and what it offers. Row, column and
+// position are derived from the label when not given explicitly.
+type SeatAttributes struct {
+	Row          int    `json:"row,omitempty"`      // e.g. 12 for 12A
+	Column       string `json:"column,omitempty"`   // e.g. A for 12A
+	Position     string `json:"position,omitempty"` // WINDOW|MIDDLE|AISLE
+	ExitRow      bool   `json:"exit_row"`
+	Bulkhead     bool   `json:"bulkhead"`
+	ExtraLegroom bool   `json:"extra_legroom"`
+	Reclining    bool   `json:"reclining"`
+	PowerOutlet  bool   `json:"power_outlet"`
+	Blocked      bool   `json:"blocked"` // blocked or inoperative seats are never assigned
+}
+
 type CreateBulkSeat struct {
-	FlightID int64    `json:"flight_id"`
-	Cabin    string   `json:"cabin"`
-	Labels   []string `json:"labels"`
+	FlightID    int64    `json:"flight_id"`
+	Cabin       string   `json:"cabin"`
+	Labels      []string `json:"labels"`       // plain seats or ranges such as 10-25:ABCDEF, attributes derived from the label
+	Seats       Seats    `json:"seats"`        // seats with explicit attributes
+	SkipRows    []int    `json:"skip_rows"`    // rows left out of label ranges, e.g. 13
+	SkipColumns []string `json:"skip_columns"` // columns left out of label ranges, e.g. I
+}
+
+const (
+	SeatFree     = "FREE"
+	SeatAssigned = "ASSIGNED"
+	SeatHeld     = "HELD"
+	SeatBlocked  = "BLOCKED"
+)
+
+// SeatOccupancy is a seat together with the voucher holding it, if any.
+type SeatOccupancy struct {
+	Seat
+	Assigned    bool
+	Held        bool // held for a voucher that has not confirmed yet
+	VoucherCode string
+}
+
+// SeatMap is a flight's seats grouped by cabin and row, front to back.
+type SeatMap struct {
+	FlightID int64          `json:"flight_id"`
+	Cabins   []SeatMapCabin `json:"cabins"`
+}
+
+type SeatMapCabin struct {
+	Cabin   string       `json:"cabin"`
+	Columns []string     `json:"columns"` // every column used in the cabin, left to right
+	Rows    []SeatMapRow `json:"rows"`
+}
+
+type SeatMapRow struct {
+	Row   int           `json:"row"`
+	Seats []SeatMapSeat `json:"seats"`
+}
+
+type SeatMapSeat struct {
+	Seat
+	Status      string `json:"status"`                 // FREE|ASSIGNED|HELD|BLOCKED
+	VoucherCode string `json:"voucher_code,omitempty"` // masked, e.g. V2***X2
+}
+
+// FindAdjacentSeats asks which seats a group would be given, without
+// assigning them.
+type FindAdjacentSeats struct {
+	FlightID int64
+	Cabin    string
+	Count    int
+	Strategy string // empty means the flight's strategy
+	Limit    int    // how many options to list
+}
+
+// AdjacentSeats is a dry run of a group redemption, best option first.
+type AdjacentSeats struct {
+	FlightID int64                 `json:"flight_id"`
+	Cabin    string                `json:"cabin"`
+	Count    int                   `json:"count"`
+	Strategy string                `json:"strategy"`
+	Options  []AdjacentSeatsOption `json:"options"`
+}
+
+type AdjacentSeatsOption struct {
+	Arrangement string         `json:"arrangement"` // same_row|across_aisle|adjacent_rows
+	Seats       []AssignedSeat `json:"seats"`
 }
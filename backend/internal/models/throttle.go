@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ThrottleState is the redemption attempt bookkeeping of one key, e.g. a
+// client IP or a voucher code prefix.
+type ThrottleState struct {
+	Key         string    `json:"key"`
+	WindowStart time.Time `json:"window_start"` // start of the current rate limit window
+	Hits        int       `json:"hits"`         // attempts in the window
+	Failures    int       `json:"failures"`     // unknown codes in the window
+	Lockouts    int       `json:"lockouts"`     // lockouts in a row, each one lasts longer
+	LockedUntil time.Time `json:"locked_until"`
+}
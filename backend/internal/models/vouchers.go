@@ -2,36 +2,238 @@ package models
 
 import (
 	"database/sql"
+	"time"
 )
 
 type (
 	Voucher struct {
-		ID         int64          `json:"id"`
-		Code       string         `json:"code"`
-		FlightID   int64          `json:"flight_id"`
-		Cabin      string         `json:"cabin"`
-		ExpiresAt  sql.NullString `json:"expires_at"` // voucher time periode
-		Redeemed   int64          `json:"redeemed"`   // redeemed is used to flag or mark the voucher is used or not!
-		RedeemedAt *string        `json:"redeemed_at,omitempty"`
+		ID           int64          `json:"id"`
+		Code         string         `json:"code"`
+		FlightID     int64          `json:"flight_id"`
+		Cabin        string         `json:"cabin"`
+		ExpiresAt    sql.NullString `json:"expires_at"` // voucher time periode
+		Redeemed     int64          `json:"redeemed"`   // redeemed is used to flag or mark the voucher is used or not!
+		RedeemedAt   *string        `json:"redeemed_at,omitempty"`
+		SeatStrategy sql.NullString `json:"seat_strategy"` // overrides the campaign's and flight's strategy
+		CampaignID   sql.NullInt64  `json:"campaign_id"`
+
+		ExpiresBeforeDeparture sql.NullInt64 `json:"expires_before_departure"` // seconds, ExpiresAt follows the flight's departure
+
+		MaxRedemptions int `json:"max_redemptions"` // times the voucher can be redeemed
+		GroupSize      int `json:"group_size"`      // adjacent seats assigned per redemption
+		Redemptions    int `json:"redemptions"`     // redemptions holding seats, a group counts once
+
+		Status string `json:"status"` // ISSUED|ACTIVE|REDEEMED|CHECKED_IN|REVOKED|EXPIRED|CANCELLED
 	}
 
 	VoucherAssigment struct {
 		VoucherCode string `json:"voucher_code"`
 		Cabin       string `json:"cabin"`
-		SeatID      int64  `json:"seat_id"`
+		SeatID      int64  `json:"seat_id"` // the first seat of a group
 		SeatLabel   string `json:"seat_label"`
+		Strategy    string `json:"strategy"` // seat assignment strategy used
+
+		Seats           []AssignedSeat `json:"seats,omitempty"`       // every seat of the redemption
+		Arrangement     string         `json:"arrangement,omitempty"` // how a group's seats sit, e.g. same_row
+		RedemptionsLeft int            `json:"redemptions_left"`
+
+		SatisfiedPreferences   []string `json:"satisfied_preferences,omitempty"`
+		UnsatisfiedPreferences []string `json:"unsatisfied_preferences,omitempty"`
+	}
+
+	AssignedSeat struct {
+		SeatID    int64  `json:"seat_id"`
+		SeatLabel string `json:"seat_label"`
 	}
 
 	CreateNewVoucher struct {
-		Code      string         `json:"code"`
-		FlightID  int64          `json:"flight_id"`
-		Cabin     string         `json:"cabin"` // ECONOMY|BUSINESS|FIRST
-		ExpiresAt sql.NullString `json:"expires_at"`
+		Code         string         `json:"code"`
+		FlightID     int64          `json:"flight_id"`
+		Cabin        string         `json:"cabin"`      // ECONOMY|BUSINESS|FIRST
+		ExpiresAt    sql.NullString `json:"expires_at"` // defaults to the campaign's voucher expiry
+		SeatStrategy sql.NullString `json:"seat_strategy"`
+		CampaignID   sql.NullInt64  `json:"campaign_id"`
+
+		// ExpiresBeforeDeparture sets ExpiresAt that long before the flight
+		// departs, moved along when the departure changes. 0 means not at all.
+		ExpiresBeforeDeparture time.Duration `json:"expires_before_departure"`
+
+		MaxRedemptions int `json:"max_redemptions"` // 0 means once
+		GroupSize      int `json:"group_size"`      // 0 means a single seat
+
+		Status string `json:"status"` // ISSUED or ACTIVE, empty means ACTIVE
+	}
+
+	// VoucherCodePattern describes generated codes: the prefix, then Length
+	// characters from the alphabet and an optional check character.
+	VoucherCodePattern struct {
+		Prefix     string `json:"prefix,omitempty"`
+		Length     int    `json:"length"`
+		Alphabet   string `json:"alphabet"`
+		CheckDigit bool   `json:"check_digit"`
+	}
+
+	CreateVoucherBatch struct {
+		FlightID       int64              `json:"flight_id"`
+		Cabin          string             `json:"cabin"`
+		ExpiresAt      sql.NullString     `json:"expires_at"`
+		SeatStrategy   sql.NullString     `json:"seat_strategy"`
+		CampaignID     sql.NullInt64      `json:"campaign_id"`
+		MaxRedemptions int                `json:"max_redemptions"`
+		GroupSize      int                `json:"group_size"`
+		Count          int                `json:"count"`
+		Pattern        VoucherCodePattern `json:"pattern"`
+		Signed         bool               `json:"signed"` // signed codes instead of the pattern
+		Status         string             `json:"status"` // ISSUED or ACTIVE, empty means ACTIVE
+	}
+
+	VoucherBatch struct {
+		FlightID  int64    `json:"flight_id"`
+		Cabin     string   `json:"cabin"`
+		ExpiresAt string   `json:"expires_at,omitempty"`
+		Codes     []string `json:"codes"`
 	}
 
 	AssignsRandomVoucher struct {
+		VoucherCode string           `json:"voucher_code"`
+		Preferences *SeatPreferences `json:"preferences,omitempty"`
+		ClientIP    string           `json:"-"`                  // who redeems, for throttling
+		Waitlist    bool             `json:"waitlist,omitempty"` // join the cabin's waitlist when it is full
+	}
+
+	// HoldVoucherSeat asks for a seat to be held for the voucher until TTL has
+	// passed, hidden from other redeemers until confirmed or released.
+	HoldVoucherSeat struct {
+		VoucherCode string           `json:"voucher_code"`
+		Preferences *SeatPreferences `json:"preferences,omitempty"`
+		TTL         time.Duration    `json:"ttl"`
+	}
+
+	VoucherHold struct {
+		VoucherCode string `json:"voucher_code"`
+		Cabin       string `json:"cabin"`
+		SeatID      int64  `json:"seat_id"`
+		SeatLabel   string `json:"seat_label"`
+		Strategy    string `json:"strategy"`
+		ExpiresAt   string `json:"expires_at"` // the seat is offered to others again after this
+
+		SatisfiedPreferences   []string `json:"satisfied_preferences,omitempty"`
+		UnsatisfiedPreferences []string `json:"unsatisfied_preferences,omitempty"`
+	}
+
+	// ChangeVoucherSeat undoes or changes an assignment on someone's behalf,
+	// recorded with who asked and why.
+	ChangeVoucherSeat struct {
+		VoucherCode string           `json:"voucher_code"`
+		SeatLabel   string           `json:"seat_label,omitempty"`  // the seat to move to
+		FromSeat    string           `json:"from_seat,omitempty"`   // the seat to change, when the voucher has several
+		Preferences *SeatPreferences `json:"preferences,omitempty"` // when reshuffling
+		ChangedBy   string           `json:"changed_by"`
+		Reason      string           `json:"reason"`
+		ClientIP    string           `json:"-"` // who asks, for throttling
+	}
+
+	SeatChange struct {
+		ID          int64  `json:"id"`
 		VoucherCode string `json:"voucher_code"`
+		Action      string `json:"action"` // UNASSIGN|MOVE|RESHUFFLE
+		FromSeat    string `json:"from_seat"`
+		ToSeat      string `json:"to_seat,omitempty"`
+		ChangedBy   string `json:"changed_by"`
+		Reason      string `json:"reason"`
+		ChangedAt   string `json:"changed_at"`
+	}
+
+	// ChangeVoucherStatus moves a voucher from one status to another on
+	// someone's behalf, recorded with who asked and why.
+	ChangeVoucherStatus struct {
+		VoucherCode string `json:"voucher_code"`
+		From        string `json:"from"` // the status the change was checked against
+		To          string `json:"to"`
+		ChangedBy   string `json:"changed_by"`
+		Reason      string `json:"reason"`
+		ClientIP    string `json:"-"` // who asks, for throttling
+	}
+
+	VoucherStatusChange struct {
+		ID          int64    `json:"id"`
+		VoucherCode string   `json:"voucher_code"`
+		FromStatus  string   `json:"from_status"`
+		ToStatus    string   `json:"to_status"`
+		ChangedBy   string   `json:"changed_by,omitempty"` // empty for changes made by redemptions
+		Reason      string   `json:"reason,omitempty"`
+		ChangedAt   string   `json:"changed_at"`
+		FreedSeats  []string `json:"freed_seats,omitempty"` // seats given up by a revoked or cancelled voucher
+	}
+
+	// SeatPreferences are soft constraints honoured when a seat matching them is free.
+	SeatPreferences struct {
+		Position string `json:"position,omitempty"`  // WINDOW|MIDDLE|AISLE
+		Zone     string `json:"zone,omitempty"`      // FRONT|REAR of the cabin
+		ExitRow  bool   `json:"exit_row,omitempty"`  // opt-in to exit row seats
+		NearSeat string `json:"near_seat,omitempty"` // e.g. 14C
 	}
 )
 
 type Vouchers = []Voucher
+
+const (
+	VoucherIssued    = "ISSUED" // created but not redeemable until activated
+	VoucherActive    = "ACTIVE"
+	VoucherRedeemed  = "REDEEMED" // every redemption is used
+	VoucherCheckedIn = "CHECKED_IN"
+	VoucherRevoked   = "REVOKED"
+	VoucherExpired   = "EXPIRED"
+	VoucherCancelled = "CANCELLED"
+)
+
+// VoucherDetails is a voucher with its flight and the seats it holds.
+type VoucherDetails struct {
+	Voucher
+	FlightNo string
+	DepDate  time.Time
+	Seats    []AssignedSeat
+}
+
+// VoucherStatus is what a passenger sees of their own voucher, looked up by
+// its code.
+type VoucherStatus struct {
+	Code            string         `json:"code"`
+	Status          string         `json:"status"` // ISSUED|ACTIVE|REDEEMED|CHECKED_IN|REVOKED|EXPIRED|CANCELLED
+	FlightID        int64          `json:"flight_id"`
+	FlightNo        string         `json:"flight_no"`
+	DepDate         time.Time      `json:"dep_date"`
+	Cabin           string         `json:"cabin"`
+	ExpiresAt       string         `json:"expires_at,omitempty"`
+	SeatLabel       string         `json:"seat_label,omitempty"` // the first seat of a group
+	Seats           []AssignedSeat `json:"seats,omitempty"`
+	RedemptionsLeft int            `json:"redemptions_left"`
+}
+
+const (
+	SeatChangeUnassign  = "UNASSIGN"
+	SeatChangeMove      = "MOVE"
+	SeatChangeReshuffle = "RESHUFFLE"
+)
+
+// NewVoucherAssigment reports the seats of the voucher's latest redemption,
+// the first seat leads a group.
+func NewVoucherAssigment(code string, v *Voucher, seats Seats, strategy string) *VoucherAssigment {
+	result := &VoucherAssigment{
+		VoucherCode:     code,
+		Cabin:           v.Cabin,
+		SeatID:          seats[0].ID,
+		SeatLabel:       seats[0].Label,
+		Strategy:        strategy,
+		RedemptionsLeft: max(v.MaxRedemptions-v.Redemptions, 0),
+	}
+	for _, seat := range seats {
+		result.Seats = append(result.Seats, AssignedSeat{SeatID: seat.ID, SeatLabel: seat.Label})
+	}
+
+	return result
+}
+
+func (sp *SeatPreferences) IsEmpty() bool {
+	return sp.Position == "" && sp.Zone == "" && !sp.ExitRow && sp.NearSeat == ""
+}
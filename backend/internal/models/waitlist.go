@@ -0,0 +1,32 @@
+package models
+
+import "fmt"
+
+const (
+	WaitlistWaiting  = "WAITING"
+	WaitlistAssigned = "ASSIGNED"
+	WaitlistExpired  = "EXPIRED" // the voucher expired before a seat was freed
+)
+
+// WaitlistEntry is a voucher waiting for a seat in a full cabin. Entries are
+// seated first come, first served as seats are released or added.
+type WaitlistEntry struct {
+	VoucherCode string         `json:"voucher_code"`
+	FlightID    int64          `json:"flight_id"`
+	Cabin       string         `json:"cabin"`
+	Status      string         `json:"status"`             // WAITING|ASSIGNED|EXPIRED
+	Position    int            `json:"position,omitempty"` // 1 is next in line, only while waiting
+	JoinedAt    string         `json:"joined_at"`
+	AssignedAt  string         `json:"assigned_at,omitempty"`
+	Seats       []AssignedSeat `json:"seats,omitempty"` // the seats given from the waitlist
+}
+
+// WaitlistedError answers a redemption that found the cabin full and put the
+// voucher on the waitlist instead.
+type WaitlistedError struct {
+	Entry *WaitlistEntry
+}
+
+func (e *WaitlistedError) Error() string {
+	return fmt.Sprintf("cabin is full, voucher is number %d on the waitlist!", e.Entry.Position)
+}
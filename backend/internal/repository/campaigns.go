@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/pkg/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type CampaignsRepository interface {
+	Create(ctx context.Context, cc *models.CreateCampaign) (*models.Campaign, error)
+	GetAll(ctx context.Context) (models.Campaigns, error)
+	Report(ctx context.Context, id int64) (*models.CampaignReport, error)
+}
+
+type campaignsRepository struct {
+	db     *sql.DB
+	driver db.Driver
+	clock  clock.Clock
+}
+
+func NewCampaignsRepository(conn *sql.DB, clk clock.Clock) CampaignsRepository {
+	return &campaignsRepository{
+		db:     conn,
+		driver: db.SQLite,
+		clock:  clock.OrSystem(clk),
+	}
+}
+
+func NewPostgresCampaignsRepository(conn *sql.DB, clk clock.Clock) CampaignsRepository {
+	return &campaignsRepository{
+		db:     conn,
+		driver: db.Postgres,
+		clock:  clock.OrSystem(clk),
+	}
+}
+
+func (cr *campaignsRepository) Create(ctx context.Context, cc *models.CreateCampaign) (*models.Campaign, error) {
+	tx, err := cr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, flightID := range cc.FlightIDs {
+		var exists int
+		err := tx.QueryRowContext(ctx, cr.driver.Rebind(`SELECT 1 FROM flights WHERE id=?`), flightID).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("flight %d not found", flightID)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	var seatStrategy, voucherExpiresAt sql.NullString
+	if cc.SeatStrategy != "" {
+		seatStrategy = sql.NullString{String: cc.SeatStrategy, Valid: true}
+	}
+	if cc.VoucherExpiresAt != "" {
+		voucherExpiresAt = sql.NullString{String: cc.VoucherExpiresAt, Valid: true}
+	}
+
+	c := &models.Campaign{
+		Name:             cc.Name,
+		StartsAt:         cc.StartsAt.UTC(),
+		EndsAt:           cc.EndsAt.UTC(),
+		FlightIDs:        cc.FlightIDs,
+		Cabin:            cc.Cabin,
+		Quota:            cc.Quota,
+		SeatStrategy:     cc.SeatStrategy,
+		VoucherExpiresAt: cc.VoucherExpiresAt,
+	}
+
+	err = tx.QueryRowContext(ctx, cr.driver.Rebind(`INSERT INTO campaigns(name, starts_at, ends_at, cabin, quota, seat_strategy, voucher_expires_at, created_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		c.Name, clock.Format(c.StartsAt), clock.Format(c.EndsAt), c.Cabin, c.Quota, seatStrategy, voucherExpiresAt,
+		clock.Format(cr.clock.Now())).Scan(&c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, flightID := range c.FlightIDs {
+		if _, err := tx.ExecContext(ctx, cr.driver.Rebind(`INSERT INTO campaign_flights(campaign_id, flight_id) VALUES(?, ?) ON CONFLICT DO NOTHING`), c.ID, flightID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (cr *campaignsRepository) GetAll(ctx context.Context) (models.Campaigns, error) {
+	rows, err := cr.db.QueryContext(ctx, `SELECT `+campaignColumns+` FROM campaigns ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns models.Campaigns
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	flights, err := cr.db.QueryContext(ctx, `SELECT campaign_id, flight_id FROM campaign_flights ORDER BY flight_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer flights.Close()
+
+	for flights.Next() {
+		var campaignID, flightID int64
+		if err := flights.Scan(&campaignID, &flightID); err != nil {
+			return nil, err
+		}
+		if i := slices.IndexFunc(campaigns, func(c models.Campaign) bool { return c.ID == campaignID }); i >= 0 {
+			campaigns[i].FlightIDs = append(campaigns[i].FlightIDs, flightID)
+		}
+	}
+
+	return campaigns, flights.Err()
+}
+
+// Report counts the campaign's vouchers issued and redeemed, per flight.
+func (cr *campaignsRepository) Report(ctx context.Context, id int64) (*models.CampaignReport, error) {
+	row := cr.db.QueryRowContext(ctx, cr.driver.Rebind(`SELECT `+campaignColumns+` FROM campaigns WHERE id=?`), id)
+	c, err := scanCampaign(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("campaign not found!")
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := cr.db.QueryContext(ctx, cr.driver.Rebind(`SELECT cf.flight_id, f.flight_no, COUNT(v.id), COALESCE(SUM(v.redeemed), 0)
+		FROM campaign_flights cf
+		JOIN flights f ON f.id = cf.flight_id
+		LEFT JOIN vouchers v ON v.campaign_id = cf.campaign_id AND v.flight_id = cf.flight_id
+		WHERE cf.campaign_id=?
+		GROUP BY cf.flight_id, f.flight_no
+		ORDER BY cf.flight_id`), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &models.CampaignReport{Campaign: *c, Flights: []models.CampaignFlightReport{}}
+	for rows.Next() {
+		var f models.CampaignFlightReport
+		if err := rows.Scan(&f.FlightID, &f.FlightNo, &f.Issued, &f.Redeemed); err != nil {
+			return nil, err
+		}
+		report.FlightIDs = append(report.FlightIDs, f.FlightID)
+		report.Flights = append(report.Flights, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	SummarizeCampaign(report)
+	return report, nil
+}
+
+// SummarizeCampaign totals the per flight counts of the report, shared with
+// the in-memory storage.
+func SummarizeCampaign(report *models.CampaignReport) {
+	for _, f := range report.Flights {
+		report.Issued += f.Issued
+		report.Redeemed += f.Redeemed
+	}
+
+	report.QuotaLeft = max(report.Quota-report.Issued, 0)
+	if report.Issued > 0 {
+		report.RedemptionRate = float64(report.Redeemed) / float64(report.Issued)
+	}
+}
+
+const campaignColumns = `id, name, starts_at, ends_at, cabin, quota, COALESCE(seat_strategy,''), COALESCE(voucher_expires_at,'')`
+
+func scanCampaign(row rowScanner) (*models.Campaign, error) {
+	var c models.Campaign
+	var startsAt, endsAt string
+	if err := row.Scan(&c.ID, &c.Name, &startsAt, &endsAt, &c.Cabin, &c.Quota, &c.SeatStrategy, &c.VoucherExpiresAt); err != nil {
+		return nil, err
+	}
+
+	c.StartsAt, _ = time.Parse(time.RFC3339, startsAt)
+	c.EndsAt, _ = time.Parse(time.RFC3339, endsAt)
+	c.FlightIDs = []int64{}
+
+	return &c, nil
+}
+
+// campaignForVouchers checks count more vouchers for the flight and cabin fit
+// the campaign: its window is open, the flight and cabin are part of it and
+// the quota is not used up. The campaign row is locked on PostgreSQL so
+// concurrent issues can't exceed the quota.
+func campaignForVouchers(ctx context.Context, tx *sql.Tx, driver db.Driver, campaignID, flightID int64, cabin string, count int, now time.Time) (*models.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns WHERE id=?`
+	if driver == db.Postgres {
+		query += ` FOR UPDATE`
+	}
+
+	c, err := scanCampaign(tx.QueryRowContext(ctx, driver.Rebind(query), campaignID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("campaign not found!")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := c.CheckWindow(now); err != nil {
+		return nil, err
+	}
+
+	var exists int
+	err = tx.QueryRowContext(ctx, driver.Rebind(`SELECT 1 FROM campaign_flights WHERE campaign_id=? AND flight_id=?`), campaignID, flightID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("flight not in campaign!")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if cabin != c.Cabin {
+		return nil, errors.New("cabin not in campaign!")
+	}
+
+	var issued int
+	if err := tx.QueryRowContext(ctx, driver.Rebind(`SELECT COUNT(*) FROM vouchers WHERE campaign_id=?`), campaignID).Scan(&issued); err != nil {
+		return nil, err
+	}
+	if err := c.CheckQuota(issued, count); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
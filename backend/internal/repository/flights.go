@@ -1,25 +1,54 @@
 package repository
 
 import (
+	"backend/internal/airports"
+	"backend/internal/clock"
 	"backend/internal/models"
+	"backend/pkg/db"
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
 )
 
+var (
+	ErrFlightNotFound = errors.New("flight not found")
+	ErrFlightExists   = errors.New("flight already exists on that date!")
+	// ErrFlightRedeemed refuses to delete a flight whose vouchers hold seats.
+	ErrFlightRedeemed = errors.New("flight has redeemed vouchers, delete it with force!")
+	ErrFlightDeparted = errors.New("flight already departed!")
+	ErrNoDeparture    = errors.New("flight has no scheduled departure!")
+)
+
 type FlightsRepository interface {
 	Create(ctx context.Context, flight *models.CreateBulkFlight) error
 	GetAll(ctx context.Context) (models.Flights, error)
+	GetByID(ctx context.Context, id int64) (*models.Flight, error)
+	// Update saves the flight's number, date, route and times, refusing a
+	// number and date that would clash with another flight.
+	Update(ctx context.Context, flight *models.Flight) error
+	// Delete removes the flight, its seats and vouchers cascading with it.
+	// Unless forced it refuses when vouchers of the flight hold seats.
+	Delete(ctx context.Context, id int64, force bool) (*models.DeletedFlight, error)
 }
 
 type flightsRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	driver db.Driver
 }
 
-func NewFlightsRepository(db *sql.DB) FlightsRepository {
+func NewFlightsRepository(conn *sql.DB) FlightsRepository {
 	return &flightsRepository{
-		db,
+		db:     conn,
+		driver: db.SQLite,
+	}
+}
+
+func NewPostgresFlightsRepository(conn *sql.DB) FlightsRepository {
+	return &flightsRepository{
+		db:     conn,
+		driver: db.Postgres,
 	}
 }
 
@@ -27,9 +56,18 @@ func (fr *flightsRepository) Create(ctx context.Context, flight *models.CreateBu
 	tx, _ := fr.db.Begin()
 	defer tx.Rollback()
 
+	var seatStrategy sql.NullString
+	if flight.SeatStrategy != "" {
+		seatStrategy = sql.NullString{String: flight.SeatStrategy, Valid: true}
+	}
+
+	origin, destination := nullIfEmpty(flight.Origin), nullIfEmpty(flight.Destination)
+	departureAt, arrivalAt := nullIfNil(flight.DepartureAt), nullIfNil(flight.ArrivalAt)
+
 	for _, fn := range flight.FlightNumbers {
 		fn = strings.ToUpper(strings.TrimSpace(fn))
-		if _, err := tx.Exec(`INSERT INTO flights(flight_no, dep_date) VALUES(?,?)`, fn, flight.DepDate.Format(time.RFC3339)); err != nil {
+		if _, err := tx.Exec(fr.driver.Rebind(`INSERT INTO flights(flight_no, dep_date, seat_strategy, origin, destination, departure_at, arrival_at) VALUES(?,?,?,?,?,?,?)`),
+			fn, flight.DepDate.Format(time.RFC3339), seatStrategy, origin, destination, departureAt, arrivalAt); err != nil {
 			return err
 		}
 	}
@@ -42,27 +80,261 @@ func (fr *flightsRepository) Create(ctx context.Context, flight *models.CreateBu
 }
 
 func (fr *flightsRepository) GetAll(ctx context.Context) (models.Flights, error) {
-	rows, err := fr.db.Query("SELECT id, flight_no, dep_date FROM flights")
+	rows, err := fr.db.QueryContext(ctx, "SELECT "+flightColumns+" FROM flights")
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var flights models.Flights
 
 	for rows.Next() {
-		var flight models.Flight
-		var depDateStr string
+		flight, err := scanFlight(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		flights = append(flights, *flight)
+	}
+
+	return flights, rows.Err()
+}
+
+func (fr *flightsRepository) GetByID(ctx context.Context, id int64) (*models.Flight, error) {
+	return fr.flightByID(ctx, fr.db, id)
+}
+
+func (fr *flightsRepository) flightByID(ctx context.Context, q querier, id int64) (*models.Flight, error) {
+	flight, err := scanFlight(q.QueryRowContext(ctx, fr.driver.Rebind("SELECT "+flightColumns+" FROM flights WHERE id=?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFlightNotFound
+	}
+	return flight, err
+}
+
+const flightColumns = `id, flight_no, dep_date, seat_strategy, origin, destination, departure_at, arrival_at`
+
+// scanFlight reads a row of flightColumns, the scheduled times are turned
+// back to the local time of their airports.
+func scanFlight(row rowScanner) (*models.Flight, error) {
+	var flight models.Flight
+	var depDateStr string
+	var seatStrategy, origin, destination, departureAt, arrivalAt sql.NullString
+
+	if err := row.Scan(&flight.ID, &flight.FlightNo, &depDateStr, &seatStrategy, &origin, &destination, &departureAt, &arrivalAt); err != nil {
+		return nil, err
+	}
+	flight.SeatStrategy = seatStrategy.String
+	flight.Origin, flight.Destination = origin.String, destination.String
+
+	if parsedTime, err := time.Parse(time.RFC3339, depDateStr); err == nil {
+		flight.DepDate = parsedTime
+	}
+
+	var err error
+	if flight.DepartureAt, flight.DepartureTimezone, err = localTime(departureAt, flight.Origin); err != nil {
+		return nil, err
+	}
+	if flight.ArrivalAt, flight.ArrivalTimezone, err = localTime(arrivalAt, flight.Destination); err != nil {
+		return nil, err
+	}
+
+	return &flight, nil
+}
+
+// localTime parses a stored UTC time into the airport's timezone.
+func localTime(stored sql.NullString, airport string) (*time.Time, string, error) {
+	if !stored.Valid {
+		return nil, "", nil
+	}
+	t, err := time.Parse(time.RFC3339, stored.String)
+	if err != nil {
+		return nil, "", err
+	}
+	a, err := airports.Lookup(airport)
+	if err != nil {
+		return nil, "", err
+	}
+	t = t.In(a.Location())
+	return &t, a.Timezone, nil
+}
+
+func nullIfNil(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: clock.Format(*t), Valid: true}
+}
+
+func (fr *flightsRepository) Update(ctx context.Context, flight *models.Flight) error {
+	tx, err := fr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := fr.flightByID(ctx, tx, flight.ID); err != nil {
+		return err
+	}
+	flight.FlightNo = strings.ToUpper(strings.TrimSpace(flight.FlightNo))
+
+	var other int64
+	err = tx.QueryRowContext(ctx, fr.driver.Rebind(`SELECT id FROM flights WHERE flight_no=? AND dep_date=? AND id<>?`),
+		flight.FlightNo, flight.DepDate.Format(time.RFC3339), flight.ID).Scan(&other)
+	if err == nil {
+		return ErrFlightExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, fr.driver.Rebind(`UPDATE flights SET flight_no=?, dep_date=?, origin=?, destination=?, departure_at=?, arrival_at=? WHERE id=?`),
+		flight.FlightNo, flight.DepDate.Format(time.RFC3339), nullIfEmpty(flight.Origin), nullIfEmpty(flight.Destination),
+		nullIfNil(flight.DepartureAt), nullIfNil(flight.ArrivalAt), flight.ID); err != nil {
+		return err
+	}
+
+	if err := fr.moveExpiries(ctx, tx, flight); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// moveExpiries recomputes the expiry of the flight's vouchers that expire
+// relative to its departure. Vouchers already expired stay expired.
+func (fr *flightsRepository) moveExpiries(ctx context.Context, tx *sql.Tx, flight *models.Flight) error {
+	if flight.DepartureAt == nil {
+		return nil
+	}
 
-		if err := rows.Scan(&flight.ID, &flight.FlightNo, &depDateStr); err != nil {
+	rows, err := tx.QueryContext(ctx, fr.driver.Rebind(`SELECT id, expires_before_departure FROM vouchers
+		WHERE flight_id=? AND expires_before_departure IS NOT NULL AND status<>?`), flight.ID, models.VoucherExpired)
+	if err != nil {
+		return err
+	}
+
+	expiries := map[int64]sql.NullString{}
+	for rows.Next() {
+		var id, seconds int64
+		if err := rows.Scan(&id, &seconds); err != nil {
+			rows.Close()
+			return err
+		}
+		expiries[id] = relativeExpiry(*flight.DepartureAt, time.Duration(seconds)*time.Second)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, expiresAt := range expiries {
+		if _, err := tx.ExecContext(ctx, fr.driver.Rebind(`UPDATE vouchers SET expires_at=? WHERE id=?`), expiresAt, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flightDeparture is the flight's scheduled departure, nil when it has none.
+func flightDeparture(ctx context.Context, q querier, driver db.Driver, flightID int64) (*time.Time, error) {
+	var departureAt sql.NullString
+	err := q.QueryRowContext(ctx, driver.Rebind(`SELECT departure_at FROM flights WHERE id=?`), flightID).Scan(&departureAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFlightNotFound
+	} else if err != nil || !departureAt.Valid {
+		return nil, err
+	}
+
+	t, err := time.Parse(time.RFC3339, departureAt.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Departure is when the flight counts as departed: its scheduled departure,
+// or without one the end of its dep_date at the origin, in UTC when it has no
+// origin either.
+func Departure(departureAt *time.Time, depDate time.Time, origin string) time.Time {
+	if departureAt != nil {
+		return *departureAt
+	}
+
+	location := time.UTC
+	if a, err := airports.Lookup(origin); origin != "" && err == nil {
+		location = a.Location()
+	}
+	year, month, day := depDate.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, location)
+}
+
+// relativeExpiry is the expiry of a voucher expiring before the departure.
+func relativeExpiry(departure time.Time, before time.Duration) sql.NullString {
+	return sql.NullString{String: clock.Format(departure.Add(-before)), Valid: true}
+}
+
+func (fr *flightsRepository) Delete(ctx context.Context, id int64, force bool) (*models.DeletedFlight, error) {
+	tx, err := fr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	flight, err := fr.flightByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	deleted := &models.DeletedFlight{Flight: *flight, Vouchers: []string{}, SeatAssignments: []models.SeatAssignment{}}
+
+	// what the ON DELETE CASCADE rules are about to take with the flight
+	rows, err := tx.QueryContext(ctx, fr.driver.Rebind(`SELECT code FROM vouchers WHERE flight_id=? ORDER BY id`), id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			rows.Close()
 			return nil, err
 		}
+		deleted.Vouchers = append(deleted.Vouchers, code)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		if parsedTime, err := time.Parse(time.RFC3339, depDateStr); err == nil {
-			flight.DepDate = parsedTime
+	rows, err = tx.QueryContext(ctx, fr.driver.Rebind(`SELECT v.code, s.label FROM seat_assignments sa
+		JOIN vouchers v ON v.id = sa.voucher_id JOIN seats s ON s.id = sa.seat_id
+		WHERE v.flight_id=? ORDER BY sa.id`), id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var sa models.SeatAssignment
+		if err := rows.Scan(&sa.VoucherCode, &sa.SeatLabel); err != nil {
+			rows.Close()
+			return nil, err
 		}
+		deleted.SeatAssignments = append(deleted.SeatAssignments, sa)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(deleted.SeatAssignments) > 0 && !force {
+		return nil, ErrFlightRedeemed
+	}
+
+	if _, err := tx.ExecContext(ctx, fr.driver.Rebind(`DELETE FROM flights WHERE id=?`), id); err != nil {
+		return nil, err
+	}
 
-		flights = append(flights, flight)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
-	return flights, nil
+	return deleted, nil
 }
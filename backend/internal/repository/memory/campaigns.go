@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type campaignsRepository struct {
+	s *Store
+}
+
+func NewCampaignsRepository(s *Store) repository.CampaignsRepository {
+	return &campaignsRepository{s: s}
+}
+
+func (cr *campaignsRepository) Create(ctx context.Context, cc *models.CreateCampaign) (*models.Campaign, error) {
+	cr.s.mu.Lock()
+	defer cr.s.mu.Unlock()
+
+	var flightIDs []int64
+	for _, flightID := range cc.FlightIDs {
+		if cr.s.flightByID(flightID) == nil {
+			return nil, fmt.Errorf("flight %d not found", flightID)
+		}
+		if !slices.Contains(flightIDs, flightID) {
+			flightIDs = append(flightIDs, flightID)
+		}
+	}
+	for _, c := range cr.s.campaigns {
+		if c.Name == cc.Name {
+			return nil, errors.New("UNIQUE constraint failed: campaigns.name")
+		}
+	}
+
+	cr.s.nextCampaignID++
+	c := models.Campaign{
+		ID:               cr.s.nextCampaignID,
+		Name:             cc.Name,
+		StartsAt:         cc.StartsAt.UTC(),
+		EndsAt:           cc.EndsAt.UTC(),
+		FlightIDs:        flightIDs,
+		Cabin:            cc.Cabin,
+		Quota:            cc.Quota,
+		SeatStrategy:     cc.SeatStrategy,
+		VoucherExpiresAt: cc.VoucherExpiresAt,
+	}
+	cr.s.campaigns = append(cr.s.campaigns, c)
+
+	c.FlightIDs = slices.Clone(flightIDs)
+	return &c, nil
+}
+
+func (cr *campaignsRepository) GetAll(ctx context.Context) (models.Campaigns, error) {
+	cr.s.mu.Lock()
+	defer cr.s.mu.Unlock()
+
+	var campaigns models.Campaigns
+	for _, c := range cr.s.campaigns {
+		c.FlightIDs = slices.Sorted(slices.Values(c.FlightIDs))
+		campaigns = append(campaigns, c)
+	}
+
+	return campaigns, nil
+}
+
+func (cr *campaignsRepository) Report(ctx context.Context, id int64) (*models.CampaignReport, error) {
+	cr.s.mu.Lock()
+	defer cr.s.mu.Unlock()
+
+	c := cr.s.campaignByID(id)
+	if c == nil {
+		return nil, errors.New("campaign not found!")
+	}
+
+	report := &models.CampaignReport{Campaign: *c, Flights: []models.CampaignFlightReport{}}
+	report.FlightIDs = slices.Sorted(slices.Values(c.FlightIDs))
+	for _, flightID := range report.FlightIDs {
+		f := models.CampaignFlightReport{FlightID: flightID}
+		if flight := cr.s.flightByID(flightID); flight != nil {
+			f.FlightNo = flight.FlightNo
+		}
+		for _, v := range cr.s.vouchers {
+			if v.CampaignID.Valid && v.CampaignID.Int64 == id && v.FlightID == flightID {
+				f.Issued++
+				f.Redeemed += int(v.Redeemed)
+			}
+		}
+		report.Flights = append(report.Flights, f)
+	}
+
+	repository.SummarizeCampaign(report)
+	return report, nil
+}
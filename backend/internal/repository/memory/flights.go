@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+type flightsRepository struct {
+	s *Store
+}
+
+func NewFlightsRepository(s *Store) repository.FlightsRepository {
+	return &flightsRepository{s: s}
+}
+
+func (fr *flightsRepository) Create(ctx context.Context, flight *models.CreateBulkFlight) error {
+	fr.s.mu.Lock()
+	defer fr.s.mu.Unlock()
+
+	// validate the whole batch first so a failure inserts nothing
+	seen := map[string]bool{}
+	var numbers []string
+	for _, fn := range flight.FlightNumbers {
+		fn = strings.ToUpper(strings.TrimSpace(fn))
+		if seen[fn] {
+			return errors.New("UNIQUE constraint failed: flights.flight_no, flights.dep_date")
+		}
+		for _, f := range fr.s.flights {
+			if f.FlightNo == fn && f.DepDate.Equal(flight.DepDate) {
+				return errors.New("UNIQUE constraint failed: flights.flight_no, flights.dep_date")
+			}
+		}
+		seen[fn] = true
+		numbers = append(numbers, fn)
+	}
+
+	for _, fn := range numbers {
+		fr.s.nextFlightID++
+		f := models.Flight{
+			ID:           fr.s.nextFlightID,
+			FlightNo:     fn,
+			DepDate:      flight.DepDate,
+			SeatStrategy: flight.SeatStrategy,
+			Origin:       flight.Origin,
+			Destination:  flight.Destination,
+			DepartureAt:  flight.DepartureAt,
+			ArrivalAt:    flight.ArrivalAt,
+		}
+		// times placed at their airports carry the IANA name as location
+		if f.DepartureAt != nil {
+			f.DepartureTimezone = f.DepartureAt.Location().String()
+		}
+		if f.ArrivalAt != nil {
+			f.ArrivalTimezone = f.ArrivalAt.Location().String()
+		}
+		fr.s.flights = append(fr.s.flights, f)
+	}
+
+	return nil
+}
+
+func (fr *flightsRepository) GetAll(ctx context.Context) (models.Flights, error) {
+	fr.s.mu.Lock()
+	defer fr.s.mu.Unlock()
+
+	var flights models.Flights
+	flights = append(flights, fr.s.flights...)
+
+	return flights, nil
+}
+
+func (fr *flightsRepository) GetByID(ctx context.Context, id int64) (*models.Flight, error) {
+	fr.s.mu.Lock()
+	defer fr.s.mu.Unlock()
+
+	flight := fr.s.flightByID(id)
+	if flight == nil {
+		return nil, repository.ErrFlightNotFound
+	}
+
+	found := *flight
+	return &found, nil
+}
+
+func (fr *flightsRepository) Update(ctx context.Context, flight *models.Flight) error {
+	fr.s.mu.Lock()
+	defer fr.s.mu.Unlock()
+
+	stored := fr.s.flightByID(flight.ID)
+	if stored == nil {
+		return repository.ErrFlightNotFound
+	}
+
+	flight.FlightNo = strings.ToUpper(strings.TrimSpace(flight.FlightNo))
+	for _, f := range fr.s.flights {
+		if f.ID != flight.ID && f.FlightNo == flight.FlightNo && f.DepDate.Equal(flight.DepDate) {
+			return repository.ErrFlightExists
+		}
+	}
+
+	*stored = *flight
+
+	// vouchers expiring relative to the departure follow it, expired ones stay expired
+	if flight.DepartureAt != nil {
+		for _, v := range fr.s.vouchers {
+			if v.FlightID == flight.ID && v.ExpiresBeforeDeparture.Valid && v.Status != models.VoucherExpired {
+				v.ExpiresAt = relativeExpiry(*flight.DepartureAt, time.Duration(v.ExpiresBeforeDeparture.Int64)*time.Second)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the flight and everything the SQL schema would cascade to.
+func (fr *flightsRepository) Delete(ctx context.Context, id int64, force bool) (*models.DeletedFlight, error) {
+	fr.s.mu.Lock()
+	defer fr.s.mu.Unlock()
+
+	flight := fr.s.flightByID(id)
+	if flight == nil {
+		return nil, repository.ErrFlightNotFound
+	}
+	deleted := &models.DeletedFlight{Flight: *flight, Vouchers: []string{}, SeatAssignments: []models.SeatAssignment{}}
+
+	vouchers := map[int64]bool{}
+	for _, v := range fr.s.vouchers {
+		if v.FlightID == id {
+			vouchers[v.ID] = true
+			deleted.Vouchers = append(deleted.Vouchers, v.Code)
+		}
+	}
+	for _, a := range fr.s.assignments {
+		if vouchers[a.voucherID] {
+			deleted.SeatAssignments = append(deleted.SeatAssignments, models.SeatAssignment{
+				VoucherCode: fr.s.voucherByID(a.voucherID).Code,
+				SeatLabel:   fr.s.seatByID(a.seatID).Label,
+			})
+		}
+	}
+
+	if len(deleted.SeatAssignments) > 0 && !force {
+		return nil, repository.ErrFlightRedeemed
+	}
+
+	seats := map[int64]bool{}
+	for _, seat := range fr.s.seats {
+		if seat.FlightID == id {
+			seats[seat.ID] = true
+		}
+	}
+
+	fr.s.flights = slices.DeleteFunc(fr.s.flights, func(f models.Flight) bool { return f.ID == id })
+	fr.s.seats = slices.DeleteFunc(fr.s.seats, func(seat *seatRow) bool { return seats[seat.ID] })
+	fr.s.vouchers = slices.DeleteFunc(fr.s.vouchers, func(v *models.Voucher) bool { return vouchers[v.ID] })
+	fr.s.assignments = slices.DeleteFunc(fr.s.assignments, func(a assignmentRow) bool { return vouchers[a.voucherID] || seats[a.seatID] })
+	fr.s.deleteHolds(func(h holdRow) bool { return vouchers[h.voucherID] || seats[h.seatID] })
+	fr.s.seatChanges = slices.DeleteFunc(fr.s.seatChanges, func(c seatChangeRow) bool { return vouchers[c.voucherID] })
+	fr.s.statusChanges = slices.DeleteFunc(fr.s.statusChanges, func(c statusChangeRow) bool { return vouchers[c.voucherID] })
+	fr.s.waitlist = slices.DeleteFunc(fr.s.waitlist, func(w waitlistRow) bool { return w.flightID == id || vouchers[w.voucherID] })
+	for i := range fr.s.campaigns {
+		fr.s.campaigns[i].FlightIDs = slices.DeleteFunc(fr.s.campaigns[i].FlightIDs, func(flightID int64) bool { return flightID == id })
+	}
+
+	return deleted, nil
+}
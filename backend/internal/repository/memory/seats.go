@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/seating"
+	"context"
+	"errors"
+	"strings"
+)
+
+type seatRepository struct {
+	s *Store
+}
+
+func NewSeatRepository(s *Store) repository.SeatRepository {
+	return &seatRepository{s: s}
+}
+
+func (sr *seatRepository) Create(ctx context.Context, cbs *models.CreateBulkSeat) error {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+
+	if !sr.s.flightExists(cbs.FlightID) {
+		return repository.ErrFlightNotFound
+	}
+
+	seats := cbs.Seats
+	for _, l := range cbs.Labels {
+		seats = append(seats, models.Seat{Label: l, Cabin: cbs.Cabin, SeatAttributes: models.SeatAttributes{Reclining: true}})
+	}
+
+	seen := map[string]bool{}
+	var created models.Seats
+	for _, seat := range seats {
+		seat.Label = strings.ToUpper(strings.TrimSpace(seat.Label))
+		if seen[seat.Label] {
+			return errors.New("UNIQUE constraint failed: seats.flight_id, seats.label")
+		}
+		for _, existing := range sr.s.seats {
+			if existing.FlightID == cbs.FlightID && existing.Label == seat.Label {
+				return errors.New("UNIQUE constraint failed: seats.flight_id, seats.label")
+			}
+		}
+		seen[seat.Label] = true
+
+		seat.FlightID = cbs.FlightID
+		if seat.Cabin == "" {
+			seat.Cabin = cbs.Cabin
+		}
+		if seat.Row == 0 {
+			seat.Row, seat.Column, _ = seating.ParseLabel(seat.Label)
+		}
+		created = append(created, seat)
+	}
+
+	for _, seat := range created {
+		sr.s.nextSeatID++
+		seat.ID = sr.s.nextSeatID
+		sr.s.seats = append(sr.s.seats, &seatRow{Seat: seat})
+	}
+
+	// new seats go to the flight's waitlist first
+	sr.s.promoteWaitlist(cbs.FlightID)
+
+	return nil
+}
+
+func (sr *seatRepository) GetAll(ctx context.Context) (*models.Seats, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+
+	var seats models.Seats
+	for _, seat := range sr.s.seats {
+		seats = append(seats, seat.Seat)
+	}
+
+	return &seats, nil
+}
+
+func (sr *seatRepository) GetByFlight(ctx context.Context, flightID int64) (*models.Seats, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+
+	var seats models.Seats
+	for _, seat := range sr.s.seats {
+		if seat.FlightID == flightID {
+			seats = append(seats, seat.Seat)
+		}
+	}
+
+	return &seats, nil
+}
+
+func (sr *seatRepository) GetOccupancy(ctx context.Context, flightID int64) ([]models.SeatOccupancy, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+
+	if !sr.s.flightExists(flightID) {
+		return nil, repository.ErrFlightNotFound
+	}
+
+	now := sr.s.clock.Now()
+	var seats []models.SeatOccupancy
+	for _, seat := range sr.s.seats {
+		if seat.FlightID != flightID {
+			continue
+		}
+
+		occupancy := models.SeatOccupancy{Seat: seat.Seat, Assigned: seat.assigned, Held: sr.s.activeHoldBySeat(seat.ID, now) != nil}
+		if a := sr.s.assignmentBySeat(seat.ID); a != nil {
+			if v := sr.s.voucherByID(a.voucherID); v != nil {
+				occupancy.VoucherCode = v.Code
+			}
+		}
+		seats = append(seats, occupancy)
+	}
+
+	return seats, nil
+}
+
+func (sr *seatRepository) GetCabinPool(ctx context.Context, flightID int64, cabin string) (seating.SeatPool, string, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+
+	flight := sr.s.flightByID(flightID)
+	if flight == nil {
+		return seating.SeatPool{}, "", repository.ErrFlightNotFound
+	}
+
+	return sr.s.cabinPool(flightID, cabin, sr.s.clock.Now()), flight.SeatStrategy, nil
+}
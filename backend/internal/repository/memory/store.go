@@ -0,0 +1,316 @@
+// Package memory implements the repository interfaces in pure Go, for demo
+// mode and for tests that should not depend on a SQL database or cgo.
+package memory
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/seating"
+	"database/sql"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+type seatRow struct {
+	models.Seat
+	assigned bool
+}
+
+type assignmentRow struct {
+	voucherID  int64
+	seatID     int64
+	redemption int // the seats of a group share their redemption
+	strategy   string
+	assignedAt string
+}
+
+type holdRow struct {
+	voucherID int64
+	seatID    int64
+	strategy  string
+	heldAt    time.Time
+	expiresAt time.Time
+}
+
+type waitlistRow struct {
+	id         int64
+	voucherID  int64
+	flightID   int64
+	cabin      string
+	status     string
+	joinedAt   string
+	assignedAt string
+	redemption int // the voucher's redemption that seated it
+}
+
+type statusChangeRow struct {
+	models.VoucherStatusChange
+	voucherID int64
+}
+
+type seatChangeRow struct {
+	models.SeatChange
+	voucherID int64
+}
+
+// Store holds every table shared by the in-memory repositories. Each
+// repository method locks the store for its whole duration, which gives the
+// same all-or-nothing behaviour as a SQL transaction.
+type Store struct {
+	mu    sync.Mutex
+	clock clock.Clock
+
+	flights       []models.Flight
+	seats         []*seatRow
+	vouchers      []*models.Voucher
+	assignments   []assignmentRow
+	holds         []holdRow
+	seatChanges   []seatChangeRow
+	statusChanges []statusChangeRow
+	campaigns     []models.Campaign
+	waitlist      []waitlistRow
+
+	nextFlightID   int64
+	nextSeatID     int64
+	nextVoucherID  int64
+	nextChangeID   int64
+	nextCampaignID int64
+	nextWaitlistID int64
+	nextStatusID   int64
+}
+
+// NewStore returns an empty store reading the time from clk, the wall clock
+// when nil.
+func NewStore(clk clock.Clock) *Store {
+	return &Store{clock: clock.OrSystem(clk)}
+}
+
+func (s *Store) flightByID(id int64) *models.Flight {
+	for i := range s.flights {
+		if s.flights[i].ID == id {
+			return &s.flights[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) flightExists(id int64) bool {
+	return s.flightByID(id) != nil
+}
+
+func (s *Store) seatByID(id int64) *seatRow {
+	for _, seat := range s.seats {
+		if seat.ID == id {
+			return seat
+		}
+	}
+	return nil
+}
+
+func (s *Store) voucherByCode(code string) *models.Voucher {
+	for _, v := range s.vouchers {
+		if v.Code == code {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *Store) voucherByID(id int64) *models.Voucher {
+	for _, v := range s.vouchers {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *Store) assignmentBySeat(seatID int64) *assignmentRow {
+	for i := range s.assignments {
+		if s.assignments[i].seatID == seatID {
+			return &s.assignments[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) deleteAssignment(seatID int64) {
+	for i := range s.assignments {
+		if s.assignments[i].seatID == seatID {
+			s.assignments = append(s.assignments[:i], s.assignments[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Store) recordSeatChange(voucherID int64, change *models.SeatChange) {
+	s.nextChangeID++
+	change.ID = s.nextChangeID
+	s.seatChanges = append(s.seatChanges, seatChangeRow{SeatChange: *change, voucherID: voucherID})
+}
+
+func (s *Store) recordStatusChange(voucherID int64, change *models.VoucherStatusChange) {
+	s.nextStatusID++
+	change.ID = s.nextStatusID
+	s.statusChanges = append(s.statusChanges, statusChangeRow{VoucherStatusChange: *change, voucherID: voucherID})
+}
+
+// setStatus moves the voucher to a new status and records the transition.
+// Redemptions pass an empty changedBy.
+func (s *Store) setStatus(v *models.Voucher, to, changedBy, reason string) {
+	s.recordStatusChange(v.ID, &models.VoucherStatusChange{
+		VoucherCode: v.Code,
+		FromStatus:  v.Status,
+		ToStatus:    to,
+		ChangedBy:   changedBy,
+		Reason:      reason,
+		ChangedAt:   clock.Format(s.clock.Now()),
+	})
+	v.Status = to
+}
+
+func (s *Store) assignmentsByVoucher(voucherID int64) []*assignmentRow {
+	var assignments []*assignmentRow
+	for i := range s.assignments {
+		if s.assignments[i].voucherID == voucherID {
+			assignments = append(assignments, &s.assignments[i])
+		}
+	}
+	return assignments
+}
+
+// redemptions counts the voucher's redemptions holding seats and returns the
+// number of its latest one.
+func (s *Store) redemptions(voucherID int64) (count, last int) {
+	seen := map[int]bool{}
+	for _, a := range s.assignmentsByVoucher(voucherID) {
+		seen[a.redemption] = true
+		last = max(last, a.redemption)
+	}
+	return len(seen), last
+}
+
+// activeHoldBySeat returns the hold on the seat unless it has expired.
+func (s *Store) activeHoldBySeat(seatID int64, now time.Time) *holdRow {
+	for i := range s.holds {
+		if s.holds[i].seatID == seatID && now.Before(s.holds[i].expiresAt) {
+			return &s.holds[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) holdByVoucher(voucherID int64) *holdRow {
+	for i := range s.holds {
+		if s.holds[i].voucherID == voucherID {
+			return &s.holds[i]
+		}
+	}
+	return nil
+}
+
+// deleteHolds removes every hold matching the predicate and returns how many.
+func (s *Store) deleteHolds(match func(h holdRow) bool) int64 {
+	kept := s.holds[:0]
+	var deleted int64
+	for _, h := range s.holds {
+		if match(h) {
+			deleted++
+			continue
+		}
+		kept = append(kept, h)
+	}
+	s.holds = kept
+	return deleted
+}
+
+func (s *Store) campaignByID(id int64) *models.Campaign {
+	for i := range s.campaigns {
+		if s.campaigns[i].ID == id {
+			return &s.campaigns[i]
+		}
+	}
+	return nil
+}
+
+// cabinPool splits a flight's cabin into free and occupied seats, seats held
+// for a voucher count as occupied.
+func (s *Store) cabinPool(flightID int64, cabin string, now time.Time) seating.SeatPool {
+	var pool seating.SeatPool
+	for _, seat := range s.seats {
+		if seat.FlightID != flightID || seat.Cabin != cabin {
+			continue
+		}
+
+		switch {
+		case seat.Blocked:
+			// blocked seats are neither offered nor count as occupied
+		case seat.assigned, s.activeHoldBySeat(seat.ID, now) != nil:
+			pool.Assigned = append(pool.Assigned, seat.Seat)
+		default:
+			pool.Free = append(pool.Free, seat.Seat)
+		}
+	}
+	return pool
+}
+
+// defaultStrategy is the seat strategy of the voucher's campaign, or else of
+// its flight, used when the voucher has none of its own.
+func (s *Store) defaultStrategy(v *models.Voucher) string {
+	if v.CampaignID.Valid {
+		if c := s.campaignByID(v.CampaignID.Int64); c != nil && c.SeatStrategy != "" {
+			return c.SeatStrategy
+		}
+	}
+	if f := s.flightByID(v.FlightID); f != nil {
+		return f.SeatStrategy
+	}
+	return ""
+}
+
+// campaignForVouchers checks count more vouchers for the flight and cabin fit
+// the campaign: its window is open, the flight and cabin are part of it and
+// the quota is not used up.
+func (s *Store) campaignForVouchers(campaignID, flightID int64, cabin string, count int) (*models.Campaign, error) {
+	c := s.campaignByID(campaignID)
+	if c == nil {
+		return nil, errors.New("campaign not found!")
+	}
+
+	if err := c.CheckWindow(s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(c.FlightIDs, flightID) {
+		return nil, errors.New("flight not in campaign!")
+	}
+	if cabin != c.Cabin {
+		return nil, errors.New("cabin not in campaign!")
+	}
+
+	issued := 0
+	for _, v := range s.vouchers {
+		if v.CampaignID.Valid && v.CampaignID.Int64 == campaignID {
+			issued++
+		}
+	}
+	if err := c.CheckQuota(issued, count); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// relativeExpiry is the expiry of a voucher expiring before the departure.
+func relativeExpiry(departure time.Time, before time.Duration) sql.NullString {
+	return sql.NullString{String: clock.Format(departure.Add(-before)), Valid: true}
+}
+
+// campaignExpiry falls back to the campaign's voucher expiry.
+func campaignExpiry(c *models.Campaign, expiresAt sql.NullString) sql.NullString {
+	if !expiresAt.Valid && c.VoucherExpiresAt != "" {
+		return sql.NullString{String: c.VoucherExpiresAt, Valid: true}
+	}
+	return expiresAt
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"sync"
+	"time"
+)
+
+// throttleRepository keeps the throttle state of this process only. It is
+// independent of the Store, so it also serves instances on a SQL database.
+type throttleRepository struct {
+	mu     sync.Mutex
+	states map[string]models.ThrottleState
+}
+
+func NewThrottleRepository() repository.ThrottleRepository {
+	return &throttleRepository{
+		states: map[string]models.ThrottleState{},
+	}
+}
+
+func (tr *throttleRepository) Update(ctx context.Context, key string, fn func(s *models.ThrottleState)) (*models.ThrottleState, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	s := tr.states[key]
+	s.Key = key
+	fn(&s)
+	tr.states[key] = s
+
+	return &s, nil
+}
+
+func (tr *throttleRepository) Prune(ctx context.Context, staleBefore time.Time) (int64, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	var pruned int64
+	for key, s := range tr.states {
+		if s.WindowStart.Before(staleBefore) && s.LockedUntil.Before(staleBefore) {
+			delete(tr.states, key)
+			pruned++
+		}
+	}
+
+	return pruned, nil
+}
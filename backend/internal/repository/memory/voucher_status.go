@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"time"
+)
+
+// ChangeStatus moves the voucher from cvs.From to cvs.To. A revoked or
+// cancelled voucher gives up its seats, and like an expired one its hold and
+// place on the waitlist. What is freed goes to the flight's waitlist.
+func (vr *vouchersRepository) ChangeStatus(ctx context.Context, cvs *models.ChangeVoucherStatus) (*models.VoucherStatusChange, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v := vr.s.voucherByCode(cvs.VoucherCode)
+	if v == nil {
+		return nil, repository.ErrVoucherNotFound
+	}
+	if v.Status != cvs.From {
+		return nil, repository.ErrStatusChanged
+	}
+
+	now := clock.Format(vr.s.clock.Now())
+	change := &models.VoucherStatusChange{
+		VoucherCode: cvs.VoucherCode,
+		FromStatus:  cvs.From,
+		ToStatus:    cvs.To,
+		ChangedBy:   cvs.ChangedBy,
+		Reason:      cvs.Reason,
+		ChangedAt:   now,
+	}
+
+	switch cvs.To {
+	case models.VoucherRevoked, models.VoucherCancelled:
+		var freed []*seatRow
+		for _, a := range vr.s.assignmentsByVoucher(v.ID) {
+			freed = append(freed, vr.s.seatByID(a.seatID))
+		}
+		for _, seat := range freed {
+			seat.assigned = false
+			vr.s.deleteAssignment(seat.ID)
+			vr.s.recordSeatChange(v.ID, &models.SeatChange{
+				VoucherCode: cvs.VoucherCode,
+				Action:      models.SeatChangeUnassign,
+				FromSeat:    seat.Label,
+				ChangedBy:   cvs.ChangedBy,
+				Reason:      cvs.Reason,
+				ChangedAt:   now,
+			})
+			change.FreedSeats = append(change.FreedSeats, seat.Label)
+		}
+		fallthrough
+	case models.VoucherExpired:
+		vr.s.deleteHolds(func(h holdRow) bool { return h.voucherID == v.ID })
+		for i := range vr.s.waitlist {
+			if w := &vr.s.waitlist[i]; w.voucherID == v.ID && w.status == models.WaitlistWaiting {
+				w.status = models.WaitlistExpired
+			}
+		}
+		vr.s.promoteWaitlist(v.FlightID)
+	}
+
+	v.Status = cvs.To
+	vr.s.recordStatusChange(v.ID, change)
+
+	return change, nil
+}
+
+func (vr *vouchersRepository) ExpiringVouchers(ctx context.Context, now time.Time) ([]models.Voucher, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	vouchers := []models.Voucher{}
+	for _, v := range vr.s.vouchers {
+		if v.Status != models.VoucherIssued && v.Status != models.VoucherActive || !v.ExpiresAt.Valid {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, v.ExpiresAt.String); err == nil && now.After(t) {
+			vouchers = append(vouchers, *v)
+		}
+	}
+
+	return vouchers, nil
+}
+
+func (vr *vouchersRepository) GetStatusChanges(ctx context.Context, code string) ([]models.VoucherStatusChange, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v := vr.s.voucherByCode(code)
+	if v == nil {
+		return nil, repository.ErrVoucherNotFound
+	}
+
+	changes := []models.VoucherStatusChange{}
+	for _, c := range vr.s.statusChanges {
+		if c.voucherID == v.ID {
+			change := c.VoucherStatusChange
+			change.FreedSeats = nil
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
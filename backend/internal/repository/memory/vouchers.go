@@ -0,0 +1,587 @@
+package memory
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/seating"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+type vouchersRepository struct {
+	s *Store
+}
+
+func NewVouchersRepository(s *Store) repository.VouchersRepository {
+	return &vouchersRepository{s: s}
+}
+
+func (vr *vouchersRepository) Create(ctx context.Context, cnv *models.CreateNewVoucher) error {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	hasSeat := false
+	for _, seat := range vr.s.seats {
+		if seat.FlightID == cnv.FlightID && seat.Cabin == cnv.Cabin {
+			hasSeat = true
+			break
+		}
+	}
+	if !hasSeat {
+		return errors.New("no seats available for this flight and cabin")
+	}
+
+	if vr.s.voucherByCode(cnv.Code) != nil {
+		return errors.New("UNIQUE constraint failed: vouchers.code")
+	}
+
+	expiresAt := cnv.ExpiresAt
+	var expiresBefore sql.NullInt64
+	if cnv.ExpiresBeforeDeparture > 0 {
+		f := vr.s.flightByID(cnv.FlightID)
+		if f == nil {
+			return repository.ErrFlightNotFound
+		}
+		if f.DepartureAt == nil {
+			return repository.ErrNoDeparture
+		}
+		expiresAt = relativeExpiry(*f.DepartureAt, cnv.ExpiresBeforeDeparture)
+		expiresBefore = sql.NullInt64{Int64: int64(cnv.ExpiresBeforeDeparture / time.Second), Valid: true}
+	}
+
+	if cnv.CampaignID.Valid {
+		c, err := vr.s.campaignForVouchers(cnv.CampaignID.Int64, cnv.FlightID, cnv.Cabin, 1)
+		if err != nil {
+			return err
+		}
+		expiresAt = campaignExpiry(c, expiresAt)
+	}
+
+	vr.s.nextVoucherID++
+	vr.s.vouchers = append(vr.s.vouchers, &models.Voucher{
+		ID:           vr.s.nextVoucherID,
+		Code:         cnv.Code,
+		FlightID:     cnv.FlightID,
+		Cabin:        cnv.Cabin,
+		ExpiresAt:    expiresAt,
+		SeatStrategy: cnv.SeatStrategy,
+		CampaignID:   cnv.CampaignID,
+
+		ExpiresBeforeDeparture: expiresBefore,
+
+		MaxRedemptions: max(cnv.MaxRedemptions, 1),
+		GroupSize:      max(cnv.GroupSize, 1),
+		Status:         repository.InitialStatus(cnv.Status),
+	})
+
+	return nil
+}
+
+func (vr *vouchersRepository) CreateBatch(ctx context.Context, cvb *models.CreateVoucherBatch, newCode func() (string, error)) (*models.VoucherBatch, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	hasSeat := false
+	for _, seat := range vr.s.seats {
+		if seat.FlightID == cvb.FlightID && seat.Cabin == cvb.Cabin {
+			hasSeat = true
+			break
+		}
+	}
+	if !hasSeat {
+		return nil, errors.New("no seats available for this flight and cabin")
+	}
+
+	expiresAt := cvb.ExpiresAt
+	if cvb.CampaignID.Valid {
+		c, err := vr.s.campaignForVouchers(cvb.CampaignID.Int64, cvb.FlightID, cvb.Cabin, cvb.Count)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt = campaignExpiry(c, expiresAt)
+	}
+
+	batch := &models.VoucherBatch{
+		FlightID:  cvb.FlightID,
+		Cabin:     cvb.Cabin,
+		ExpiresAt: expiresAt.String,
+		Codes:     make([]string, 0, cvb.Count),
+	}
+
+	taken := map[string]bool{}
+	for _, v := range vr.s.vouchers {
+		taken[v.Code] = true
+	}
+
+	maxAttempts := 3*cvb.Count + 100
+	for attempt := 0; len(batch.Codes) < cvb.Count; attempt++ {
+		if attempt == maxAttempts {
+			return nil, errors.New("not enough unique voucher codes, use a longer code!")
+		}
+
+		code, err := newCode()
+		if err != nil {
+			return nil, err
+		}
+		if taken[code] {
+			continue
+		}
+		taken[code] = true
+		batch.Codes = append(batch.Codes, code)
+	}
+
+	for _, code := range batch.Codes {
+		vr.s.nextVoucherID++
+		vr.s.vouchers = append(vr.s.vouchers, &models.Voucher{
+			ID:           vr.s.nextVoucherID,
+			Code:         code,
+			FlightID:     cvb.FlightID,
+			Cabin:        cvb.Cabin,
+			ExpiresAt:    expiresAt,
+			SeatStrategy: cvb.SeatStrategy,
+			CampaignID:   cvb.CampaignID,
+
+			MaxRedemptions: max(cvb.MaxRedemptions, 1),
+			GroupSize:      max(cvb.GroupSize, 1),
+			Status:         repository.InitialStatus(cvb.Status),
+		})
+	}
+
+	return batch, nil
+}
+
+func (vr *vouchersRepository) Assigns(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v, err := vr.s.redeemableVoucher(arv.VoucherCode)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := seating.Resolve(v.SeatStrategy.String, vr.s.defaultStrategy(v))
+	if err != nil {
+		return nil, err
+	}
+
+	// assigning straight away drops a seat the voucher was holding
+	vr.s.deleteHolds(func(h holdRow) bool { return h.voucherID == v.ID })
+
+	pool := vr.s.cabinPool(v.FlightID, v.Cabin, vr.s.clock.Now())
+
+	// the store is locked, the best group is still free
+	groups := seating.RankGroups(strategy, pool, arv.Preferences, v.GroupSize)
+	if len(groups) == 0 && arv.Waitlist {
+		return nil, &models.WaitlistedError{Entry: vr.joinWaitlist(v)}
+	}
+	if len(groups) == 0 {
+		return nil, repository.NoSeatsError(v.GroupSize)
+	}
+	seats := groups[0].Seats
+
+	vr.s.assignSeats(v, seats, strategy.Name())
+
+	result := models.NewVoucherAssigment(arv.VoucherCode, v, seats, strategy.Name())
+	if v.GroupSize > 1 {
+		result.Arrangement = groups[0].Arrangement
+	}
+	result.SatisfiedPreferences, result.UnsatisfiedPreferences = seating.Evaluate(seats[0], pool, arv.Preferences)
+
+	return result, nil
+}
+
+// assignSeats records the seats as the voucher's next redemption and marks
+// the voucher redeemed once it has no redemptions left.
+func (s *Store) assignSeats(v *models.Voucher, seats models.Seats, strategy string) {
+	now := clock.Format(s.clock.Now())
+
+	_, last := s.redemptions(v.ID)
+	for _, seat := range seats {
+		s.assignments = append(s.assignments, assignmentRow{voucherID: v.ID, seatID: seat.ID, redemption: last + 1, strategy: strategy, assignedAt: now})
+		s.seatByID(seat.ID).assigned = true
+	}
+
+	v.Redemptions, _ = s.redemptions(v.ID)
+	if v.Redemptions >= v.MaxRedemptions {
+		v.Redeemed = 1
+		s.setStatus(v, models.VoucherRedeemed, "", "every redemption used")
+	}
+	v.RedeemedAt = &now
+
+	// a voucher seated while on the waitlist leaves it
+	for i := range s.waitlist {
+		if w := &s.waitlist[i]; w.voucherID == v.ID && w.status == models.WaitlistWaiting {
+			w.status, w.assignedAt, w.redemption = models.WaitlistAssigned, now, last+1
+		}
+	}
+}
+
+// redeemableVoucher looks the voucher up and checks it can still be redeemed.
+func (s *Store) redeemableVoucher(code string) (*models.Voucher, error) {
+	v := s.voucherByCode(code)
+	if v == nil {
+		return nil, repository.ErrVoucherNotFound
+	}
+
+	if err := repository.RedeemStatusError(v.Status); err != nil {
+		return nil, err
+	}
+
+	v.Redemptions, _ = s.redemptions(v.ID)
+	if v.Redeemed == 1 || v.Redemptions >= v.MaxRedemptions {
+		return nil, repository.ErrVoucherRedeemed
+	}
+
+	if v.ExpiresAt.Valid && v.ExpiresAt.String != "" {
+		if t, e := time.Parse(time.RFC3339, v.ExpiresAt.String); e == nil && s.clock.Now().After(t) {
+			return nil, repository.ErrVoucherExpired
+		}
+	}
+
+	if f := s.flightByID(v.FlightID); f != nil && !s.clock.Now().Before(repository.Departure(f.DepartureAt, f.DepDate, f.Origin)) {
+		return nil, repository.ErrFlightDeparted
+	}
+
+	return v, nil
+}
+
+func (vr *vouchersRepository) Hold(ctx context.Context, hvs *models.HoldVoucherSeat) (*models.VoucherHold, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v, err := vr.s.redeemableVoucher(hvs.VoucherCode)
+	if err != nil {
+		return nil, err
+	}
+	if v.GroupSize > 1 {
+		return nil, repository.ErrGroupHold
+	}
+
+	strategy, err := seating.Resolve(v.SeatStrategy.String, vr.s.defaultStrategy(v))
+	if err != nil {
+		return nil, err
+	}
+
+	var previousSeatID int64
+	if h := vr.s.holdByVoucher(v.ID); h != nil {
+		previousSeatID = h.seatID
+	}
+	vr.s.deleteHolds(func(h holdRow) bool { return h.voucherID == v.ID })
+
+	now := vr.s.clock.Now()
+	pool := vr.s.cabinPool(v.FlightID, v.Cabin, now)
+
+	ranked := seating.OfferLast(seating.Rank(strategy, pool, hvs.Preferences), previousSeatID)
+	if len(ranked) == 0 {
+		return nil, repository.ErrNoSeats
+	}
+
+	seat := ranked[0]
+	expiresAt := now.Add(hvs.TTL)
+
+	// an expired hold on the seat is taken over
+	vr.s.deleteHolds(func(h holdRow) bool { return h.seatID == seat.ID })
+	vr.s.holds = append(vr.s.holds, holdRow{voucherID: v.ID, seatID: seat.ID, strategy: strategy.Name(), heldAt: now, expiresAt: expiresAt})
+
+	result := &models.VoucherHold{
+		VoucherCode: hvs.VoucherCode,
+		Cabin:       v.Cabin,
+		SeatID:      seat.ID,
+		SeatLabel:   seat.Label,
+		Strategy:    strategy.Name(),
+		ExpiresAt:   clock.Format(expiresAt),
+	}
+	result.SatisfiedPreferences, result.UnsatisfiedPreferences = seating.Evaluate(seat, pool, hvs.Preferences)
+
+	return result, nil
+}
+
+func (vr *vouchersRepository) ConfirmHold(ctx context.Context, code string) (*models.VoucherAssigment, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v, err := vr.s.redeemableVoucher(code)
+	if err != nil {
+		return nil, err
+	}
+
+	h := vr.s.holdByVoucher(v.ID)
+	if h == nil {
+		return nil, errors.New("no seat held for voucher!")
+	}
+
+	now := vr.s.clock.Now()
+	if !now.Before(h.expiresAt) {
+		return nil, errors.New("seat hold expired!")
+	}
+
+	seat := vr.s.seatByID(h.seatID)
+	if seat == nil || seat.assigned {
+		return nil, errors.New("seat taken concurrently")
+	}
+
+	vr.s.assignSeats(v, models.Seats{seat.Seat}, h.strategy)
+
+	result := models.NewVoucherAssigment(code, v, models.Seats{seat.Seat}, h.strategy)
+	vr.s.deleteHolds(func(h holdRow) bool { return h.voucherID == v.ID })
+
+	return result, nil
+}
+
+func (vr *vouchersRepository) ReleaseHold(ctx context.Context, code string) error {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v := vr.s.voucherByCode(code)
+	if v == nil {
+		return repository.ErrVoucherNotFound
+	}
+
+	if vr.s.deleteHolds(func(h holdRow) bool { return h.voucherID == v.ID }) == 0 {
+		return errors.New("no seat held for voucher!")
+	}
+
+	vr.s.promoteWaitlist(v.FlightID)
+	return nil
+}
+
+func (vr *vouchersRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) ([]models.VoucherHold, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	holds := []models.VoucherHold{}
+	var flightIDs []int64
+	for _, h := range vr.s.holds {
+		if now.Before(h.expiresAt) {
+			continue
+		}
+		seat, v := vr.s.seatByID(h.seatID), vr.s.voucherByID(h.voucherID)
+		holds = append(holds, models.VoucherHold{
+			VoucherCode: v.Code,
+			Cabin:       v.Cabin,
+			SeatID:      seat.ID,
+			SeatLabel:   seat.Label,
+			Strategy:    h.strategy,
+			ExpiresAt:   clock.Format(h.expiresAt),
+		})
+		if !slices.Contains(flightIDs, seat.FlightID) {
+			flightIDs = append(flightIDs, seat.FlightID)
+		}
+	}
+
+	vr.s.deleteHolds(func(h holdRow) bool { return !now.Before(h.expiresAt) })
+	for _, flightID := range flightIDs {
+		vr.s.promoteWaitlist(flightID)
+	}
+	return holds, nil
+}
+
+// assignedVoucher looks up the voucher together with the assignment to
+// change, fromSeat tells which one when the voucher has several.
+func (vr *vouchersRepository) assignedVoucher(code, fromSeat string) (*models.Voucher, *assignmentRow, error) {
+	v := vr.s.voucherByCode(code)
+	if v == nil {
+		return nil, nil, repository.ErrVoucherNotFound
+	}
+	if v.Status == models.VoucherCheckedIn {
+		return nil, nil, repository.ErrCheckedIn
+	}
+
+	assignments := vr.s.assignmentsByVoucher(v.ID)
+	var assigned models.Seats
+	for _, a := range assignments {
+		assigned = append(assigned, vr.s.seatByID(a.seatID).Seat)
+	}
+
+	seat, err := repository.PickAssignedSeat(assigned, fromSeat)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return v, vr.s.assignmentBySeat(seat.ID), nil
+}
+
+func (vr *vouchersRepository) Unassign(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.SeatChange, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v, a, err := vr.assignedVoucher(cvs.VoucherCode, cvs.FromSeat)
+	if err != nil {
+		return nil, err
+	}
+
+	seat := vr.s.seatByID(a.seatID)
+	seat.assigned = false
+	vr.s.deleteAssignment(seat.ID)
+
+	// the voucher can be redeemed again once a whole redemption is given back
+	v.Redemptions, _ = vr.s.redemptions(v.ID)
+	if v.Redemptions < v.MaxRedemptions {
+		v.Redeemed = 0
+		if v.Status == models.VoucherRedeemed {
+			vr.s.setStatus(v, models.VoucherActive, cvs.ChangedBy, cvs.Reason)
+		}
+	}
+	if v.Redemptions == 0 {
+		v.RedeemedAt = nil
+	}
+
+	change := &models.SeatChange{
+		VoucherCode: cvs.VoucherCode,
+		Action:      models.SeatChangeUnassign,
+		FromSeat:    seat.Label,
+		ChangedBy:   cvs.ChangedBy,
+		Reason:      cvs.Reason,
+		ChangedAt:   clock.Format(vr.s.clock.Now()),
+	}
+	vr.s.recordSeatChange(v.ID, change)
+	vr.s.promoteWaitlist(v.FlightID)
+
+	return change, nil
+}
+
+func (vr *vouchersRepository) Move(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v, a, err := vr.assignedVoucher(cvs.VoucherCode, cvs.FromSeat)
+	if err != nil {
+		return nil, err
+	}
+
+	label := strings.ToUpper(strings.TrimSpace(cvs.SeatLabel))
+	var target *seatRow
+	for _, seat := range vr.s.seats {
+		if seat.FlightID == v.FlightID && seat.Cabin == v.Cabin && seat.Label == label {
+			target = seat
+			break
+		}
+	}
+	if target == nil {
+		return nil, errors.New("seat not found in cabin!")
+	}
+	if target.ID == a.seatID {
+		return nil, errors.New("voucher already assigned to seat!")
+	}
+	if target.Blocked || target.assigned || vr.s.activeHoldBySeat(target.ID, vr.s.clock.Now()) != nil {
+		return nil, errors.New("seat not available!")
+	}
+
+	return vr.changeSeat(v, a, target, a.strategy, models.SeatChangeMove, cvs), nil
+}
+
+func (vr *vouchersRepository) Reshuffle(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v, a, err := vr.assignedVoucher(cvs.VoucherCode, cvs.FromSeat)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := seating.Resolve(v.SeatStrategy.String, vr.s.defaultStrategy(v))
+	if err != nil {
+		return nil, err
+	}
+
+	// the current seat counts as assigned, so it is never picked again
+	pool := vr.s.cabinPool(v.FlightID, v.Cabin, vr.s.clock.Now())
+	ranked := seating.Rank(strategy, pool, cvs.Preferences)
+	if len(ranked) == 0 {
+		return nil, repository.ErrNoSeats
+	}
+
+	target := vr.s.seatByID(ranked[0].ID)
+	result := vr.changeSeat(v, a, target, strategy.Name(), models.SeatChangeReshuffle, cvs)
+	result.SatisfiedPreferences, result.UnsatisfiedPreferences = seating.Evaluate(target.Seat, pool, cvs.Preferences)
+
+	return result, nil
+}
+
+// changeSeat moves the assignment to the target seat and records the change.
+func (vr *vouchersRepository) changeSeat(v *models.Voucher, a *assignmentRow, target *seatRow, strategy, action string, cvs *models.ChangeVoucherSeat) *models.VoucherAssigment {
+	now := clock.Format(vr.s.clock.Now())
+
+	current := vr.s.seatByID(a.seatID)
+	current.assigned = false
+	target.assigned = true
+	a.seatID = target.ID
+	a.strategy = strategy
+	a.assignedAt = now
+
+	vr.s.recordSeatChange(v.ID, &models.SeatChange{
+		VoucherCode: cvs.VoucherCode,
+		Action:      action,
+		FromSeat:    current.Label,
+		ToSeat:      target.Label,
+		ChangedBy:   cvs.ChangedBy,
+		Reason:      cvs.Reason,
+		ChangedAt:   now,
+	})
+
+	return &models.VoucherAssigment{
+		VoucherCode: cvs.VoucherCode,
+		Cabin:       v.Cabin,
+		SeatID:      target.ID,
+		SeatLabel:   target.Label,
+		Strategy:    strategy,
+	}
+}
+
+func (vr *vouchersRepository) GetSeatChanges(ctx context.Context, code string) ([]models.SeatChange, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v := vr.s.voucherByCode(code)
+	if v == nil {
+		return nil, repository.ErrVoucherNotFound
+	}
+
+	changes := []models.SeatChange{}
+	for _, c := range vr.s.seatChanges {
+		if c.voucherID == v.ID {
+			changes = append(changes, c.SeatChange)
+		}
+	}
+
+	return changes, nil
+}
+
+func (vr *vouchersRepository) GetAll(ctx context.Context) (*models.Vouchers, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	var vouchers models.Vouchers
+	for _, v := range vr.s.vouchers {
+		v.Redemptions, _ = vr.s.redemptions(v.ID)
+		vouchers = append(vouchers, *v)
+	}
+
+	return &vouchers, nil
+}
+
+func (vr *vouchersRepository) GetByCode(ctx context.Context, code string) (*models.VoucherDetails, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v := vr.s.voucherByCode(code)
+	if v == nil {
+		return nil, repository.ErrVoucherNotFound
+	}
+	v.Redemptions, _ = vr.s.redemptions(v.ID)
+
+	details := &models.VoucherDetails{Voucher: *v}
+	if f := vr.s.flightByID(v.FlightID); f != nil {
+		details.FlightNo, details.DepDate = f.FlightNo, f.DepDate
+	}
+	for _, a := range vr.s.assignmentsByVoucher(v.ID) {
+		details.Seats = append(details.Seats, models.AssignedSeat{SeatID: a.seatID, SeatLabel: vr.s.seatByID(a.seatID).Label})
+	}
+
+	return details, nil
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/seating"
+	"context"
+	"errors"
+)
+
+// joinWaitlist puts the voucher at the end of its cabin's waitlist. A voucher
+// already waiting keeps its place.
+func (vr *vouchersRepository) joinWaitlist(v *models.Voucher) *models.WaitlistEntry {
+	for _, w := range vr.s.waitlist {
+		if w.voucherID == v.ID && w.status == models.WaitlistWaiting {
+			return vr.waitlistEntry(w)
+		}
+	}
+
+	vr.s.nextWaitlistID++
+	w := waitlistRow{
+		id:        vr.s.nextWaitlistID,
+		voucherID: v.ID,
+		flightID:  v.FlightID,
+		cabin:     v.Cabin,
+		status:    models.WaitlistWaiting,
+		joinedAt:  clock.Format(vr.s.clock.Now()),
+	}
+	vr.s.waitlist = append(vr.s.waitlist, w)
+
+	return vr.waitlistEntry(w)
+}
+
+func (vr *vouchersRepository) waitlistEntry(w waitlistRow) *models.WaitlistEntry {
+	entry := &models.WaitlistEntry{
+		FlightID:   w.flightID,
+		Cabin:      w.cabin,
+		Status:     w.status,
+		JoinedAt:   w.joinedAt,
+		AssignedAt: w.assignedAt,
+	}
+	if v := vr.s.voucherByID(w.voucherID); v != nil {
+		entry.VoucherCode = v.Code
+	}
+
+	switch {
+	case w.status == models.WaitlistWaiting:
+		for _, other := range vr.s.waitlist {
+			if other.flightID == w.flightID && other.cabin == w.cabin && other.status == models.WaitlistWaiting && other.id <= w.id {
+				entry.Position++
+			}
+		}
+	case w.redemption > 0:
+		for _, a := range vr.s.assignmentsByVoucher(w.voucherID) {
+			if a.redemption == w.redemption {
+				entry.Seats = append(entry.Seats, models.AssignedSeat{SeatID: a.seatID, SeatLabel: vr.s.seatByID(a.seatID).Label})
+			}
+		}
+	}
+
+	return entry
+}
+
+// promoteWaitlist seats the flight's waiting vouchers, first come first
+// served, after seats were released or added. A group that does not fit yet
+// keeps its place while smaller ones behind it are seated. Vouchers that can
+// no longer be redeemed leave the waitlist.
+func (s *Store) promoteWaitlist(flightID int64) {
+	now := s.clock.Now()
+	for i := range s.waitlist {
+		w := &s.waitlist[i]
+		if w.flightID != flightID || w.status != models.WaitlistWaiting {
+			continue
+		}
+
+		v := s.voucherByID(w.voucherID)
+		if v == nil {
+			continue
+		}
+		_, err := s.redeemableVoucher(v.Code)
+		switch {
+		case errors.Is(err, repository.ErrVoucherExpired), errors.Is(err, repository.ErrVoucherRevoked), errors.Is(err, repository.ErrVoucherCancelled), errors.Is(err, repository.ErrFlightDeparted):
+			w.status = models.WaitlistExpired
+			continue
+		case errors.Is(err, repository.ErrVoucherRedeemed):
+			w.status, w.assignedAt = models.WaitlistAssigned, clock.Format(now)
+			continue
+		case err != nil:
+			continue
+		}
+
+		strategy, err := seating.Resolve(v.SeatStrategy.String, s.defaultStrategy(v))
+		if err != nil {
+			continue
+		}
+
+		groups := seating.RankGroups(strategy, s.cabinPool(v.FlightID, v.Cabin, now), nil, v.GroupSize)
+		if len(groups) == 0 {
+			continue
+		}
+		s.assignSeats(v, groups[0].Seats, strategy.Name())
+	}
+}
+
+func (vr *vouchersRepository) GetWaitlist(ctx context.Context, code string) (*models.WaitlistEntry, error) {
+	vr.s.mu.Lock()
+	defer vr.s.mu.Unlock()
+
+	v := vr.s.voucherByCode(code)
+	if v == nil {
+		return nil, repository.ErrVoucherNotFound
+	}
+
+	// the latest entry, a multi-use voucher may have waited before
+	for i := len(vr.s.waitlist) - 1; i >= 0; i-- {
+		if vr.s.waitlist[i].voucherID == v.ID {
+			return vr.waitlistEntry(vr.s.waitlist[i]), nil
+		}
+	}
+
+	return nil, errors.New("voucher not on the waitlist!")
+}
@@ -1,31 +1,103 @@
 package repository
 
 import (
+	"backend/internal/clock"
 	"backend/internal/models"
+	"backend/internal/seating"
+	"backend/pkg/db"
 	"context"
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 )
 
 type SeatRepository interface {
 	Create(ctx context.Context, cbs *models.CreateBulkSeat) error
 	GetAll(ctx context.Context) (*models.Seats, error)
+	GetByFlight(ctx context.Context, flightID int64) (*models.Seats, error)
+	GetOccupancy(ctx context.Context, flightID int64) ([]models.SeatOccupancy, error)
+	// GetCabinPool loads a cabin's free and occupied seats as a redemption
+	// would see them, along with the flight's default seat strategy.
+	GetCabinPool(ctx context.Context, flightID int64, cabin string) (seating.SeatPool, string, error)
 }
 
 type seatRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	driver db.Driver
+	clock  clock.Clock
 }
 
-func NewSeatRepository(db *sql.DB) SeatRepository {
+func NewSeatRepository(conn *sql.DB, clk clock.Clock) SeatRepository {
 	return &seatRepository{
-		db: db,
+		db:     conn,
+		driver: db.SQLite,
+		clock:  clock.OrSystem(clk),
 	}
 }
 
+func NewPostgresSeatRepository(conn *sql.DB, clk clock.Clock) SeatRepository {
+	return &seatRepository{
+		db:     conn,
+		driver: db.Postgres,
+		clock:  clock.OrSystem(clk),
+	}
+}
+
+const seatColumns = `id, flight_id, label, cabin, row_no, column_letter, position,
+	exit_row, bulkhead, extra_legroom, reclining, power_outlet, blocked`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// querier runs a query on the database or within a transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanSeat(row rowScanner, extra ...any) (models.Seat, error) {
+	var seat models.Seat
+	var rowNo sql.NullInt64
+	var column, position sql.NullString
+
+	dest := append([]any{&seat.ID, &seat.FlightID, &seat.Label, &seat.Cabin, &rowNo, &column, &position,
+		&seat.ExitRow, &seat.Bulkhead, &seat.ExtraLegroom, &seat.Reclining, &seat.PowerOutlet, &seat.Blocked}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return seat, err
+	}
+
+	seat.Row = int(rowNo.Int64)
+	seat.Column = column.String
+	seat.Position = position.String
+
+	// seats created before attributes existed only have a label
+	if seat.Row == 0 {
+		seat.Row, seat.Column, _ = seating.ParseLabel(seat.Label)
+	}
+
+	return seat, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullIfZero(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
 func (sr *seatRepository) flightExists(ctx context.Context, flightID int64) (bool, error) {
 	var exists bool
-	err := sr.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM flights WHERE id = ?)", flightID).Scan(&exists)
+	err := sr.db.QueryRowContext(ctx, sr.driver.Rebind("SELECT EXISTS(SELECT 1 FROM flights WHERE id = ?)"), flightID).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
@@ -40,19 +112,39 @@ func (sr *seatRepository) Create(ctx context.Context, cbs *models.CreateBulkSeat
 		return err
 	}
 	if !exists {
-		return errors.New("flight not found")
+		return ErrFlightNotFound
 	}
 
 	tx, _ := sr.db.Begin()
 	defer tx.Rollback()
 
+	seats := cbs.Seats
 	for _, l := range cbs.Labels {
-		l = strings.ToUpper(strings.TrimSpace(l))
-		if _, err := tx.Exec(`INSERT INTO seats(flight_id, label, cabin) VALUES(?,?,?)`, cbs.FlightID, l, cbs.Cabin); err != nil {
+		seats = append(seats, models.Seat{Label: l, Cabin: cbs.Cabin, SeatAttributes: models.SeatAttributes{Reclining: true}})
+	}
+
+	for _, s := range seats {
+		s.Label = strings.ToUpper(strings.TrimSpace(s.Label))
+		if s.Cabin == "" {
+			s.Cabin = cbs.Cabin
+		}
+		if s.Row == 0 {
+			s.Row, s.Column, _ = seating.ParseLabel(s.Label)
+		}
+
+		if _, err := tx.Exec(sr.driver.Rebind(`INSERT INTO seats(flight_id, label, cabin, row_no, column_letter, position,
+			exit_row, bulkhead, extra_legroom, reclining, power_outlet, blocked) VALUES(?,?,?,?,?,?,?,?,?,?,?,?)`),
+			cbs.FlightID, s.Label, s.Cabin, nullIfZero(s.Row), nullIfEmpty(s.Column), nullIfEmpty(s.Position),
+			boolToInt(s.ExitRow), boolToInt(s.Bulkhead), boolToInt(s.ExtraLegroom), boolToInt(s.Reclining), boolToInt(s.PowerOutlet), boolToInt(s.Blocked)); err != nil {
 			return err
 		}
 	}
 
+	// new seats go to the flight's waitlist first
+	if err := promoteWaitlist(ctx, tx, sr.driver, sr.clock, cbs.FlightID); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return err
 	}
@@ -61,15 +153,16 @@ func (sr *seatRepository) Create(ctx context.Context, cbs *models.CreateBulkSeat
 }
 
 func (sr *seatRepository) GetAll(ctx context.Context) (*models.Seats, error) {
-	rows, err := sr.db.Query("SELECT id, flight_id, label, cabin FROM seats")
+	rows, err := sr.db.Query("SELECT " + seatColumns + " FROM seats")
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var seats models.Seats
 	for rows.Next() {
-		var seat models.Seat
-		if err := rows.Scan(&seat.ID, &seat.FlightID, &seat.Label, &seat.Cabin); err != nil {
+		seat, err := scanSeat(rows)
+		if err != nil {
 			return nil, err
 		}
 		seats = append(seats, seat)
@@ -77,3 +170,101 @@ func (sr *seatRepository) GetAll(ctx context.Context) (*models.Seats, error) {
 
 	return &seats, nil
 }
+
+func (sr *seatRepository) GetByFlight(ctx context.Context, flightID int64) (*models.Seats, error) {
+	rows, err := sr.db.QueryContext(ctx, sr.driver.Rebind("SELECT "+seatColumns+" FROM seats WHERE flight_id=? ORDER BY id"), flightID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seats models.Seats
+	for rows.Next() {
+		seat, err := scanSeat(rows)
+		if err != nil {
+			return nil, err
+		}
+		seats = append(seats, seat)
+	}
+
+	return &seats, rows.Err()
+}
+
+func (sr *seatRepository) GetOccupancy(ctx context.Context, flightID int64) ([]models.SeatOccupancy, error) {
+	exists, err := sr.flightExists(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrFlightNotFound
+	}
+
+	rows, err := sr.db.QueryContext(ctx, sr.driver.Rebind(`SELECT `+seatColumns+`, is_assigned,
+		EXISTS(SELECT 1 FROM seat_holds h WHERE h.seat_id = seats.id AND h.expires_at > ?),
+		(SELECT v.code FROM seat_assignments sa JOIN vouchers v ON v.id = sa.voucher_id WHERE sa.seat_id = seats.id)
+		FROM seats WHERE flight_id=? ORDER BY id`), clock.Format(sr.clock.Now()), flightID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seats []models.SeatOccupancy
+	for rows.Next() {
+		var assigned, held bool
+		var code sql.NullString
+		seat, err := scanSeat(rows, &assigned, &held, &code)
+		if err != nil {
+			return nil, err
+		}
+		seats = append(seats, models.SeatOccupancy{Seat: seat, Assigned: assigned, Held: held, VoucherCode: code.String})
+	}
+
+	return seats, rows.Err()
+}
+
+func (sr *seatRepository) GetCabinPool(ctx context.Context, flightID int64, cabin string) (seating.SeatPool, string, error) {
+	var strategy sql.NullString
+	err := sr.db.QueryRowContext(ctx, sr.driver.Rebind(`SELECT seat_strategy FROM flights WHERE id=?`), flightID).Scan(&strategy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return seating.SeatPool{}, "", ErrFlightNotFound
+	} else if err != nil {
+		return seating.SeatPool{}, "", err
+	}
+
+	pool, err := cabinPool(ctx, sr.db, sr.driver, flightID, cabin, sr.clock.Now())
+	return pool, strategy.String, err
+}
+
+// cabinPool loads the free and assigned seats of a flight's cabin for the
+// strategies to rank. Seats held for another voucher count as assigned.
+func cabinPool(ctx context.Context, q querier, driver db.Driver, flightID int64, cabin string, now time.Time) (seating.SeatPool, error) {
+	var pool seating.SeatPool
+
+	rows, err := q.QueryContext(ctx, driver.Rebind(`SELECT `+seatColumns+`, is_assigned,
+		EXISTS(SELECT 1 FROM seat_holds h WHERE h.seat_id = seats.id AND h.expires_at > ?)
+		FROM seats WHERE flight_id=? AND cabin=?`), clock.Format(now), flightID, cabin)
+	if err != nil {
+		return pool, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var isAssigned int
+		var held bool
+		seat, err := scanSeat(rows, &isAssigned, &held)
+		if err != nil {
+			return pool, err
+		}
+
+		switch {
+		case seat.Blocked:
+			// blocked seats are neither offered nor count as occupied
+		case isAssigned == 1, held:
+			pool.Assigned = append(pool.Assigned, seat)
+		default:
+			pool.Free = append(pool.Free, seat)
+		}
+	}
+
+	return pool, rows.Err()
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"backend/pkg/db"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ThrottleRepository keeps the redemption throttle state, in the database when
+// several instances have to share it.
+type ThrottleRepository interface {
+	// Update applies fn to the key's state and saves it, atomically. A key
+	// seen for the first time starts from the zero state.
+	Update(ctx context.Context, key string, fn func(s *models.ThrottleState)) (*models.ThrottleState, error)
+	// Prune deletes the state of keys neither counted nor locked since staleBefore.
+	Prune(ctx context.Context, staleBefore time.Time) (int64, error)
+}
+
+type throttleRepository struct {
+	db     *sql.DB
+	driver db.Driver
+}
+
+func NewThrottleRepository(conn *sql.DB) ThrottleRepository {
+	return &throttleRepository{
+		db:     conn,
+		driver: db.SQLite,
+	}
+}
+
+func NewPostgresThrottleRepository(conn *sql.DB) ThrottleRepository {
+	return &throttleRepository{
+		db:     conn,
+		driver: db.Postgres,
+	}
+}
+
+func (tr *throttleRepository) Update(ctx context.Context, key string, fn func(s *models.ThrottleState)) (*models.ThrottleState, error) {
+	tx, err := tr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// inserting first takes the write lock on SQLite and the row lock on Postgres
+	if _, err := tx.ExecContext(ctx, tr.driver.Rebind(`INSERT INTO redeem_throttle(throttle_key) VALUES(?) ON CONFLICT(throttle_key) DO NOTHING`), key); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT window_start, hits, failures, lockouts, locked_until FROM redeem_throttle WHERE throttle_key=?`
+	if tr.driver == db.Postgres {
+		query += ` FOR UPDATE`
+	}
+
+	s := &models.ThrottleState{Key: key}
+	var windowStart, lockedUntil string
+	if err := tx.QueryRowContext(ctx, tr.driver.Rebind(query), key).Scan(&windowStart, &s.Hits, &s.Failures, &s.Lockouts, &lockedUntil); err != nil {
+		return nil, err
+	}
+	s.WindowStart = parseThrottleTime(windowStart)
+	s.LockedUntil = parseThrottleTime(lockedUntil)
+
+	fn(s)
+
+	if _, err := tx.ExecContext(ctx, tr.driver.Rebind(`UPDATE redeem_throttle SET window_start=?, hits=?, failures=?, lockouts=?, locked_until=? WHERE throttle_key=?`),
+		formatThrottleTime(s.WindowStart), s.Hits, s.Failures, s.Lockouts, formatThrottleTime(s.LockedUntil), key); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (tr *throttleRepository) Prune(ctx context.Context, staleBefore time.Time) (int64, error) {
+	before := formatThrottleTime(staleBefore)
+	res, err := tr.db.ExecContext(ctx, tr.driver.Rebind(`DELETE FROM redeem_throttle WHERE window_start < ? AND locked_until < ?`), before, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// formatThrottleTime stores the zero time as an empty string, which sorts
+// before every timestamp.
+func formatThrottleTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseThrottleTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/pkg/db"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// setStatus moves the voucher to a new status and records the transition.
+// Redemptions pass an empty changedBy.
+func setStatus(ctx context.Context, tx *sql.Tx, driver db.Driver, v *models.Voucher, to, changedBy, reason string, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, driver.Rebind(`UPDATE vouchers SET status=? WHERE id=?`), to, v.ID); err != nil {
+		return err
+	}
+
+	_, err := recordStatusChange(ctx, tx, driver, v.ID, &models.VoucherStatusChange{
+		FromStatus: v.Status,
+		ToStatus:   to,
+		ChangedBy:  changedBy,
+		Reason:     reason,
+		ChangedAt:  clock.Format(now),
+	})
+	v.Status = to
+	return err
+}
+
+func recordStatusChange(ctx context.Context, tx *sql.Tx, driver db.Driver, voucherID int64, change *models.VoucherStatusChange) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, driver.Rebind(`INSERT INTO voucher_status_changes(voucher_id, from_status, to_status, changed_by, reason, changed_at)
+		VALUES(?, ?, ?, ?, ?, ?) RETURNING id`), voucherID, change.FromStatus, change.ToStatus, change.ChangedBy, change.Reason, change.ChangedAt).Scan(&id)
+	return id, err
+}
+
+// ChangeStatus moves the voucher from cvs.From to cvs.To, failing when the
+// status changed in the meantime. A revoked or cancelled voucher gives up its
+// seats, and like an expired one its hold and place on the waitlist. What is
+// freed goes to the flight's waitlist.
+func (vr *vouchersRepository) ChangeStatus(ctx context.Context, cvs *models.ChangeVoucherStatus) (*models.VoucherStatusChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	v := &models.Voucher{Code: cvs.VoucherCode}
+	err = tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id, flight_id FROM vouchers WHERE code=?`), cvs.VoucherCode).Scan(&v.ID, &v.FlightID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVoucherNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE vouchers SET status=? WHERE id=? AND status=?`), cvs.To, v.ID, cvs.From)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrStatusChanged
+	}
+
+	now := vr.clock.Now()
+	change := &models.VoucherStatusChange{
+		VoucherCode: cvs.VoucherCode,
+		FromStatus:  cvs.From,
+		ToStatus:    cvs.To,
+		ChangedBy:   cvs.ChangedBy,
+		Reason:      cvs.Reason,
+		ChangedAt:   clock.Format(now),
+	}
+
+	switch cvs.To {
+	case models.VoucherRevoked, models.VoucherCancelled:
+		change.FreedSeats, err = vr.releaseSeats(ctx, tx, v, cvs, now)
+		if err != nil {
+			return nil, err
+		}
+		fallthrough
+	case models.VoucherExpired:
+		if err := vr.leaveQueues(ctx, tx, v.ID); err != nil {
+			return nil, err
+		}
+		if err := promoteWaitlist(ctx, tx, vr.driver, vr.clock, v.FlightID); err != nil {
+			return nil, err
+		}
+	}
+
+	change.ID, err = recordStatusChange(ctx, tx, vr.driver, v.ID, change)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// releaseSeats frees every seat assigned to the voucher, recording each as
+// unassigned, and returns their labels.
+func (vr *vouchersRepository) releaseSeats(ctx context.Context, tx *sql.Tx, v *models.Voucher, cvs *models.ChangeVoucherStatus, now time.Time) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, vr.driver.Rebind(`SELECT s.id, s.label FROM seat_assignments sa JOIN seats s ON s.id = sa.seat_id
+		WHERE sa.voucher_id=? ORDER BY sa.id`), v.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	var labels []string
+	for rows.Next() {
+		var id int64
+		var label string
+		if err := rows.Scan(&id, &label); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		labels = append(labels, label)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_assignments WHERE seat_id=?`), id); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE seats SET is_assigned=0 WHERE id=?`), id); err != nil {
+			return nil, err
+		}
+		if err := vr.recordSeatChange(ctx, tx, v.ID, &models.SeatChange{
+			Action:    models.SeatChangeUnassign,
+			FromSeat:  labels[i],
+			ChangedBy: cvs.ChangedBy,
+			Reason:    cvs.Reason,
+			ChangedAt: clock.Format(now),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return labels, nil
+}
+
+// leaveQueues drops the voucher's seat hold and takes it off the waitlist.
+func (vr *vouchersRepository) leaveQueues(ctx context.Context, tx *sql.Tx, voucherID int64) error {
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_holds WHERE voucher_id=?`), voucherID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE waitlist SET status=? WHERE voucher_id=? AND status=?`),
+		models.WaitlistExpired, voucherID, models.WaitlistWaiting)
+	return err
+}
+
+// ExpiringVouchers lists the issued and active vouchers past their expiry at
+// now, still to be marked expired.
+func (vr *vouchersRepository) ExpiringVouchers(ctx context.Context, now time.Time) ([]models.Voucher, error) {
+	rows, err := vr.db.QueryContext(ctx, vr.driver.Rebind(`SELECT id, code, flight_id, status, expires_at FROM vouchers
+		WHERE status IN (?, ?) AND expires_at IS NOT NULL AND expires_at <> '' AND expires_at < ? ORDER BY id`),
+		models.VoucherIssued, models.VoucherActive, clock.Format(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vouchers := []models.Voucher{}
+	for rows.Next() {
+		var v models.Voucher
+		if err := rows.Scan(&v.ID, &v.Code, &v.FlightID, &v.Status, &v.ExpiresAt); err != nil {
+			return nil, err
+		}
+		vouchers = append(vouchers, v)
+	}
+
+	return vouchers, rows.Err()
+}
+
+func (vr *vouchersRepository) GetStatusChanges(ctx context.Context, code string) ([]models.VoucherStatusChange, error) {
+	var voucherID int64
+	err := vr.db.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id FROM vouchers WHERE code=?`), code).Scan(&voucherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVoucherNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := vr.db.QueryContext(ctx, vr.driver.Rebind(`SELECT id, from_status, to_status, COALESCE(changed_by,''), COALESCE(reason,''), changed_at
+		FROM voucher_status_changes WHERE voucher_id=? ORDER BY id`), voucherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.VoucherStatusChange{}
+	for rows.Next() {
+		change := models.VoucherStatusChange{VoucherCode: code}
+		if err := rows.Scan(&change.ID, &change.FromStatus, &change.ToStatus, &change.ChangedBy, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
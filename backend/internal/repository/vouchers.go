@@ -1,151 +1,1043 @@
 package repository
 
 import (
+	"backend/internal/clock"
 	"backend/internal/models"
+	"backend/internal/seating"
+	"backend/pkg/db"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
+var (
+	// ErrVoucherNotFound is told apart from other failures to throttle code guessing.
+	ErrVoucherNotFound = errors.New("voucher not found!")
+	// ErrGroupHold rejects holds for group vouchers, a hold is a single seat.
+	ErrGroupHold = errors.New("seats of group vouchers can not be held!")
+	// ErrNoSeats is matched by every NoSeatsError, whatever the group size.
+	ErrNoSeats = errors.New("no available seats in cabin!")
+
+	ErrVoucherRedeemed  = errors.New("voucher already redeemed!")
+	ErrVoucherExpired   = errors.New("voucher expired!")
+	ErrVoucherInactive  = errors.New("voucher not active yet!")
+	ErrVoucherRevoked   = errors.New("voucher revoked!")
+	ErrVoucherCancelled = errors.New("voucher cancelled!")
+	// ErrCheckedIn rejects seat changes once the passenger has checked in.
+	ErrCheckedIn = errors.New("voucher already checked in!")
+	// ErrStatusChanged rejects a status change checked against a stale status.
+	ErrStatusChanged = errors.New("voucher status changed concurrently, try again!")
+)
+
+// RedeemStatusError tells why a voucher in the given status can not be
+// redeemed, nil when it can.
+func RedeemStatusError(status string) error {
+	switch status {
+	case models.VoucherIssued:
+		return ErrVoucherInactive
+	case models.VoucherRedeemed, models.VoucherCheckedIn:
+		return ErrVoucherRedeemed
+	case models.VoucherRevoked:
+		return ErrVoucherRevoked
+	case models.VoucherExpired:
+		return ErrVoucherExpired
+	case models.VoucherCancelled:
+		return ErrVoucherCancelled
+	}
+	return nil
+}
+
 type VouchersRepository interface {
 	Assigns(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, error)
 	Create(ctx context.Context, cnv *models.CreateNewVoucher) error
+	CreateBatch(ctx context.Context, cvb *models.CreateVoucherBatch, newCode func() (string, error)) (*models.VoucherBatch, error)
 	GetAll(ctx context.Context) (*models.Vouchers, error)
+	GetByCode(ctx context.Context, code string) (*models.VoucherDetails, error)
+	Hold(ctx context.Context, hvs *models.HoldVoucherSeat) (*models.VoucherHold, error)
+	ConfirmHold(ctx context.Context, code string) (*models.VoucherAssigment, error)
+	ReleaseHold(ctx context.Context, code string) error
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) ([]models.VoucherHold, error)
+	ExpiringVouchers(ctx context.Context, now time.Time) ([]models.Voucher, error)
+	Unassign(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.SeatChange, error)
+	Move(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error)
+	Reshuffle(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error)
+	GetSeatChanges(ctx context.Context, code string) ([]models.SeatChange, error)
+	GetWaitlist(ctx context.Context, code string) (*models.WaitlistEntry, error)
+	ChangeStatus(ctx context.Context, cvs *models.ChangeVoucherStatus) (*models.VoucherStatusChange, error)
+	GetStatusChanges(ctx context.Context, code string) ([]models.VoucherStatusChange, error)
 }
 
 type vouchersRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	driver db.Driver
+	clock  clock.Clock
 }
 
-func NewVouchersRepository(db *sql.DB) VouchersRepository {
+func NewVouchersRepository(conn *sql.DB, clk clock.Clock) VouchersRepository {
 	return &vouchersRepository{
-		db,
+		db:     conn,
+		driver: db.SQLite,
+		clock:  clock.OrSystem(clk),
+	}
+}
+
+func NewPostgresVouchersRepository(conn *sql.DB, clk clock.Clock) VouchersRepository {
+	return &vouchersRepository{
+		db:     conn,
+		driver: db.Postgres,
+		clock:  clock.OrSystem(clk),
 	}
 }
 
 func (vr *vouchersRepository) Create(ctx context.Context, cnv *models.CreateNewVoucher) error {
 	var seatID int64
-	err := vr.db.QueryRow(`SELECT id FROM seats WHERE flight_id=? AND cabin=? LIMIT 1`, cnv.FlightID, cnv.Cabin).Scan(&seatID)
+	err := vr.db.QueryRow(vr.driver.Rebind(`SELECT id FROM seats WHERE flight_id=? AND cabin=? LIMIT 1`), cnv.FlightID, cnv.Cabin).Scan(&seatID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return errors.New("no seats available for this flight and cabin")
 	} else if err != nil {
 		return err
 	}
 
-	if _, err := vr.db.Exec(`INSERT INTO vouchers(code, flight_id, cabin, expires_at) VALUES(?, ?, ?, ?)`, cnv.Code, cnv.FlightID, cnv.Cabin, cnv.ExpiresAt); err != nil {
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	expiresAt := cnv.ExpiresAt
+	var expiresBefore sql.NullInt64
+	if cnv.ExpiresBeforeDeparture > 0 {
+		departure, err := flightDeparture(ctx, tx, vr.driver, cnv.FlightID)
+		if err != nil {
+			return err
+		}
+		if departure == nil {
+			return ErrNoDeparture
+		}
+		expiresAt = relativeExpiry(*departure, cnv.ExpiresBeforeDeparture)
+		expiresBefore = sql.NullInt64{Int64: int64(cnv.ExpiresBeforeDeparture / time.Second), Valid: true}
+	}
+
+	if cnv.CampaignID.Valid {
+		c, err := campaignForVouchers(ctx, tx, vr.driver, cnv.CampaignID.Int64, cnv.FlightID, cnv.Cabin, 1, vr.clock.Now())
+		if err != nil {
+			return err
+		}
+		expiresAt = campaignExpiry(c, expiresAt)
+	}
+
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`INSERT INTO vouchers(code, flight_id, cabin, expires_at, seat_strategy, campaign_id, max_redemptions, group_size, status, expires_before_departure)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		cnv.Code, cnv.FlightID, cnv.Cabin, expiresAt, cnv.SeatStrategy, cnv.CampaignID, max(cnv.MaxRedemptions, 1), max(cnv.GroupSize, 1), InitialStatus(cnv.Status), expiresBefore); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InitialStatus is the status of a new voucher, ACTIVE unless it is issued
+// to be activated later.
+func InitialStatus(status string) string {
+	if status == "" {
+		return models.VoucherActive
+	}
+	return status
+}
+
+// campaignExpiry falls back to the campaign's voucher expiry.
+func campaignExpiry(c *models.Campaign, expiresAt sql.NullString) sql.NullString {
+	if !expiresAt.Valid && c.VoucherExpiresAt != "" {
+		return sql.NullString{String: c.VoucherExpiresAt, Valid: true}
+	}
+	return expiresAt
+}
+
+// CreateBatch mints cvb.Count vouchers with codes from newCode in one
+// transaction. A code that already exists is skipped and replaced by a fresh one.
+func (vr *vouchersRepository) CreateBatch(ctx context.Context, cvb *models.CreateVoucherBatch, newCode func() (string, error)) (*models.VoucherBatch, error) {
+	var seatID int64
+	err := vr.db.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id FROM seats WHERE flight_id=? AND cabin=? LIMIT 1`), cvb.FlightID, cvb.Cabin).Scan(&seatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("no seats available for this flight and cabin")
+	} else if err != nil {
+		return nil, err
+	}
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	expiresAt := cvb.ExpiresAt
+	if cvb.CampaignID.Valid {
+		c, err := campaignForVouchers(ctx, tx, vr.driver, cvb.CampaignID.Int64, cvb.FlightID, cvb.Cabin, cvb.Count, vr.clock.Now())
+		if err != nil {
+			return nil, err
+		}
+		expiresAt = campaignExpiry(c, expiresAt)
+	}
+
+	batch := &models.VoucherBatch{
+		FlightID:  cvb.FlightID,
+		Cabin:     cvb.Cabin,
+		ExpiresAt: expiresAt.String,
+		Codes:     make([]string, 0, cvb.Count),
+	}
+
+	maxAttempts := 3*cvb.Count + 100
+	for attempt := 0; len(batch.Codes) < cvb.Count; attempt++ {
+		if attempt == maxAttempts {
+			return nil, errors.New("not enough unique voucher codes, use a longer code!")
+		}
+
+		code, err := newCode()
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := tx.ExecContext(ctx, vr.driver.Rebind(`INSERT INTO vouchers(code, flight_id, cabin, expires_at, seat_strategy, campaign_id, max_redemptions, group_size, status)
+			VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(code) DO NOTHING`),
+			code, cvb.FlightID, cvb.Cabin, expiresAt, cvb.SeatStrategy, cvb.CampaignID, max(cvb.MaxRedemptions, 1), max(cvb.GroupSize, 1), InitialStatus(cvb.Status))
+		if err != nil {
+			return nil, err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 1 {
+			batch.Codes = append(batch.Codes, code)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
 }
 
 func (vr *vouchersRepository) Assigns(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, error) {
 	const maxAttempts = 3
 	var lastError error
-	var result models.VoucherAssigment
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
+		result, retry, err := vr.assignOnce(ctx, arv)
+		if err == nil || !retry {
+			return result, err
+		}
+		lastError = err
+	}
+
+	return nil, lastError
+}
+
+// assignOnce is a single attempt of Assigns in its own transaction, rolled
+// back before the next attempt. Retry tells whether another attempt may
+// succeed, e.g. after a concurrent redeemer took the ranked seats.
+func (vr *vouchersRepository) assignOnce(ctx context.Context, arv *models.AssignsRandomVoucher) (*models.VoucherAssigment, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	v, flightStrategy, err := redeemableVoucher(ctx, tx, vr.driver, vr.clock, arv.VoucherCode)
+	if err != nil {
+		return nil, false, err
+	}
+
+	strategy, err := seating.Resolve(v.SeatStrategy.String, flightStrategy)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// assigning straight away drops a seat the voucher was holding
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_holds WHERE voucher_id=?`), v.ID); err != nil {
+		return nil, true, err
+	}
+
+	now := vr.clock.Now()
+	pool, err := cabinPool(ctx, tx, vr.driver, v.FlightID, v.Cabin, now)
+	if err != nil {
+		return nil, true, err
+	}
 
-		tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	group, err := claimGroup(ctx, tx, vr.driver, seating.RankGroups(strategy, pool, arv.Preferences, v.GroupSize), now)
+	if err != nil {
+		return nil, true, err
+	}
+	if group == nil && arv.Waitlist {
+		entry, err := vr.joinWaitlist(ctx, tx, v, now)
 		if err != nil {
-			return nil, err
+			return nil, false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, false, err
 		}
-		defer tx.Rollback()
+		return nil, false, &models.WaitlistedError{Entry: entry}
+	}
+	if group == nil {
+		return nil, true, NoSeatsError(v.GroupSize)
+	}
+	seats := group.Seats
 
-		var v models.Voucher
-		err = tx.QueryRowContext(ctx, `SELECT id, flight_id, cabin, redeemed, COALESCE(expires_at,'')
-		FROM vouchers WHERE code=?`, arv.VoucherCode).Scan(&v.ID, &v.FlightID, &v.Cabin, &v.Redeemed, &v.ExpiresAt)
+	if err := assignSeats(ctx, tx, vr.driver, v, seats, strategy.Name(), now); err != nil {
+		return nil, true, err
+	}
 
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("voucher not found!")
-		} else if err != nil {
-			return nil, err
-		}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	result := models.NewVoucherAssigment(arv.VoucherCode, v, seats, strategy.Name())
+	if v.GroupSize > 1 {
+		result.Arrangement = group.Arrangement
+	}
+	result.SatisfiedPreferences, result.UnsatisfiedPreferences = seating.Evaluate(seats[0], pool, arv.Preferences)
+
+	return result, false, nil
+}
+
+// NoSeatsError tells a redeemer the cabin has no room for the voucher's group.
+func NoSeatsError(groupSize int) error {
+	return noSeatsError{groupSize: groupSize}
+}
+
+type noSeatsError struct {
+	groupSize int
+}
+
+func (e noSeatsError) Error() string {
+	if e.groupSize > 1 {
+		return fmt.Sprintf("no %d adjacent seats available in cabin!", e.groupSize)
+	}
+	return ErrNoSeats.Error()
+}
+
+func (e noSeatsError) Is(target error) bool {
+	return target == ErrNoSeats
+}
 
-		if v.Redeemed == 1 {
-			return nil, errors.New("voucher already redeemed!")
+// claimGroup claims the first ranked group whose seats are all still free.
+// The seats of a group claimed only in part are freed again, so the group is
+// seated together or not at all.
+func claimGroup(ctx context.Context, tx *sql.Tx, driver db.Driver, groups []seating.SeatGroup, now time.Time) (*seating.SeatGroup, error) {
+	for _, group := range groups {
+		var claimed models.Seats
+		for _, s := range group.Seats {
+			seat, err := claimSeat(ctx, tx, driver, models.Seats{s}, now)
+			if err != nil {
+				return nil, err
+			}
+			if seat == nil {
+				break
+			}
+			claimed = append(claimed, *seat)
 		}
 
-		if v.ExpiresAt.Valid && v.ExpiresAt.String != "" {
-			if t, e := time.Parse(time.RFC3339, v.ExpiresAt.String); e == nil && time.Now().After(t) {
-				return nil, errors.New("voucher expired!")
+		if len(claimed) == len(group.Seats) {
+			return &seating.SeatGroup{Seats: claimed, Arrangement: group.Arrangement}, nil
+		}
+		for _, seat := range claimed {
+			if _, err := tx.ExecContext(ctx, driver.Rebind(`UPDATE seats SET is_assigned=0 WHERE id=?`), seat.ID); err != nil {
+				return nil, err
 			}
 		}
+	}
+
+	return nil, nil
+}
+
+// assignSeats records the claimed seats as the voucher's next redemption and
+// marks the voucher redeemed once it has no redemptions left.
+func assignSeats(ctx context.Context, tx *sql.Tx, driver db.Driver, v *models.Voucher, seats models.Seats, strategy string, now time.Time) error {
+	var redemption int
+	if err := tx.QueryRowContext(ctx, driver.Rebind(`SELECT COALESCE(MAX(redemption), 0) + 1 FROM seat_assignments WHERE voucher_id=?`), v.ID).Scan(&redemption); err != nil {
+		return err
+	}
 
-		candidateSeatID, err := pickSeatRandomly(ctx, tx, v.FlightID, v.Cabin)
+	for _, seat := range seats {
+		res, err := tx.ExecContext(ctx, driver.Rebind(`INSERT INTO seat_assignments(voucher_id, seat_id, redemption, strategy, assigned_at) VALUES(?, ?, ?, ?, ?)
+			ON CONFLICT(seat_id) DO NOTHING`), v.ID, seat.ID, redemption, strategy, clock.Format(now))
 		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errors.New("seat taken concurrently")
+		}
+	}
+
+	v.Redemptions++
+	redeemed := 0
+	if v.Redemptions >= v.MaxRedemptions {
+		redeemed = 1
+	}
+
+	if _, err := tx.ExecContext(ctx, driver.Rebind(`UPDATE vouchers SET redeemed=?, redeemed_at=? WHERE id=?`),
+		redeemed, clock.Format(now), v.ID); err != nil {
+		return err
+	}
+	if redeemed == 1 {
+		if err := setStatus(ctx, tx, driver, v, models.VoucherRedeemed, "", "every redemption used", now); err != nil {
+			return err
+		}
+	}
+
+	// a voucher seated while on the waitlist leaves it
+	_, err := tx.ExecContext(ctx, driver.Rebind(`UPDATE waitlist SET status=?, assigned_at=?, redemption=? WHERE voucher_id=? AND status=?`),
+		models.WaitlistAssigned, clock.Format(now), redemption, v.ID, models.WaitlistWaiting)
+	return err
+}
+
+// redeemableVoucher loads the voucher, locked on PostgreSQL, together with the
+// seat strategy of its campaign or else its flight, and checks it can still be
+// redeemed.
+func redeemableVoucher(ctx context.Context, tx *sql.Tx, driver db.Driver, clk clock.Clock, code string) (*models.Voucher, string, error) {
+	voucherQuery := `SELECT v.id, v.flight_id, v.cabin, v.redeemed, COALESCE(v.expires_at,''), COALESCE(v.seat_strategy,''), COALESCE(c.seat_strategy, f.seat_strategy, ''),
+		v.max_redemptions, v.group_size, (SELECT COUNT(DISTINCT sa.redemption) FROM seat_assignments sa WHERE sa.voucher_id = v.id), v.status, COALESCE(f.departure_at,''),
+		f.dep_date, COALESCE(f.origin,'')
+		FROM vouchers v JOIN flights f ON f.id = v.flight_id LEFT JOIN campaigns c ON c.id = v.campaign_id WHERE v.code=?`
+	if driver == db.Postgres {
+		voucherQuery += ` FOR UPDATE OF v`
+	}
+
+	var v models.Voucher
+	var flightStrategy, departureAt, depDate, origin string
+	err := tx.QueryRowContext(ctx, driver.Rebind(voucherQuery), code).
+		Scan(&v.ID, &v.FlightID, &v.Cabin, &v.Redeemed, &v.ExpiresAt, &v.SeatStrategy, &flightStrategy, &v.MaxRedemptions, &v.GroupSize, &v.Redemptions, &v.Status, &departureAt,
+			&depDate, &origin)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrVoucherNotFound
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	if err := RedeemStatusError(v.Status); err != nil {
+		return nil, "", err
+	}
+
+	if v.Redeemed == 1 || v.Redemptions >= v.MaxRedemptions {
+		return nil, "", ErrVoucherRedeemed
+	}
+
+	if v.ExpiresAt.Valid && v.ExpiresAt.String != "" {
+		if t, e := time.Parse(time.RFC3339, v.ExpiresAt.String); e == nil && clk.Now().After(t) {
+			return nil, "", ErrVoucherExpired
+		}
+	}
+
+	// with or without an expiry, no seat is given once the flight is gone
+	var departure *time.Time
+	if t, e := time.Parse(time.RFC3339, departureAt); e == nil {
+		departure = &t
+	}
+	if date, e := time.Parse(time.RFC3339, depDate); e == nil && !clk.Now().Before(Departure(departure, date, origin)) {
+		return nil, "", ErrFlightDeparted
+	}
+
+	v.Code = code
+	return &v, flightStrategy, nil
+}
+
+// claimSeat marks the first ranked seat that is still free as assigned, so a
+// seat taken or held by a concurrent redeemer after ranking is skipped.
+func claimSeat(ctx context.Context, tx *sql.Tx, driver db.Driver, ranked models.Seats, now time.Time) (*models.Seat, error) {
+	for i := range ranked {
+		if driver == db.Postgres {
+			// concurrent redeemers skip the row another transaction is claiming instead of waiting on it
+			var id int64
+			err := tx.QueryRowContext(ctx, driver.Rebind(`SELECT id FROM seats WHERE id=? AND is_assigned=0 FOR UPDATE SKIP LOCKED`), ranked[i].ID).Scan(&id)
 			if errors.Is(err, sql.ErrNoRows) {
-				lastError = errors.New("no available seats in cabin!")
 				continue
+			} else if err != nil {
+				return nil, err
 			}
 		}
 
-		if _, err := tx.ExecContext(ctx,
-			`INSERT INTO seat_assignments(voucher_id, seat_id) VALUES(?, ?)
-				 ON CONFLICT(seat_id) DO NOTHING`, v.ID, candidateSeatID); err != nil {
-			lastError = err
-			continue
+		res, err := tx.ExecContext(ctx, driver.Rebind(`UPDATE seats SET is_assigned=1 WHERE id=? AND is_assigned=0
+			AND NOT EXISTS(SELECT 1 FROM seat_holds h WHERE h.seat_id = seats.id AND h.expires_at > ?)`), ranked[i].ID, clock.Format(now))
+		if err != nil {
+			return nil, err
 		}
 
-		var count int
-		if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM seat_assignments WHERE voucher_id=?`, v.ID).Scan(&count); err != nil {
-			lastError = err
-			continue
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 1 {
+			return &ranked[i], nil
 		}
+	}
+
+	return nil, nil
+}
 
-		if count == 0 {
-			lastError = errors.New("seat taken concurrently")
+// Hold picks a seat like Assigns but only holds it for the voucher until
+// hvs.TTL has passed. Holding again releases the previous seat and offers it
+// last, so passengers can reshuffle.
+func (vr *vouchersRepository) Hold(ctx context.Context, hvs *models.HoldVoucherSeat) (*models.VoucherHold, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	v, flightStrategy, err := redeemableVoucher(ctx, tx, vr.driver, vr.clock, hvs.VoucherCode)
+	if err != nil {
+		return nil, err
+	}
+	if v.GroupSize > 1 {
+		return nil, ErrGroupHold
+	}
+
+	strategy, err := seating.Resolve(v.SeatStrategy.String, flightStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	var previousSeatID int64
+	err = tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT seat_id FROM seat_holds WHERE voucher_id=?`), v.ID).Scan(&previousSeatID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_holds WHERE voucher_id=?`), v.ID); err != nil {
+		return nil, err
+	}
+
+	now := vr.clock.Now()
+	pool, err := cabinPool(ctx, tx, vr.driver, v.FlightID, v.Cabin, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := seating.OfferLast(seating.Rank(strategy, pool, hvs.Preferences), previousSeatID)
+	expiresAt := now.Add(hvs.TTL)
+
+	seat, err := vr.claimHold(ctx, tx, ranked, v.ID, strategy.Name(), now, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if seat == nil {
+		return nil, ErrNoSeats
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	result := &models.VoucherHold{
+		VoucherCode: hvs.VoucherCode,
+		Cabin:       v.Cabin,
+		SeatID:      seat.ID,
+		SeatLabel:   seat.Label,
+		Strategy:    strategy.Name(),
+		ExpiresAt:   clock.Format(expiresAt),
+	}
+	result.SatisfiedPreferences, result.UnsatisfiedPreferences = seating.Evaluate(*seat, pool, hvs.Preferences)
+
+	return result, nil
+}
+
+// claimHold holds the first ranked seat that is neither assigned nor held by
+// another voucher. An expired hold on the seat is taken over in place.
+func (vr *vouchersRepository) claimHold(ctx context.Context, tx *sql.Tx, ranked models.Seats, voucherID int64, strategy string, now, expiresAt time.Time) (*models.Seat, error) {
+	freeQuery := `SELECT id FROM seats WHERE id=? AND is_assigned=0`
+	if vr.driver == db.Postgres {
+		freeQuery += ` FOR UPDATE SKIP LOCKED`
+	}
+
+	for i := range ranked {
+		var id int64
+		err := tx.QueryRowContext(ctx, vr.driver.Rebind(freeQuery), ranked[i].ID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
 			continue
+		} else if err != nil {
+			return nil, err
 		}
 
-		if _, err := tx.ExecContext(ctx, `UPDATE seats SET is_assigned=1 WHERE id=?`, candidateSeatID); err != nil {
-			lastError = err
-			continue
+		res, err := tx.ExecContext(ctx, vr.driver.Rebind(`INSERT INTO seat_holds(voucher_id, seat_id, strategy, held_at, expires_at) VALUES(?, ?, ?, ?, ?)
+			ON CONFLICT(seat_id) DO UPDATE SET voucher_id=excluded.voucher_id, strategy=excluded.strategy,
+				held_at=excluded.held_at, expires_at=excluded.expires_at
+			WHERE seat_holds.expires_at <= excluded.held_at`),
+			voucherID, ranked[i].ID, strategy, clock.Format(now), clock.Format(expiresAt))
+		if err != nil {
+			return nil, err
 		}
 
-		if _, err := tx.ExecContext(ctx, `UPDATE vouchers SET redeemed=1, redeemed_at=datetime('now') WHERE id=?`, v.ID); err != nil {
-			lastError = err
-			continue
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 1 {
+			return &ranked[i], nil
 		}
+	}
 
-		if err := tx.Commit(); err != nil {
+	return nil, nil
+}
+
+// ConfirmHold turns the voucher's seat hold into an assignment.
+func (vr *vouchersRepository) ConfirmHold(ctx context.Context, code string) (*models.VoucherAssigment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	v, _, err := redeemableVoucher(ctx, tx, vr.driver, vr.clock, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var seatID int64
+	var label, strategy, expiresAt string
+	err = tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT h.seat_id, s.label, h.strategy, h.expires_at
+		FROM seat_holds h JOIN seats s ON s.id = h.seat_id WHERE h.voucher_id=?`), v.ID).Scan(&seatID, &label, &strategy, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("no seat held for voucher!")
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := vr.clock.Now()
+	if t, e := time.Parse(time.RFC3339, expiresAt); e != nil || !now.Before(t) {
+		return nil, errors.New("seat hold expired!")
+	}
+
+	res, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE seats SET is_assigned=1 WHERE id=? AND is_assigned=0`), seatID)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n != 1 {
+		return nil, errors.New("seat taken concurrently")
+	}
+
+	seats := models.Seats{{ID: seatID, Label: label}}
+	if err := assignSeats(ctx, tx, vr.driver, v, seats, strategy, now); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_holds WHERE voucher_id=?`), v.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return models.NewVoucherAssigment(code, v, seats, strategy), nil
+}
+
+// ReleaseHold gives the voucher's held seat back to the other redeemers.
+func (vr *vouchersRepository) ReleaseHold(ctx context.Context, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var voucherID, flightID int64
+	err = tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id, flight_id FROM vouchers WHERE code=?`), code).Scan(&voucherID, &flightID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrVoucherNotFound
+	} else if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_holds WHERE voucher_id=?`), voucherID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errors.New("no seat held for voucher!")
+	}
+
+	if err := promoteWaitlist(ctx, tx, vr.driver, vr.clock, flightID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseExpiredHolds deletes the holds past their TTL at now and returns
+// them. Expired holds are already ignored everywhere, this reclaims the rows
+// and offers the seats to the waitlists of their flights.
+func (vr *vouchersRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) ([]models.VoucherHold, error) {
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now = now.UTC()
+	rows, err := tx.QueryContext(ctx, vr.driver.Rebind(`SELECT v.code, v.cabin, s.id, s.label, s.flight_id, h.strategy, h.expires_at
+		FROM seat_holds h JOIN seats s ON s.id = h.seat_id JOIN vouchers v ON v.id = h.voucher_id
+		WHERE h.expires_at <= ? ORDER BY h.expires_at, h.voucher_id`), clock.Format(now))
+	if err != nil {
+		return nil, err
+	}
+	holds := []models.VoucherHold{}
+	var flightIDs []int64
+	for rows.Next() {
+		var hold models.VoucherHold
+		var flightID int64
+		if err := rows.Scan(&hold.VoucherCode, &hold.Cabin, &hold.SeatID, &hold.SeatLabel, &flightID, &hold.Strategy, &hold.ExpiresAt); err != nil {
+			rows.Close()
 			return nil, err
 		}
+		holds = append(holds, hold)
+		if !slices.Contains(flightIDs, flightID) {
+			flightIDs = append(flightIDs, flightID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		var seatLabel string
-		_ = vr.db.QueryRow(`SELECT label FROM seats WHERE id=?`, candidateSeatID).Scan(&seatLabel)
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_holds WHERE expires_at <= ?`), clock.Format(now)); err != nil {
+		return nil, err
+	}
 
-		result := &models.VoucherAssigment{
-			VoucherCode: arv.VoucherCode,
-			Cabin:       v.Cabin,
-			SeatID:      candidateSeatID,
-			SeatLabel:   seatLabel,
+	for _, flightID := range flightIDs {
+		if err := promoteWaitlist(ctx, tx, vr.driver, vr.clock, flightID); err != nil {
+			return nil, err
 		}
+	}
+
+	return holds, tx.Commit()
+}
+
+// assignedVoucher loads the voucher, locked on PostgreSQL, with the seat to
+// change, and the seat strategy of its campaign or else its flight. A voucher
+// assigned to several seats needs fromSeat to tell which one.
+func (vr *vouchersRepository) assignedVoucher(ctx context.Context, tx *sql.Tx, code, fromSeat string) (*models.Voucher, models.Seat, string, error) {
+	var v models.Voucher
+	var seat models.Seat
+	var flightStrategy string
+
+	voucherQuery := `SELECT v.id, v.flight_id, v.cabin, COALESCE(v.seat_strategy,''), COALESCE(c.seat_strategy, f.seat_strategy, ''), v.max_redemptions, v.status
+		FROM vouchers v JOIN flights f ON f.id = v.flight_id LEFT JOIN campaigns c ON c.id = v.campaign_id WHERE v.code=?`
+	if vr.driver == db.Postgres {
+		voucherQuery += ` FOR UPDATE OF v`
+	}
+
+	err := tx.QueryRowContext(ctx, vr.driver.Rebind(voucherQuery), code).
+		Scan(&v.ID, &v.FlightID, &v.Cabin, &v.SeatStrategy, &flightStrategy, &v.MaxRedemptions, &v.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, seat, "", ErrVoucherNotFound
+	} else if err != nil {
+		return nil, seat, "", err
+	}
+	if v.Status == models.VoucherCheckedIn {
+		return nil, seat, "", ErrCheckedIn
+	}
+
+	rows, err := tx.QueryContext(ctx, vr.driver.Rebind(`SELECT s.id, s.label FROM seat_assignments sa JOIN seats s ON s.id = sa.seat_id WHERE sa.voucher_id=? ORDER BY sa.id`), v.ID)
+	if err != nil {
+		return nil, seat, "", err
+	}
+	defer rows.Close()
 
-		return result, nil
+	var assigned models.Seats
+	for rows.Next() {
+		var s models.Seat
+		if err := rows.Scan(&s.ID, &s.Label); err != nil {
+			return nil, seat, "", err
+		}
+		assigned = append(assigned, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, seat, "", err
 	}
 
-	if lastError != nil {
-		return nil, lastError
+	seat, err = PickAssignedSeat(assigned, fromSeat)
+	if err != nil {
+		return nil, seat, "", err
 	}
 
-	return &result, nil
+	v.Code = code
+	return &v, seat, flightStrategy, nil
 }
 
-func pickSeatRandomly(ctx context.Context, tx *sql.Tx, flightID int64, cabin string) (int64, error) {
-	var seatID int64
-	err := tx.QueryRowContext(ctx, `SELECT id FROM seats WHERE flight_id=? AND cabin=? AND is_assigned=0 ORDER BY random() LIMIT 1`, flightID, cabin).Scan(&seatID)
+// PickAssignedSeat is the voucher's seat labelled fromSeat, or its only seat
+// when fromSeat is empty.
+func PickAssignedSeat(assigned models.Seats, fromSeat string) (models.Seat, error) {
+	if len(assigned) == 0 {
+		return models.Seat{}, errors.New("voucher not assigned!")
+	}
+
+	fromSeat = strings.ToUpper(strings.TrimSpace(fromSeat))
+	if fromSeat == "" {
+		if len(assigned) > 1 {
+			return models.Seat{}, errors.New("voucher assigned to several seats, from_seat required!")
+		}
+		return assigned[0], nil
+	}
+
+	for _, s := range assigned {
+		if s.Label == fromSeat {
+			return s, nil
+		}
+	}
+
+	return models.Seat{}, errors.New("voucher not assigned to seat!")
+}
+
+func (vr *vouchersRepository) recordSeatChange(ctx context.Context, tx *sql.Tx, voucherID int64, change *models.SeatChange) error {
+	var toSeat sql.NullString
+	if change.ToSeat != "" {
+		toSeat = sql.NullString{String: change.ToSeat, Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx, vr.driver.Rebind(`INSERT INTO seat_assignment_changes(voucher_id, action, from_seat, to_seat, changed_by, reason, changed_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?)`), voucherID, change.Action, change.FromSeat, toSeat, change.ChangedBy, change.Reason, change.ChangedAt)
+	return err
+}
+
+// Unassign gives the voucher's seat back and makes the voucher redeemable again.
+func (vr *vouchersRepository) Unassign(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.SeatChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	v, seat, _, err := vr.assignedVoucher(ctx, tx, cvs.VoucherCode, cvs.FromSeat)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`DELETE FROM seat_assignments WHERE seat_id=?`), seat.ID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE seats SET is_assigned=0 WHERE id=?`), seat.ID); err != nil {
+		return nil, err
+	}
+
+	// the voucher can be redeemed again once a whole redemption is given back
+	if err := tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT COUNT(DISTINCT redemption) FROM seat_assignments WHERE voucher_id=?`), v.ID).Scan(&v.Redemptions); err != nil {
+		return nil, err
+	}
+	redeemed := 0
+	if v.Redemptions >= v.MaxRedemptions {
+		redeemed = 1
+	}
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE vouchers SET redeemed=?, redeemed_at=CASE WHEN ? = 0 THEN NULL ELSE redeemed_at END WHERE id=?`),
+		redeemed, v.Redemptions, v.ID); err != nil {
+		return nil, err
+	}
+	if redeemed == 0 && v.Status == models.VoucherRedeemed {
+		if err := setStatus(ctx, tx, vr.driver, v, models.VoucherActive, cvs.ChangedBy, cvs.Reason, vr.clock.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	change := &models.SeatChange{
+		VoucherCode: cvs.VoucherCode,
+		Action:      models.SeatChangeUnassign,
+		FromSeat:    seat.Label,
+		ChangedBy:   cvs.ChangedBy,
+		Reason:      cvs.Reason,
+		ChangedAt:   clock.Format(vr.clock.Now()),
+	}
+	if err := vr.recordSeatChange(ctx, tx, v.ID, change); err != nil {
+		return nil, err
+	}
+
+	if err := promoteWaitlist(ctx, tx, vr.driver, vr.clock, v.FlightID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// Move reassigns the voucher to a specific free seat in the same cabin.
+func (vr *vouchersRepository) Move(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	v, current, _, err := vr.assignedVoucher(ctx, tx, cvs.VoucherCode, cvs.FromSeat)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := scanSeat(tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT `+seatColumns+` FROM seats WHERE flight_id=? AND cabin=? AND label=?`),
+		v.FlightID, v.Cabin, strings.ToUpper(strings.TrimSpace(cvs.SeatLabel))))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("seat not found in cabin!")
+	} else if err != nil {
+		return nil, err
+	}
+	if target.ID == current.ID {
+		return nil, errors.New("voucher already assigned to seat!")
+	}
+	if target.Blocked {
+		return nil, errors.New("seat not available!")
+	}
+
+	now := vr.clock.Now()
+	seat, err := claimSeat(ctx, tx, vr.driver, models.Seats{target}, now)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	return seatID, nil
+	if seat == nil {
+		return nil, errors.New("seat not available!")
+	}
+
+	return vr.finishSeatChange(ctx, tx, v, current, *seat, "", models.SeatChangeMove, cvs, now)
+}
+
+// Reshuffle reassigns the voucher to another seat picked by its strategy.
+func (vr *vouchersRepository) Reshuffle(ctx context.Context, cvs *models.ChangeVoucherSeat) (*models.VoucherAssigment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := vr.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	v, current, flightStrategy, err := vr.assignedVoucher(ctx, tx, cvs.VoucherCode, cvs.FromSeat)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := seating.Resolve(v.SeatStrategy.String, flightStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// the current seat counts as assigned, so it is never picked again
+	now := vr.clock.Now()
+	pool, err := cabinPool(ctx, tx, vr.driver, v.FlightID, v.Cabin, now)
+	if err != nil {
+		return nil, err
+	}
+
+	seat, err := claimSeat(ctx, tx, vr.driver, seating.Rank(strategy, pool, cvs.Preferences), now)
+	if err != nil {
+		return nil, err
+	}
+	if seat == nil {
+		return nil, ErrNoSeats
+	}
+
+	result, err := vr.finishSeatChange(ctx, tx, v, current, *seat, strategy.Name(), models.SeatChangeReshuffle, cvs, now)
+	if err != nil {
+		return nil, err
+	}
+	result.SatisfiedPreferences, result.UnsatisfiedPreferences = seating.Evaluate(*seat, pool, cvs.Preferences)
+
+	return result, nil
+}
+
+// finishSeatChange moves the assignment from the current to the claimed seat,
+// records the change and commits. An empty strategy keeps the recorded one.
+func (vr *vouchersRepository) finishSeatChange(ctx context.Context, tx *sql.Tx, v *models.Voucher, current, seat models.Seat, strategy, action string, cvs *models.ChangeVoucherSeat, now time.Time) (*models.VoucherAssigment, error) {
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE seats SET is_assigned=0 WHERE id=?`), current.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, vr.driver.Rebind(`UPDATE seat_assignments SET seat_id=?, strategy=COALESCE(NULLIF(?, ''), strategy), assigned_at=? WHERE seat_id=?`),
+		seat.ID, strategy, clock.Format(now), current.ID); err != nil {
+		return nil, err
+	}
+
+	if err := vr.recordSeatChange(ctx, tx, v.ID, &models.SeatChange{
+		Action:    action,
+		FromSeat:  current.Label,
+		ToSeat:    seat.Label,
+		ChangedBy: cvs.ChangedBy,
+		Reason:    cvs.Reason,
+		ChangedAt: clock.Format(now),
+	}); err != nil {
+		return nil, err
+	}
+
+	var recorded string
+	if err := tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT strategy FROM seat_assignments WHERE seat_id=?`), seat.ID).Scan(&recorded); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.VoucherAssigment{
+		VoucherCode: cvs.VoucherCode,
+		Cabin:       v.Cabin,
+		SeatID:      seat.ID,
+		SeatLabel:   seat.Label,
+		Strategy:    recorded,
+	}, nil
+}
+
+func (vr *vouchersRepository) GetSeatChanges(ctx context.Context, code string) ([]models.SeatChange, error) {
+	var voucherID int64
+	err := vr.db.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id FROM vouchers WHERE code=?`), code).Scan(&voucherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVoucherNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := vr.db.QueryContext(ctx, vr.driver.Rebind(`SELECT id, action, from_seat, COALESCE(to_seat,''), changed_by, reason, changed_at
+		FROM seat_assignment_changes WHERE voucher_id=? ORDER BY id`), voucherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.SeatChange{}
+	for rows.Next() {
+		change := models.SeatChange{VoucherCode: code}
+		if err := rows.Scan(&change.ID, &change.Action, &change.FromSeat, &change.ToSeat, &change.ChangedBy, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
 }
 
 func (vr *vouchersRepository) GetAll(ctx context.Context) (*models.Vouchers, error) {
-	rows, err := vr.db.Query("SELECT id, flight_id, code, cabin, redeemed, expires_at, redeemed_at FROM vouchers")
+	rows, err := vr.db.Query(`SELECT id, flight_id, code, cabin, redeemed, expires_at, redeemed_at, seat_strategy, campaign_id, max_redemptions, group_size,
+		(SELECT COUNT(DISTINCT sa.redemption) FROM seat_assignments sa WHERE sa.voucher_id = vouchers.id), status, expires_before_departure FROM vouchers`)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +1045,8 @@ func (vr *vouchersRepository) GetAll(ctx context.Context) (*models.Vouchers, err
 	var vouchers models.Vouchers
 	for rows.Next() {
 		var voucher models.Voucher
-		if err := rows.Scan(&voucher.ID, &voucher.FlightID, &voucher.Code, &voucher.Cabin, &voucher.Redeemed, &voucher.ExpiresAt, &voucher.RedeemedAt); err != nil {
+		if err := rows.Scan(&voucher.ID, &voucher.FlightID, &voucher.Code, &voucher.Cabin, &voucher.Redeemed, &voucher.ExpiresAt, &voucher.RedeemedAt, &voucher.SeatStrategy, &voucher.CampaignID,
+			&voucher.MaxRedemptions, &voucher.GroupSize, &voucher.Redemptions, &voucher.Status, &voucher.ExpiresBeforeDeparture); err != nil {
 			return nil, err
 		}
 
@@ -162,3 +1055,35 @@ func (vr *vouchersRepository) GetAll(ctx context.Context) (*models.Vouchers, err
 
 	return &vouchers, nil
 }
+
+func (vr *vouchersRepository) GetByCode(ctx context.Context, code string) (*models.VoucherDetails, error) {
+	var details models.VoucherDetails
+	var depDate string
+	err := vr.db.QueryRowContext(ctx, vr.driver.Rebind(`SELECT v.id, v.flight_id, v.code, v.cabin, v.redeemed, v.expires_at, v.redeemed_at, v.seat_strategy, v.campaign_id,
+		v.max_redemptions, v.group_size, (SELECT COUNT(DISTINCT sa.redemption) FROM seat_assignments sa WHERE sa.voucher_id = v.id), v.status, f.flight_no, f.dep_date
+		FROM vouchers v JOIN flights f ON f.id = v.flight_id WHERE v.code=?`), code).
+		Scan(&details.ID, &details.FlightID, &details.Code, &details.Cabin, &details.Redeemed, &details.ExpiresAt, &details.RedeemedAt, &details.SeatStrategy, &details.CampaignID,
+			&details.MaxRedemptions, &details.GroupSize, &details.Redemptions, &details.Status, &details.FlightNo, &depDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVoucherNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	details.DepDate, _ = time.Parse(time.RFC3339, depDate)
+
+	rows, err := vr.db.QueryContext(ctx, vr.driver.Rebind(`SELECT s.id, s.label FROM seat_assignments sa JOIN seats s ON s.id = sa.seat_id WHERE sa.voucher_id=? ORDER BY sa.id`), details.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seat models.AssignedSeat
+		if err := rows.Scan(&seat.SeatID, &seat.SeatLabel); err != nil {
+			return nil, err
+		}
+		details.Seats = append(details.Seats, seat)
+	}
+
+	return &details, rows.Err()
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/seating"
+	"backend/pkg/db"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// joinWaitlist puts the voucher at the end of its cabin's waitlist. A voucher
+// already waiting keeps its place.
+func (vr *vouchersRepository) joinWaitlist(ctx context.Context, tx *sql.Tx, v *models.Voucher, now time.Time) (*models.WaitlistEntry, error) {
+	entry := &models.WaitlistEntry{
+		VoucherCode: v.Code,
+		FlightID:    v.FlightID,
+		Cabin:       v.Cabin,
+		Status:      models.WaitlistWaiting,
+	}
+
+	var id int64
+	err := tx.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id, joined_at FROM waitlist WHERE voucher_id=? AND status=?`), v.ID, models.WaitlistWaiting).
+		Scan(&id, &entry.JoinedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		entry.JoinedAt = clock.Format(now)
+		err = tx.QueryRowContext(ctx, vr.driver.Rebind(`INSERT INTO waitlist(voucher_id, flight_id, cabin, status, joined_at) VALUES(?, ?, ?, ?, ?) RETURNING id`),
+			v.ID, v.FlightID, v.Cabin, models.WaitlistWaiting, entry.JoinedAt).Scan(&id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Position, err = vr.waitlistPosition(ctx, tx, id, v.FlightID, v.Cabin)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// waitlistPosition counts the entries waiting in the cabin up to and
+// including the given one.
+func (vr *vouchersRepository) waitlistPosition(ctx context.Context, q querier, id, flightID int64, cabin string) (int, error) {
+	var position int
+	err := q.QueryRowContext(ctx, vr.driver.Rebind(`SELECT COUNT(*) FROM waitlist WHERE flight_id=? AND cabin=? AND status=? AND id <= ?`),
+		flightID, cabin, models.WaitlistWaiting, id).Scan(&position)
+	return position, err
+}
+
+// promoteWaitlist seats the flight's waiting vouchers, first come first
+// served, after seats were released or added. A group that does not fit yet
+// keeps its place while smaller ones behind it are seated. Vouchers that can
+// no longer be redeemed leave the waitlist. It runs inside the caller's
+// transaction, so only database errors are returned: a voucher in the way is
+// passed over rather than failing the seat change that freed the seats.
+func promoteWaitlist(ctx context.Context, tx *sql.Tx, driver db.Driver, clk clock.Clock, flightID int64) error {
+	rows, err := tx.QueryContext(ctx, driver.Rebind(`SELECT w.id, v.code FROM waitlist w JOIN vouchers v ON v.id = w.voucher_id
+		WHERE w.flight_id=? AND w.status=? ORDER BY w.id`), flightID, models.WaitlistWaiting)
+	if err != nil {
+		return err
+	}
+
+	type waiting struct {
+		id   int64
+		code string
+	}
+	var queue []waiting
+	for rows.Next() {
+		var w waiting
+		if err := rows.Scan(&w.id, &w.code); err != nil {
+			rows.Close()
+			return err
+		}
+		queue = append(queue, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := clk.Now()
+	for _, w := range queue {
+		v, flightStrategy, err := redeemableVoucher(ctx, tx, driver, clk, w.code)
+		switch {
+		case errors.Is(err, ErrVoucherExpired), errors.Is(err, ErrVoucherRevoked), errors.Is(err, ErrVoucherCancelled), errors.Is(err, ErrFlightDeparted):
+			if _, err := tx.ExecContext(ctx, driver.Rebind(`UPDATE waitlist SET status=? WHERE id=?`), models.WaitlistExpired, w.id); err != nil {
+				return err
+			}
+			continue
+		case errors.Is(err, ErrVoucherRedeemed):
+			if _, err := tx.ExecContext(ctx, driver.Rebind(`UPDATE waitlist SET status=?, assigned_at=? WHERE id=?`), models.WaitlistAssigned, clock.Format(now), w.id); err != nil {
+				return err
+			}
+			continue
+		case errors.Is(err, ErrVoucherInactive), errors.Is(err, ErrVoucherNotFound):
+			// may be activated again, keeps its place
+			continue
+		case err != nil:
+			return err
+		}
+
+		strategy, err := seating.Resolve(v.SeatStrategy.String, flightStrategy)
+		if err != nil {
+			continue
+		}
+
+		pool, err := cabinPool(ctx, tx, driver, v.FlightID, v.Cabin, now)
+		if err != nil {
+			return err
+		}
+		if len(pool.Free) == 0 {
+			continue
+		}
+
+		group, err := claimGroup(ctx, tx, driver, seating.RankGroups(strategy, pool, nil, v.GroupSize), now)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			continue
+		}
+
+		if err := assignSeats(ctx, tx, driver, v, group.Seats, strategy.Name(), now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (vr *vouchersRepository) GetWaitlist(ctx context.Context, code string) (*models.WaitlistEntry, error) {
+	var voucherID, id int64
+	var redemption sql.NullInt64
+	var assignedAt sql.NullString
+	entry := &models.WaitlistEntry{VoucherCode: code}
+
+	err := vr.db.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id FROM vouchers WHERE code=?`), code).Scan(&voucherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVoucherNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	// the latest entry, a multi-use voucher may have waited before
+	err = vr.db.QueryRowContext(ctx, vr.driver.Rebind(`SELECT id, flight_id, cabin, status, joined_at, assigned_at, redemption
+		FROM waitlist WHERE voucher_id=? ORDER BY id DESC LIMIT 1`), voucherID).
+		Scan(&id, &entry.FlightID, &entry.Cabin, &entry.Status, &entry.JoinedAt, &assignedAt, &redemption)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("voucher not on the waitlist!")
+	} else if err != nil {
+		return nil, err
+	}
+	entry.AssignedAt = assignedAt.String
+
+	switch {
+	case entry.Status == models.WaitlistWaiting:
+		entry.Position, err = vr.waitlistPosition(ctx, vr.db, id, entry.FlightID, entry.Cabin)
+		if err != nil {
+			return nil, err
+		}
+	case redemption.Valid:
+		rows, err := vr.db.QueryContext(ctx, vr.driver.Rebind(`SELECT s.id, s.label FROM seat_assignments sa JOIN seats s ON s.id = sa.seat_id
+			WHERE sa.voucher_id=? AND sa.redemption=? ORDER BY sa.id`), voucherID, redemption.Int64)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var seat models.AssignedSeat
+			if err := rows.Scan(&seat.SeatID, &seat.SeatLabel); err != nil {
+				return nil, err
+			}
+			entry.Seats = append(entry.Seats, seat)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
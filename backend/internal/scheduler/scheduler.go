@@ -0,0 +1,106 @@
+// Package scheduler runs the periodic voucher jobs: expired vouchers are
+// marked so, seat holds past their TTL are released and stale redemption
+// throttle state is forgotten. Every change is reported as an Event.
+package scheduler
+
+import (
+	"backend/internal/clock"
+	"backend/internal/controller"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const (
+	EventVoucherExpired = "voucher.expired"
+	EventHoldReleased   = "hold.released"
+)
+
+// Event is one change made by a run.
+type Event struct {
+	Type        string    `json:"type"`
+	VoucherCode string    `json:"voucher_code"`
+	SeatLabel   string    `json:"seat_label,omitempty"` // the released seat of a hold
+	At          time.Time `json:"at"`
+}
+
+type Scheduler struct {
+	vc       controller.VouchersController
+	interval time.Duration
+	clock    clock.Clock
+	emit     func(Event)
+}
+
+// New returns a scheduler running every interval. clk defaults to the wall
+// clock and emit to logging every event.
+func New(vc controller.VouchersController, interval time.Duration, clk clock.Clock, emit func(Event)) *Scheduler {
+	if emit == nil {
+		emit = logEvent
+	}
+
+	return &Scheduler{
+		vc:       vc,
+		interval: interval,
+		clock:    clock.OrSystem(clk),
+		emit:     emit,
+	}
+}
+
+func logEvent(e Event) {
+	if e.SeatLabel != "" {
+		log.Infof("%s: voucher %s, seat %s", e.Type, e.VoucherCode, e.SeatLabel)
+		return
+	}
+	log.Infof("%s: voucher %s", e.Type, e.VoucherCode)
+}
+
+// Run runs the jobs every interval until ctx is done. A failed job is logged
+// and tried again on the next tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunOnce(ctx); err != nil {
+				log.Errorf("Scheduled run failed: %v", err)
+			}
+
+			if _, err := s.vc.PruneThrottle(ctx); err != nil {
+				log.Errorf("Failed to prune redemption throttle: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce expires vouchers and then releases expired seat holds, so holds of
+// the vouchers just expired go too. It emits and returns the events of both,
+// the holds are still released when expiring vouchers fails.
+func (s *Scheduler) RunOnce(ctx context.Context) ([]Event, error) {
+	now := s.clock.Now()
+	var events []Event
+
+	changes, expireErr := s.vc.ExpireVouchers(ctx, now)
+	for _, c := range changes {
+		events = append(events, s.report(Event{Type: EventVoucherExpired, VoucherCode: c.VoucherCode, At: now}))
+	}
+
+	holds, err := s.vc.ReleaseExpiredHolds(ctx, now)
+	for _, h := range holds {
+		events = append(events, s.report(Event{Type: EventHoldReleased, VoucherCode: h.VoucherCode, SeatLabel: h.SeatLabel, At: now}))
+	}
+
+	if expireErr != nil {
+		return events, expireErr
+	}
+	return events, err
+}
+
+func (s *Scheduler) report(e Event) Event {
+	s.emit(e)
+	return e
+}
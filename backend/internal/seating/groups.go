@@ -0,0 +1,158 @@
+package seating
+
+import (
+	"backend/internal/models"
+	"sort"
+)
+
+// Arrangements of a group's seats, from most to least together.
+const (
+	ArrangementSameRow      = "same_row"      // side by side, no aisle in between
+	ArrangementAcrossAisle  = "across_aisle"  // side by side in one row, split by an aisle
+	ArrangementAdjacentRows = "adjacent_rows" // split over a row and the one behind it
+)
+
+var arrangementOrder = map[string]int{
+	ArrangementSameRow:      0,
+	ArrangementAcrossAisle:  1,
+	ArrangementAdjacentRows: 2,
+}
+
+// SeatGroup is a set of free seats offered together to a group.
+type SeatGroup struct {
+	Seats       models.Seats
+	Arrangement string
+}
+
+// run is a stretch of free seats next to each other in one row.
+type run struct {
+	seats  []placed
+	aisles int // aisles crossed between the first and last seat
+	best   int // best rank of any of its seats
+}
+
+// RankGroups offers size free seats next to each other for a group
+// redemption. Seats side by side in a row come first, then a row split by an
+// aisle, then the group split over two neighbouring rows, front over back.
+// Within an arrangement groups are ordered by the best ranked seat they
+// contain, so the strategy and preferences still decide where the group sits.
+// A size of one offers every ranked seat on its own.
+func RankGroups(strategy SeatAssignmentStrategy, pool SeatPool, prefs *models.SeatPreferences, size int) []SeatGroup {
+	ranked := Rank(strategy, pool, prefs)
+	if size <= 1 {
+		groups := make([]SeatGroup, 0, len(ranked))
+		for _, s := range ranked {
+			groups = append(groups, SeatGroup{Seats: models.Seats{s}, Arrangement: ArrangementSameRow})
+		}
+		return groups
+	}
+
+	rank := make(map[int64]int, len(ranked))
+	for i, s := range ranked {
+		rank[s.ID] = i
+	}
+
+	// a row's seats left to right, occupied ones break a group
+	free, assigned := place(pool)
+	rows := map[int][]placed{}
+	for _, p := range append(free, assigned...) {
+		if p.row > 0 {
+			rows[p.row] = append(rows[p.row], p)
+		}
+	}
+	rowNos := make([]int, 0, len(rows))
+	for rowNo, row := range rows {
+		sort.Slice(row, func(i, j int) bool { return byRow(row[i], row[j]) })
+		rowNos = append(rowNos, rowNo)
+	}
+	sort.Ints(rowNos)
+
+	type candidate struct {
+		run
+		arrangement string
+	}
+	var candidates []candidate
+	for _, rowNo := range rowNos {
+		for _, r := range runs(rows[rowNo], size, rank) {
+			arrangement := ArrangementSameRow
+			if r.aisles > 0 {
+				arrangement = ArrangementAcrossAisle
+			}
+			candidates = append(candidates, candidate{run: r, arrangement: arrangement})
+		}
+	}
+
+	// rows are neighbours when no other row of the cabin lies between them
+	for i := 0; i+1 < len(rowNos); i++ {
+		front, back := rows[rowNos[i]], rows[rowNos[i+1]]
+		for k := 1; k < size; k++ {
+			for _, f := range runs(front, k, rank) {
+				for _, b := range runs(back, size-k, rank) {
+					if !overlaps(f, b) {
+						continue
+					}
+					candidates = append(candidates, candidate{
+						run: run{
+							seats:  append(append([]placed{}, f.seats...), b.seats...),
+							aisles: f.aisles + b.aisles,
+							best:   min(f.best, b.best),
+						},
+						arrangement: ArrangementAdjacentRows,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.arrangement != b.arrangement {
+			return arrangementOrder[a.arrangement] < arrangementOrder[b.arrangement]
+		}
+		if a.aisles != b.aisles {
+			return a.aisles < b.aisles
+		}
+		if a.best != b.best {
+			return a.best < b.best
+		}
+		return a.seats[0].ID < b.seats[0].ID
+	})
+
+	groups := make([]SeatGroup, 0, len(candidates))
+	for _, c := range candidates {
+		groups = append(groups, SeatGroup{Seats: seatsOf(c.seats), Arrangement: c.arrangement})
+	}
+	return groups
+}
+
+// runs lists every stretch of n free seats in a row sorted left to right.
+// Two aisle seats next to each other have the aisle between them.
+func runs(row []placed, n int, rank map[int64]int) []run {
+	var out []run
+	for start := 0; start+n <= len(row); start++ {
+		r := run{best: len(rank)}
+		for i, p := range row[start : start+n] {
+			pr, ok := rank[p.ID]
+			if !ok {
+				r.seats = nil
+				break
+			}
+			if i > 0 && p.position == PositionAisle && row[start+i-1].position == PositionAisle {
+				r.aisles++
+			}
+			r.seats = append(r.seats, p)
+			r.best = min(r.best, pr)
+		}
+		if r.seats != nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// overlaps tells whether two runs of neighbouring rows share a column range,
+// so the front part of the group sits right in front of the back part.
+func overlaps(front, back run) bool {
+	return front.seats[0].column <= back.seats[len(back.seats)-1].column &&
+		back.seats[0].column <= front.seats[len(front.seats)-1].column
+}
@@ -0,0 +1,121 @@
+package seating
+
+import (
+	"backend/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	PositionWindow = "WINDOW"
+	PositionMiddle = "MIDDLE"
+	PositionAisle  = "AISLE"
+)
+
+// ParseLabel splits a seat label such as "12A" into its row number and column letter.
+func ParseLabel(label string) (row int, column string, ok bool) {
+	label = strings.ToUpper(strings.TrimSpace(label))
+
+	i := 0
+	for i < len(label) && unicode.IsDigit(rune(label[i])) {
+		i++
+	}
+	if i == 0 || i == len(label) {
+		return 0, "", false
+	}
+
+	row, err := strconv.Atoi(label[:i])
+	if err != nil || row <= 0 {
+		return 0, "", false
+	}
+
+	column = label[i:]
+	for _, r := range column {
+		if r < 'A' || r > 'Z' {
+			return 0, "", false
+		}
+	}
+
+	return row, column, true
+}
+
+const (
+	win = PositionWindow
+	mid = PositionMiddle
+	ais = PositionAisle
+)
+
+// rowLayouts maps the number of seats in a row to the position of each seat,
+// left to right, for the common narrow and wide body configurations.
+var rowLayouts = map[int][]string{
+	1:  {win},
+	2:  {win, win},
+	3:  {win, ais, win},                                    // 1-2
+	4:  {win, ais, ais, win},                               // 2-2
+	5:  {win, ais, ais, mid, win},                          // 2-3
+	6:  {win, mid, ais, ais, mid, win},                     // 3-3
+	7:  {win, ais, ais, mid, ais, ais, win},                // 2-3-2
+	8:  {win, ais, ais, mid, mid, ais, ais, win},           // 2-4-2
+	9:  {win, mid, ais, ais, mid, ais, ais, mid, win},      // 3-3-3
+	10: {win, mid, ais, ais, mid, mid, ais, ais, mid, win}, // 3-4-3
+}
+
+// DerivePositions guesses the window/middle/aisle position of every label from
+// the other labels in the same row. Labels that cannot be parsed are left out.
+func DerivePositions(labels []string) map[string]string {
+	rows := map[int][]string{}
+	for _, l := range labels {
+		row, column, ok := ParseLabel(l)
+		if !ok {
+			continue
+		}
+		rows[row] = append(rows[row], column)
+	}
+
+	positions := map[string]string{}
+	for row, columns := range rows {
+		sort.Strings(columns)
+		layout, ok := rowLayouts[len(columns)]
+		for i, column := range columns {
+			position := PositionMiddle
+			switch {
+			case ok:
+				position = layout[i]
+			case i == 0 || i == len(columns)-1:
+				position = PositionWindow
+			}
+			positions[strconv.Itoa(row)+column] = position
+		}
+	}
+
+	return positions
+}
+
+// DeriveAttributes fills in the row, column and position of the seats that do
+// not set them, using the flight's existing seats to complete each row.
+func DeriveAttributes(seats models.Seats, existing models.Seats) models.Seats {
+	var labels []string
+	for _, s := range existing {
+		labels = append(labels, s.Label)
+	}
+	for _, s := range seats {
+		labels = append(labels, s.Label)
+	}
+	positions := DerivePositions(labels)
+
+	derived := make(models.Seats, len(seats))
+	for i, s := range seats {
+		s.Label = strings.ToUpper(strings.TrimSpace(s.Label))
+		if s.Row == 0 {
+			s.Row, s.Column, _ = ParseLabel(s.Label)
+		}
+		if s.Position == "" {
+			s.Position = positions[s.Label]
+		}
+		derived[i] = s
+	}
+
+	return derived
+}
@@ -0,0 +1,168 @@
+package seating
+
+import (
+	"backend/internal/models"
+	"sort"
+	"strings"
+)
+
+const (
+	PreferencePosition = "position"
+	PreferenceZone     = "zone"
+	PreferenceExitRow  = "exit_row"
+	PreferenceNearSeat = "near_seat"
+
+	ZoneFront = "FRONT"
+	ZoneRear  = "REAR"
+
+	// nearSeatDistance is the furthest a seat can be from the requested one
+	// and still count as near: the seat next to it or the one behind it.
+	nearSeatDistance = 2
+)
+
+// Rank orders the free seats by the strategy, then moves the seats matching
+// most of the passenger's preferences to the front. Preferences are soft: a
+// seat matching none of them is still offered when nothing better is free.
+func Rank(strategy SeatAssignmentStrategy, pool SeatPool, prefs *models.SeatPreferences) models.Seats {
+	ranked := strategy.Rank(pool)
+	if prefs == nil || prefs.IsEmpty() {
+		return ranked
+	}
+
+	free, _ := place(SeatPool{Free: ranked, Assigned: pool.Assigned})
+	b := rowBounds(pool)
+
+	scores := make(map[int64]int, len(free))
+	for _, p := range free {
+		scores[p.ID] = score(p, b, prefs)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].ID] > scores[ranked[j].ID]
+	})
+
+	return ranked
+}
+
+// Evaluate reports which of the passenger's preferences the seat satisfies.
+func Evaluate(seat models.Seat, pool SeatPool, prefs *models.SeatPreferences) (satisfied, unsatisfied []string) {
+	if prefs == nil || prefs.IsEmpty() {
+		return nil, nil
+	}
+
+	// place the seat among the rest of the cabin so its position is derived from its row
+	var others models.Seats
+	for _, s := range append(append(models.Seats{}, pool.Free...), pool.Assigned...) {
+		if s.ID != seat.ID {
+			others = append(others, s)
+		}
+	}
+	free, _ := place(SeatPool{Free: models.Seats{seat}, Assigned: others})
+	p := free[0]
+	b := rowBounds(pool)
+
+	for _, name := range requested(prefs) {
+		if matches(name, p, b, prefs) {
+			satisfied = append(satisfied, name)
+		} else {
+			unsatisfied = append(unsatisfied, name)
+		}
+	}
+
+	return satisfied, unsatisfied
+}
+
+type bounds struct {
+	minRow, maxRow int
+}
+
+func rowBounds(pool SeatPool) bounds {
+	var b bounds
+	first := true
+	for _, seats := range []models.Seats{pool.Free, pool.Assigned} {
+		for _, s := range seats {
+			row := s.Row
+			if row == 0 {
+				row, _, _ = ParseLabel(s.Label)
+			}
+			if row == 0 {
+				continue
+			}
+			if first || row < b.minRow {
+				b.minRow = row
+			}
+			if first || row > b.maxRow {
+				b.maxRow = row
+			}
+			first = false
+		}
+	}
+	return b
+}
+
+func requested(prefs *models.SeatPreferences) []string {
+	var names []string
+	if prefs.Position != "" {
+		names = append(names, PreferencePosition)
+	}
+	if prefs.Zone != "" {
+		names = append(names, PreferenceZone)
+	}
+	if prefs.ExitRow {
+		names = append(names, PreferenceExitRow)
+	}
+	if prefs.NearSeat != "" {
+		names = append(names, PreferenceNearSeat)
+	}
+	return names
+}
+
+func matches(name string, p placed, b bounds, prefs *models.SeatPreferences) bool {
+	switch name {
+	case PreferencePosition:
+		return p.position == strings.ToUpper(prefs.Position)
+	case PreferenceZone:
+		front := float64(p.row) <= float64(b.minRow+b.maxRow)/2
+		if strings.ToUpper(prefs.Zone) == ZoneFront {
+			return front
+		}
+		return !front || b.minRow == b.maxRow
+	case PreferenceExitRow:
+		return p.exitRow
+	case PreferenceNearSeat:
+		return nearDistance(p, prefs.NearSeat) <= nearSeatDistance
+	}
+	return false
+}
+
+func nearDistance(p placed, label string) int {
+	row, column, ok := ParseLabel(label)
+	if !ok {
+		return -1
+	}
+	return Distance(p.row, p.column, row, column)
+}
+
+// score weighs every satisfied preference equally; among seats satisfying the
+// same preferences the one closer to the requested neighbour wins, and seats
+// in an exit row go last unless the passenger opted in.
+func score(p placed, b bounds, prefs *models.SeatPreferences) int {
+	total := 0
+	for _, name := range requested(prefs) {
+		if matches(name, p, b, prefs) {
+			total += 1000
+		}
+	}
+
+	if prefs.NearSeat != "" {
+		if d := nearDistance(p, prefs.NearSeat); d > 0 {
+			total -= d
+		}
+	}
+
+	if p.exitRow && !prefs.ExitRow {
+		total -= 500
+	}
+
+	return total
+}
@@ -0,0 +1,139 @@
+package seating
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxExpandedLabels caps a single request, "1-9999:A-Z" is a typo, not an aircraft.
+const maxExpandedLabels = 5000
+
+// ExpandLabels turns label specs into seat labels. A spec is either a plain
+// label such as "12A" or a range:
+//
+//	"10-25:ABCDEF"  rows 10 to 25, columns A to F
+//	"10-25:A-K"     the same with a column range
+//	"30:DEFG"       a single row
+//	"1A-4D"         rows 1 to 4, columns A to D
+//
+// Rows and columns to skip only apply to ranges, plain labels are kept as is.
+func ExpandLabels(specs []string, skipRows []int, skipColumns []string) ([]string, error) {
+	skipped := map[string]bool{}
+	for _, c := range skipColumns {
+		skipped[strings.ToUpper(strings.TrimSpace(c))] = true
+	}
+	skippedRows := map[int]bool{}
+	for _, r := range skipRows {
+		skippedRows[r] = true
+	}
+	tooMany := fmt.Errorf("seat ranges expand to more than %d seats", maxExpandedLabels)
+
+	var labels []string
+	for _, spec := range specs {
+		spec = strings.ToUpper(strings.TrimSpace(spec))
+		if !strings.ContainsAny(spec, "-:") {
+			labels = append(labels, spec)
+			continue
+		}
+
+		fromRow, toRow, columns, err := parseRange(spec)
+		if err != nil {
+			return nil, err
+		}
+		// checked up front too, skipping every column never produces a label
+		if toRow-fromRow+1 > maxExpandedLabels {
+			return nil, tooMany
+		}
+
+		for row := fromRow; row <= toRow; row++ {
+			if skippedRows[row] {
+				continue
+			}
+			for _, column := range columns {
+				if skipped[column] {
+					continue
+				}
+				labels = append(labels, strconv.Itoa(row)+column)
+			}
+			if len(labels) > maxExpandedLabels {
+				return nil, tooMany
+			}
+		}
+	}
+
+	return labels, nil
+}
+
+func parseRange(spec string) (fromRow, toRow int, columns []string, err error) {
+	invalid := fmt.Errorf("invalid seat range %q", spec)
+
+	if rows, cols, ok := strings.Cut(spec, ":"); ok {
+		fromRow, toRow, ok = parseRowRange(rows)
+		if !ok {
+			return 0, 0, nil, invalid
+		}
+		if from, to, ok := strings.Cut(cols, "-"); ok {
+			columns, ok = columnRange(from, to)
+			if !ok {
+				return 0, 0, nil, invalid
+			}
+		} else {
+			for _, r := range cols {
+				if r < 'A' || r > 'Z' || slices.Contains(columns, string(r)) {
+					return 0, 0, nil, invalid
+				}
+				columns = append(columns, string(r))
+			}
+		}
+		if len(columns) == 0 {
+			return 0, 0, nil, invalid
+		}
+		return fromRow, toRow, columns, nil
+	}
+
+	// corner to corner, e.g. 1A-4D
+	from, to, _ := strings.Cut(spec, "-")
+	fromRow, fromColumn, okFrom := ParseLabel(from)
+	toRow, toColumn, okTo := ParseLabel(to)
+	if !okFrom || !okTo || fromRow > toRow {
+		return 0, 0, nil, invalid
+	}
+	columns, ok := columnRange(fromColumn, toColumn)
+	if !ok {
+		return 0, 0, nil, invalid
+	}
+
+	return fromRow, toRow, columns, nil
+}
+
+func parseRowRange(rows string) (from, to int, ok bool) {
+	first, last, isRange := strings.Cut(rows, "-")
+	if !isRange {
+		last = first
+	}
+
+	from, err := strconv.Atoi(first)
+	if err != nil || from <= 0 {
+		return 0, 0, false
+	}
+	to, err = strconv.Atoi(last)
+	if err != nil || to < from {
+		return 0, 0, false
+	}
+
+	return from, to, true
+}
+
+func columnRange(from, to string) ([]string, bool) {
+	if len(from) != 1 || len(to) != 1 || from[0] < 'A' || to[0] > 'Z' || from[0] > to[0] {
+		return nil, false
+	}
+
+	var columns []string
+	for c := from[0]; c <= to[0]; c++ {
+		columns = append(columns, string(c))
+	}
+	return columns, true
+}
@@ -0,0 +1,300 @@
+// Package seating holds the seat selection policies shared by every
+// repository implementation.
+package seating
+
+import (
+	"backend/internal/models"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+)
+
+const (
+	StrategyRandom       = "random"
+	StrategyFrontToBack  = "front_to_back"
+	StrategyBackToFront  = "back_to_front"
+	StrategyWindowFirst  = "window_first"
+	StrategyAisleFirst   = "aisle_first"
+	StrategyFillBalanced = "fill_balanced"
+	StrategyCluster      = "cluster"
+
+	DefaultStrategy = StrategyRandom
+)
+
+// SeatPool is a cabin's seats at the time of assignment.
+type SeatPool struct {
+	Free     models.Seats
+	Assigned models.Seats
+}
+
+// SeatAssignmentStrategy orders the free seats of a cabin from most to least
+// preferred. Repositories claim the first seat that is still free, so a
+// strategy never has to deal with concurrent redeemers itself.
+type SeatAssignmentStrategy interface {
+	Name() string
+	Rank(pool SeatPool) models.Seats
+}
+
+var strategies = map[string]SeatAssignmentStrategy{
+	StrategyRandom:       randomStrategy{},
+	StrategyFrontToBack:  frontToBackStrategy{},
+	StrategyBackToFront:  backToFrontStrategy{},
+	StrategyWindowFirst:  positionFirstStrategy{name: StrategyWindowFirst, position: PositionWindow},
+	StrategyAisleFirst:   positionFirstStrategy{name: StrategyAisleFirst, position: PositionAisle},
+	StrategyFillBalanced: fillBalancedStrategy{},
+	StrategyCluster:      clusterStrategy{},
+}
+
+// Names lists the built-in strategies, e.g. for validation messages.
+func Names() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Lookup(name string) (SeatAssignmentStrategy, error) {
+	s, ok := strategies[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown seat strategy %q", name)
+	}
+	return s, nil
+}
+
+// Resolve returns the first configured strategy, most specific first, falling
+// back to DefaultStrategy when none is set.
+func Resolve(names ...string) (SeatAssignmentStrategy, error) {
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" {
+			return Lookup(name)
+		}
+	}
+	return strategies[DefaultStrategy], nil
+}
+
+// placed is a seat with its row, column and position resolved, from its
+// attributes when stored or else from its label.
+type placed struct {
+	models.Seat
+	row      int
+	column   string
+	position string
+	exitRow  bool
+}
+
+func place(pool SeatPool) (free, assigned []placed) {
+	var labels []string
+	for _, s := range pool.Free {
+		labels = append(labels, s.Label)
+	}
+	for _, s := range pool.Assigned {
+		labels = append(labels, s.Label)
+	}
+	positions := DerivePositions(labels)
+
+	convert := func(seats models.Seats) []placed {
+		out := make([]placed, 0, len(seats))
+		for _, s := range seats {
+			p := placed{Seat: s, row: s.Row, column: s.Column, position: s.Position, exitRow: s.ExitRow}
+			if p.row == 0 {
+				p.row, p.column, _ = ParseLabel(s.Label)
+			}
+			if p.position == "" {
+				p.position = positions[strings.ToUpper(s.Label)]
+			}
+			out = append(out, p)
+		}
+		return out
+	}
+
+	return convert(pool.Free), convert(pool.Assigned)
+}
+
+func seatsOf(ps []placed) models.Seats {
+	seats := make(models.Seats, 0, len(ps))
+	for _, p := range ps {
+		seats = append(seats, p.Seat)
+	}
+	return seats
+}
+
+// byRow orders seats front to back, then left to right.
+func byRow(a, b placed) bool {
+	if a.row != b.row {
+		return a.row < b.row
+	}
+	return a.column < b.column
+}
+
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string { return StrategyRandom }
+
+func (randomStrategy) Rank(pool SeatPool) models.Seats {
+	seats := make(models.Seats, len(pool.Free))
+	copy(seats, pool.Free)
+	rand.Shuffle(len(seats), func(i, j int) { seats[i], seats[j] = seats[j], seats[i] })
+	return seats
+}
+
+type frontToBackStrategy struct{}
+
+func (frontToBackStrategy) Name() string { return StrategyFrontToBack }
+
+func (frontToBackStrategy) Rank(pool SeatPool) models.Seats {
+	free, _ := place(pool)
+	sort.SliceStable(free, func(i, j int) bool { return byRow(free[i], free[j]) })
+	return seatsOf(free)
+}
+
+type backToFrontStrategy struct{}
+
+func (backToFrontStrategy) Name() string { return StrategyBackToFront }
+
+func (backToFrontStrategy) Rank(pool SeatPool) models.Seats {
+	free, _ := place(pool)
+	sort.SliceStable(free, func(i, j int) bool {
+		if free[i].row != free[j].row {
+			return free[i].row > free[j].row
+		}
+		return free[i].column < free[j].column
+	})
+	return seatsOf(free)
+}
+
+// positionFirstStrategy prefers one seat position, front to back, before the rest.
+type positionFirstStrategy struct {
+	name     string
+	position string
+}
+
+func (s positionFirstStrategy) Name() string { return s.name }
+
+func (s positionFirstStrategy) Rank(pool SeatPool) models.Seats {
+	free, _ := place(pool)
+	sort.SliceStable(free, func(i, j int) bool {
+		pi, pj := free[i].position == s.position, free[j].position == s.position
+		if pi != pj {
+			return pi
+		}
+		return byRow(free[i], free[j])
+	})
+	return seatsOf(free)
+}
+
+// fillBalancedStrategy spreads passengers over the rows for weight and
+// balance: the emptiest rows go first, closest to the middle of the cabin.
+type fillBalancedStrategy struct{}
+
+func (fillBalancedStrategy) Name() string { return StrategyFillBalanced }
+
+func (fillBalancedStrategy) Rank(pool SeatPool) models.Seats {
+	free, assigned := place(pool)
+
+	load := map[int]int{}
+	minRow, maxRow := 0, 0
+	for i, p := range append(append([]placed{}, free...), assigned...) {
+		if i == 0 || p.row < minRow {
+			minRow = p.row
+		}
+		if p.row > maxRow {
+			maxRow = p.row
+		}
+	}
+	for _, p := range assigned {
+		load[p.row]++
+	}
+
+	center := float64(minRow+maxRow) / 2
+	distance := func(row int) float64 {
+		d := float64(row) - center
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+
+	sort.SliceStable(free, func(i, j int) bool {
+		li, lj := load[free[i].row], load[free[j].row]
+		if li != lj {
+			return li < lj
+		}
+		di, dj := distance(free[i].row), distance(free[j].row)
+		if di != dj {
+			return di < dj
+		}
+		return byRow(free[i], free[j])
+	})
+	return seatsOf(free)
+}
+
+// clusterStrategy keeps assignments contiguous by picking the free seat
+// closest to an already assigned one, filling front to back when empty.
+type clusterStrategy struct{}
+
+func (clusterStrategy) Name() string { return StrategyCluster }
+
+func (clusterStrategy) Rank(pool SeatPool) models.Seats {
+	free, assigned := place(pool)
+	if len(assigned) == 0 {
+		sort.SliceStable(free, func(i, j int) bool { return byRow(free[i], free[j]) })
+		return seatsOf(free)
+	}
+
+	nearest := func(p placed) int {
+		best := -1
+		for _, a := range assigned {
+			d := Distance(p.row, p.column, a.row, a.column)
+			if best < 0 || d < best {
+				best = d
+			}
+		}
+		return best
+	}
+
+	sort.SliceStable(free, func(i, j int) bool {
+		di, dj := nearest(free[i]), nearest(free[j])
+		if di != dj {
+			return di < dj
+		}
+		return byRow(free[i], free[j])
+	})
+	return seatsOf(free)
+}
+
+// Distance is how far apart two seats are. A row apart counts as two seats
+// across, so neighbours in the same row come before the seat behind.
+func Distance(rowA int, columnA string, rowB int, columnB string) int {
+	dr := rowA - rowB
+	if dr < 0 {
+		dr = -dr
+	}
+	dc := columnIndex(columnA) - columnIndex(columnB)
+	if dc < 0 {
+		dc = -dc
+	}
+	return 2*dr + dc
+}
+
+func columnIndex(column string) int {
+	idx := 0
+	for _, r := range column {
+		idx = idx*26 + int(r-'A'+1)
+	}
+	return idx
+}
+
+// OfferLast moves the seat to the end of the ranking, e.g. to reshuffle away
+// from a seat the passenger was already offered.
+func OfferLast(ranked models.Seats, seatID int64) models.Seats {
+	for i, s := range ranked {
+		if s.ID == seatID {
+			reordered := append(append(models.Seats{}, ranked[:i]...), ranked[i+1:]...)
+			return append(reordered, s)
+		}
+	}
+	return ranked
+}
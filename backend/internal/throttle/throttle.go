@@ -0,0 +1,162 @@
+// Package throttle slows down voucher code guessing: attempts are rate
+// limited per client IP and per code prefix, and an IP trying unknown codes
+// is locked out for longer every time it keeps going.
+package throttle
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/vouchercode"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Window          time.Duration // rate limit window
+	IPLimit         int           // attempts per IP and window, 0 disables
+	PrefixLimit     int           // attempts per code prefix and window, 0 disables
+	PrefixLength    int           // characters of the code the prefix limit groups by
+	LockoutFailures int           // unknown codes per IP and window before a lockout, 0 disables
+	LockoutDuration time.Duration // the first lockout, doubled on every next one
+	LockoutMax      time.Duration // the longest lockout, lockouts are forgiven after as long
+}
+
+// LimitedError rejects an attempt, the client may retry after RetryAfter.
+type LimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LimitedError) Error() string {
+	return fmt.Sprintf("too many voucher attempts, retry in %s!", e.RetryAfter.Round(time.Second))
+}
+
+type Limiter struct {
+	cfg Config
+	tr  repository.ThrottleRepository
+	now func() time.Time
+}
+
+// New returns a limiter keeping its state in tr, clk defaults to the wall
+// clock.
+func New(cfg Config, tr repository.ThrottleRepository, clk clock.Clock) *Limiter {
+	return &Limiter{
+		cfg: cfg,
+		tr:  tr,
+		now: clock.OrSystem(clk).Now,
+	}
+}
+
+// Allow counts an attempt to redeem code from ip, it fails with a
+// *LimitedError when the IP is locked out or either limit is exceeded.
+func (l *Limiter) Allow(ctx context.Context, ip, code string) error {
+	if err := l.hit(ctx, "ip:"+ip, l.cfg.IPLimit); err != nil {
+		return err
+	}
+
+	// signed codes can't be enumerated and would all share their prefix
+	if prefix := l.prefix(code); prefix != "" && !vouchercode.IsSigned(code) {
+		return l.hit(ctx, "prefix:"+prefix, l.cfg.PrefixLimit)
+	}
+
+	return nil
+}
+
+// Fail records an unknown code tried from ip, locking it out once there are
+// too many in the window.
+func (l *Limiter) Fail(ctx context.Context, ip string) error {
+	if l.cfg.LockoutFailures <= 0 {
+		return nil
+	}
+
+	now := l.now()
+	_, err := l.tr.Update(ctx, "ip:"+ip, func(s *models.ThrottleState) {
+		l.roll(s, now)
+		s.Failures++
+		if s.Failures < l.cfg.LockoutFailures {
+			return
+		}
+
+		s.Lockouts++
+		s.Failures = 0
+		s.LockedUntil = now.Add(l.lockout(s.Lockouts))
+	})
+
+	return err
+}
+
+// Succeed clears the unknown codes counted against ip.
+func (l *Limiter) Succeed(ctx context.Context, ip string) error {
+	_, err := l.tr.Update(ctx, "ip:"+ip, func(s *models.ThrottleState) {
+		s.Failures = 0
+	})
+
+	return err
+}
+
+// Prune forgets keys whose window and lockouts are long over.
+func (l *Limiter) Prune(ctx context.Context) (int64, error) {
+	return l.tr.Prune(ctx, l.now().Add(-max(l.cfg.Window, l.cfg.LockoutMax)))
+}
+
+func (l *Limiter) hit(ctx context.Context, key string, limit int) error {
+	now := l.now()
+
+	var retryAfter time.Duration
+	_, err := l.tr.Update(ctx, key, func(s *models.ThrottleState) {
+		l.roll(s, now)
+		if now.Before(s.LockedUntil) {
+			retryAfter = s.LockedUntil.Sub(now)
+			return
+		}
+
+		s.Hits++
+		if limit > 0 && s.Hits > limit {
+			retryAfter = s.WindowStart.Add(l.cfg.Window).Sub(now)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if retryAfter > 0 {
+		return &LimitedError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// roll starts a new window once the current one is over, and forgives past
+// lockouts after LockoutMax without one.
+func (l *Limiter) roll(s *models.ThrottleState, now time.Time) {
+	if now.Sub(s.WindowStart) >= l.cfg.Window {
+		s.WindowStart = now
+		s.Hits = 0
+		s.Failures = 0
+	}
+
+	if s.Lockouts > 0 && now.Sub(s.LockedUntil) >= l.cfg.LockoutMax {
+		s.Lockouts = 0
+	}
+}
+
+// lockout doubles the duration with every lockout in a row, up to LockoutMax.
+func (l *Limiter) lockout(lockouts int) time.Duration {
+	d := l.cfg.LockoutDuration
+	for i := 1; i < lockouts && d < l.cfg.LockoutMax; i++ {
+		d *= 2
+	}
+
+	return min(d, l.cfg.LockoutMax)
+}
+
+func (l *Limiter) prefix(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if l.cfg.PrefixLimit <= 0 || l.cfg.PrefixLength <= 0 || len(code) <= l.cfg.PrefixLength {
+		return ""
+	}
+
+	return code[:l.cfg.PrefixLength]
+}
@@ -0,0 +1,26 @@
+package vouchercode
+
+import (
+	"backend/internal/models"
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// WriteCSV exports a generated batch, one voucher per line with a header.
+func WriteCSV(w io.Writer, batch *models.VoucherBatch) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"code", "flight_id", "cabin", "expires_at"}); err != nil {
+		return err
+	}
+
+	flightID := strconv.FormatInt(batch.FlightID, 10)
+	for _, code := range batch.Codes {
+		if err := cw.Write([]string{code, flightID, batch.Cabin, batch.ExpiresAt}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,180 @@
+package vouchercode
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SignedPrefix starts every signed code, e.g. SV1-K2025-<claims>-<signature>.
+// The version lets the format change without breaking codes in circulation.
+const SignedPrefix = "SV1-"
+
+const (
+	signatureLen = 10 // bytes of the HMAC kept, 80 bits
+	minKeyLen    = 16
+	maxKeyIDLen  = 8
+)
+
+var (
+	ErrForged      = errors.New("voucher code signature invalid!")
+	ErrNotSigned   = errors.New("voucher code must be signed!")
+	ErrSignedClaim = errors.New("voucher does not match its signed code!")
+)
+
+// signedEncoding writes the claims and signature with the unambiguous alphabet.
+var signedEncoding = base32.NewEncoding(DefaultAlphabet).WithPadding(base32.NoPadding)
+
+var signedCabins = []string{"FIRST", "BUSINESS", "ECONOMY"}
+
+// Claims are the voucher fields carried inside a signed code.
+type Claims struct {
+	FlightID  int64
+	Cabin     string
+	ExpiresAt time.Time // zero when the voucher does not expire
+	Serial    uint64
+}
+
+// Signer mints and verifies signed codes. New codes are signed with the
+// active key, codes signed with any configured key still verify, so a key
+// can be rotated by adding the new one, making it active and dropping the old
+// one once its vouchers are gone.
+type Signer struct {
+	// RequireSigned rejects plain codes, so none can be guessed.
+	RequireSigned bool
+
+	keys     map[string][]byte
+	activeID string
+}
+
+// NewSigner takes the keys by id, ids are 1 to 8 characters of A-Z and 0-9.
+func NewSigner(keys map[string]string, activeID string) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no voucher signing keys")
+	}
+
+	s := &Signer{keys: map[string][]byte{}, activeID: strings.ToUpper(activeID)}
+	for id, key := range keys {
+		id = strings.ToUpper(id)
+		if id == "" || len(id) > maxKeyIDLen || strings.IndexFunc(id, func(r rune) bool { return !isCodeChar(r) }) >= 0 {
+			return nil, fmt.Errorf("invalid voucher signing key id %q", id)
+		}
+		if len(key) < minKeyLen {
+			return nil, fmt.Errorf("voucher signing key %s must be at least %d bytes", id, minKeyLen)
+		}
+		s.keys[id] = []byte(key)
+	}
+
+	if _, ok := s.keys[s.activeID]; !ok {
+		return nil, fmt.Errorf("active voucher signing key %q is not configured", activeID)
+	}
+
+	return s, nil
+}
+
+// IsSigned reports whether the code is in the signed format, valid or not.
+func IsSigned(code string) bool {
+	return strings.HasPrefix(code, SignedPrefix)
+}
+
+// Sign mints a code for the claims with the active key. A zero serial is
+// replaced by a random one.
+func (s *Signer) Sign(c Claims) (string, error) {
+	cabin := slices.Index(signedCabins, c.Cabin)
+	if cabin < 0 || c.FlightID <= 0 {
+		return "", fmt.Errorf("cannot sign voucher for flight %d cabin %q", c.FlightID, c.Cabin)
+	}
+
+	if c.Serial == 0 {
+		var b [8]byte
+		if _, err := rand.Read(b[3:]); err != nil {
+			return "", err
+		}
+		c.Serial = binary.BigEndian.Uint64(b[:]) | 1<<40
+	}
+
+	var expiresAt int64
+	if !c.ExpiresAt.IsZero() {
+		expiresAt = c.ExpiresAt.Unix()
+	}
+
+	payload := binary.AppendUvarint(nil, uint64(c.FlightID))
+	payload = append(payload, byte(cabin))
+	payload = binary.AppendVarint(payload, expiresAt)
+	payload = binary.AppendUvarint(payload, c.Serial)
+
+	return SignedPrefix + s.activeID + "-" + signedEncoding.EncodeToString(payload) + "-" +
+		signedEncoding.EncodeToString(s.sign(s.activeID, payload)), nil
+}
+
+// Verify checks the code was signed by a configured key and returns its
+// claims. Forged, altered or unknown key codes all fail with ErrForged.
+func (s *Signer) Verify(code string) (Claims, error) {
+	parts := strings.Split(strings.TrimPrefix(code, SignedPrefix), "-")
+	if !IsSigned(code) || len(parts) != 3 {
+		return Claims{}, ErrForged
+	}
+
+	keyID := parts[0]
+	payload, ok := decodeCanonical(parts[1])
+	if !ok {
+		return Claims{}, ErrForged
+	}
+	signature, ok := decodeCanonical(parts[2])
+	if !ok {
+		return Claims{}, ErrForged
+	}
+	if _, ok := s.keys[keyID]; !ok || !hmac.Equal(signature, s.sign(keyID, payload)) {
+		return Claims{}, ErrForged
+	}
+
+	var c Claims
+	flightID, n := binary.Uvarint(payload)
+	if n <= 0 || n >= len(payload) || int(payload[n]) >= len(signedCabins) {
+		return Claims{}, ErrForged
+	}
+	c.FlightID = int64(flightID)
+	c.Cabin = signedCabins[payload[n]]
+	payload = payload[n+1:]
+
+	expiresAt, n := binary.Varint(payload)
+	if n <= 0 {
+		return Claims{}, ErrForged
+	}
+	if expiresAt != 0 {
+		c.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+	}
+	payload = payload[n:]
+
+	if c.Serial, n = binary.Uvarint(payload); n <= 0 || n != len(payload) {
+		return Claims{}, ErrForged
+	}
+
+	return c, nil
+}
+
+// decodeCanonical rejects strings with stray bits in the last character, which
+// would otherwise decode to the same bytes and let a code be altered.
+func decodeCanonical(s string) ([]byte, bool) {
+	b, err := signedEncoding.DecodeString(s)
+	if err != nil || signedEncoding.EncodeToString(b) != s {
+		return nil, false
+	}
+	return b, true
+}
+
+// sign binds the signature to the format version and key id as well as the
+// claims, so neither can be swapped.
+func (s *Signer) sign(keyID string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.keys[keyID])
+	mac.Write([]byte(SignedPrefix + keyID + "-"))
+	mac.Write(payload)
+	return mac.Sum(nil)[:signatureLen]
+}
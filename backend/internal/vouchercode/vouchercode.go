@@ -0,0 +1,132 @@
+// Package vouchercode mints random voucher codes from a pattern, optionally
+// ending in a Luhn mod N check character that catches typos before a lookup.
+package vouchercode
+
+import (
+	"backend/internal/models"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// DefaultAlphabet leaves out the characters easily mistaken for one
+	// another: 0 and O, 1 and I.
+	DefaultAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	DefaultLength   = 8
+
+	minLength    = 4
+	maxLength    = 32
+	maxPrefixLen = 16
+)
+
+// Normalize fills in the pattern defaults and checks it can mint codes.
+func Normalize(p models.VoucherCodePattern) (models.VoucherCodePattern, error) {
+	p.Prefix = strings.ToUpper(strings.TrimSpace(p.Prefix))
+	p.Alphabet = strings.ToUpper(strings.TrimSpace(p.Alphabet))
+	if p.Alphabet == "" {
+		p.Alphabet = DefaultAlphabet
+	}
+	if p.Length == 0 {
+		p.Length = DefaultLength
+	}
+
+	if p.Length < minLength || p.Length > maxLength {
+		return p, fmt.Errorf("code length must be between %d and %d", minLength, maxLength)
+	}
+
+	if len(p.Prefix) > maxPrefixLen {
+		return p, fmt.Errorf("code prefix must be at most %d characters", maxPrefixLen)
+	}
+	for _, r := range p.Prefix {
+		if !isCodeChar(r) && r != '-' {
+			return p, errors.New("code prefix may only contain A-Z, 0-9 and -")
+		}
+	}
+
+	seen := map[rune]bool{}
+	for _, r := range p.Alphabet {
+		if !isCodeChar(r) || seen[r] {
+			return p, errors.New("code alphabet must be distinct A-Z and 0-9 characters")
+		}
+		seen[r] = true
+	}
+	if len(seen) < 2 {
+		return p, errors.New("code alphabet needs at least 2 characters")
+	}
+
+	return p, nil
+}
+
+func isCodeChar(r rune) bool {
+	return (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// Generate mints one code: the prefix, Length random characters from the
+// alphabet and the check character when enabled. The pattern must be normalized.
+func Generate(p models.VoucherCodePattern) (string, error) {
+	max := big.NewInt(int64(len(p.Alphabet)))
+
+	body := make([]byte, p.Length)
+	for i := range body {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		body[i] = p.Alphabet[n.Int64()]
+	}
+
+	code := string(body)
+	if p.CheckDigit {
+		code += string(checkChar(code, p.Alphabet))
+	}
+
+	return p.Prefix + code, nil
+}
+
+// Verify reports whether the code fits the pattern, including its check character.
+func Verify(code string, p models.VoucherCodePattern) bool {
+	body, ok := strings.CutPrefix(strings.ToUpper(code), p.Prefix)
+	if !ok {
+		return false
+	}
+
+	want := p.Length
+	if p.CheckDigit {
+		want++
+	}
+	if len(body) != want {
+		return false
+	}
+	for i := range body {
+		if strings.IndexByte(p.Alphabet, body[i]) < 0 {
+			return false
+		}
+	}
+
+	if p.CheckDigit {
+		return checkChar(body[:p.Length], p.Alphabet) == body[p.Length]
+	}
+	return true
+}
+
+// checkChar computes the Luhn mod N check character of s over the alphabet.
+func checkChar(s, alphabet string) byte {
+	n := len(alphabet)
+	factor := 2
+	sum := 0
+
+	for i := len(s) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(alphabet, s[i])
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+		sum += addend/n + addend%n
+	}
+
+	return alphabet[(n-sum%n)%n]
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Driver names the SQL dialect a connection speaks.
+type Driver string
+
+const (
+	SQLite   Driver = "sqlite"
+	Postgres Driver = "postgres"
+)
+
+func ParseDriver(name string) (Driver, error) {
+	switch Driver(strings.ToLower(strings.TrimSpace(name))) {
+	case SQLite, "sqlite3":
+		return SQLite, nil
+	case Postgres, "postgresql":
+		return Postgres, nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q", name)
+	}
+}
+
+// Rebind rewrites `?` placeholders into the driver's bind syntax, so queries
+// are written once and run on every driver.
+func (d Driver) Rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case r == '?' && !inQuote:
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// Migrations returns the schema history written for the driver.
+func (d Driver) Migrations() []Migration {
+	if d == Postgres {
+		return PostgresMigrations
+	}
+
+	return Migrations
+}
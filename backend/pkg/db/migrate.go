@@ -0,0 +1,257 @@
+package db
+
+import (
+	"backend/internal/clock"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const schemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations(
+  version     INTEGER PRIMARY KEY,
+  name        TEXT NOT NULL,
+  checksum    TEXT NOT NULL,
+  applied_at  TEXT NOT NULL
+);`
+
+// Migration is a single, ordered schema change with its rollback.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum identifies the content of the up script, so edits to an already
+// applied migration are detected instead of silently diverging.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+type MigrationStatus struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"applied_at,omitempty"`
+	Modified  bool   `json:"modified"` // applied checksum differs from the current definition
+	Unknown   bool   `json:"unknown"`  // applied in the database but missing from the code
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt string
+}
+
+type Migrator struct {
+	db         *sql.DB
+	driver     Driver
+	migrations []Migration
+	clock      clock.Clock
+}
+
+func NewMigrator(db *sql.DB, driver Driver, migrations []Migration, clk clock.Clock) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &Migrator{
+		db:         db,
+		driver:     driver,
+		migrations: sorted,
+		clock:      clock.OrSystem(clk),
+	}
+}
+
+func (m *Migrator) init(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var am appliedMigration
+		if err := rows.Scan(&version, &am.name, &am.checksum, &am.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = am
+	}
+
+	return applied, rows.Err()
+}
+
+// verify refuses to continue when an applied migration was edited afterwards.
+func (m *Migrator) verify(applied map[int]appliedMigration) error {
+	for _, mg := range m.migrations {
+		am, ok := applied[mg.Version]
+		if ok && am.checksum != mg.Checksum() {
+			return fmt.Errorf("migration %d (%s) was modified after being applied", mg.Version, mg.Name)
+		}
+	}
+
+	return nil
+}
+
+// Up applies every pending migration in version order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+
+		if err := m.run(ctx, mg, true); err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the latest `steps` applied migrations and returns the ones rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mg := m.migrations[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+
+		if err := m.run(ctx, mg, false); err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+// Redo rolls back the latest applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	rolled, err := m.Down(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(rolled) == 0 {
+		return nil, errors.New("no applied migrations to redo")
+	}
+
+	if err := m.run(ctx, rolled[0], true); err != nil {
+		return nil, err
+	}
+
+	return &rolled[0], nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	known := map[int]bool{}
+	for _, mg := range m.migrations {
+		known[mg.Version] = true
+		status := MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if am, ok := applied[mg.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = am.appliedAt
+			status.Modified = am.checksum != mg.Checksum()
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, am := range applied {
+		if !known[version] {
+			statuses = append(statuses, MigrationStatus{
+				Version:   version,
+				Name:      am.name,
+				Applied:   true,
+				AppliedAt: am.appliedAt,
+				Unknown:   true,
+			})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+func (m *Migrator) run(ctx context.Context, mg Migration, up bool) error {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script := mg.Down
+	if up {
+		script = mg.Up
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", mg.Version, mg.Name, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, m.driver.Rebind(`INSERT INTO schema_migrations(version, name, checksum, applied_at) VALUES(?, ?, ?, ?)`),
+			mg.Version, mg.Name, mg.Checksum(), clock.Format(m.clock.Now()))
+	} else {
+		_, err = tx.ExecContext(ctx, m.driver.Rebind(`DELETE FROM schema_migrations WHERE version=?`), mg.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,298 @@
+package db
+
+// PostgresMigrations mirrors Migrations version by version for PostgreSQL.
+var PostgresMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_initial_schema",
+		Up: `
+CREATE TABLE IF NOT EXISTS flights(
+  id         BIGSERIAL PRIMARY KEY,
+  flight_no  TEXT NOT NULL,
+  dep_date   TEXT NOT NULL,
+  UNIQUE(flight_no, dep_date)
+);
+
+CREATE TABLE IF NOT EXISTS seats(
+  id           BIGSERIAL PRIMARY KEY,
+  flight_id    BIGINT NOT NULL REFERENCES flights(id) ON DELETE CASCADE,
+  label        TEXT NOT NULL, -- e.g., 12A
+  cabin        TEXT NOT NULL CHECK (cabin IN ('ECONOMY','BUSINESS','FIRST')),
+  is_assigned  INTEGER NOT NULL DEFAULT 0,
+  UNIQUE(flight_id, label)
+);
+
+CREATE TABLE IF NOT EXISTS vouchers(
+  id           BIGSERIAL PRIMARY KEY,
+  code         TEXT NOT NULL UNIQUE,
+  flight_id    BIGINT NOT NULL REFERENCES flights(id) ON DELETE CASCADE,
+  cabin        TEXT NOT NULL CHECK (cabin IN ('ECONOMY','BUSINESS','FIRST')),
+  redeemed     INTEGER NOT NULL DEFAULT 0,
+  expires_at   TEXT,
+  redeemed_at  TEXT
+);
+
+CREATE TABLE IF NOT EXISTS seat_assignments(
+  voucher_id   BIGINT NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+  seat_id      BIGINT NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
+  assigned_at  TEXT NOT NULL DEFAULT (to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"')),
+  PRIMARY KEY (voucher_id),
+  UNIQUE (seat_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_seats_flight_cabin ON seats(flight_id, cabin);`,
+		Down: `
+DROP INDEX IF EXISTS idx_seats_flight_cabin;
+DROP TABLE IF EXISTS seat_assignments;
+DROP TABLE IF EXISTS vouchers;
+DROP TABLE IF EXISTS seats;
+DROP TABLE IF EXISTS flights;`,
+	},
+	{
+		Version: 2,
+		Name:    "add_seat_strategies",
+		Up: `
+ALTER TABLE flights ADD COLUMN seat_strategy TEXT;
+ALTER TABLE vouchers ADD COLUMN seat_strategy TEXT;
+ALTER TABLE seat_assignments ADD COLUMN strategy TEXT NOT NULL DEFAULT 'random';`,
+		Down: `
+ALTER TABLE seat_assignments DROP COLUMN strategy;
+ALTER TABLE vouchers DROP COLUMN seat_strategy;
+ALTER TABLE flights DROP COLUMN seat_strategy;`,
+	},
+	{
+		Version: 3,
+		Name:    "add_seat_attributes",
+		Up: `
+ALTER TABLE seats ADD COLUMN row_no INTEGER;
+ALTER TABLE seats ADD COLUMN column_letter TEXT;
+ALTER TABLE seats ADD COLUMN position TEXT CHECK (position IN ('WINDOW','MIDDLE','AISLE'));
+ALTER TABLE seats ADD COLUMN exit_row INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE seats ADD COLUMN bulkhead INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE seats ADD COLUMN extra_legroom INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE seats ADD COLUMN reclining INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE seats ADD COLUMN power_outlet INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE seats ADD COLUMN blocked INTEGER NOT NULL DEFAULT 0;`,
+		Down: `
+ALTER TABLE seats DROP COLUMN blocked;
+ALTER TABLE seats DROP COLUMN power_outlet;
+ALTER TABLE seats DROP COLUMN reclining;
+ALTER TABLE seats DROP COLUMN extra_legroom;
+ALTER TABLE seats DROP COLUMN bulkhead;
+ALTER TABLE seats DROP COLUMN exit_row;
+ALTER TABLE seats DROP COLUMN position;
+ALTER TABLE seats DROP COLUMN column_letter;
+ALTER TABLE seats DROP COLUMN row_no;`,
+	},
+	{
+		Version: 4,
+		Name:    "add_seat_holds",
+		Up: `
+CREATE TABLE IF NOT EXISTS seat_holds(
+  voucher_id   BIGINT PRIMARY KEY REFERENCES vouchers(id) ON DELETE CASCADE,
+  seat_id      BIGINT NOT NULL UNIQUE REFERENCES seats(id) ON DELETE CASCADE,
+  strategy     TEXT NOT NULL,
+  held_at      TEXT NOT NULL,
+  expires_at   TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_seat_holds_expires_at ON seat_holds(expires_at);`,
+		Down: `
+DROP INDEX IF EXISTS idx_seat_holds_expires_at;
+DROP TABLE IF EXISTS seat_holds;`,
+	},
+	{
+		Version: 5,
+		Name:    "add_seat_assignment_changes",
+		Up: `
+CREATE TABLE IF NOT EXISTS seat_assignment_changes(
+  id           BIGSERIAL PRIMARY KEY,
+  voucher_id   BIGINT NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+  action       TEXT NOT NULL CHECK (action IN ('UNASSIGN','MOVE','RESHUFFLE')),
+  from_seat    TEXT NOT NULL, -- seat labels, the history outlives seat changes
+  to_seat      TEXT,
+  changed_by   TEXT NOT NULL,
+  reason       TEXT NOT NULL,
+  changed_at   TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_seat_assignment_changes_voucher ON seat_assignment_changes(voucher_id);`,
+		Down: `
+DROP INDEX IF EXISTS idx_seat_assignment_changes_voucher;
+DROP TABLE IF EXISTS seat_assignment_changes;`,
+	},
+	{
+		Version: 6,
+		Name:    "add_redeem_throttle",
+		Up: `
+CREATE TABLE IF NOT EXISTS redeem_throttle(
+  throttle_key  TEXT PRIMARY KEY, -- ip:<address> or prefix:<code prefix>
+  window_start  TEXT NOT NULL DEFAULT '',
+  hits          INTEGER NOT NULL DEFAULT 0,
+  failures      INTEGER NOT NULL DEFAULT 0,
+  lockouts      INTEGER NOT NULL DEFAULT 0,
+  locked_until  TEXT NOT NULL DEFAULT ''
+);`,
+		Down: `
+DROP TABLE IF EXISTS redeem_throttle;`,
+	},
+	{
+		Version: 7,
+		Name:    "add_campaigns",
+		Up: `
+CREATE TABLE IF NOT EXISTS campaigns(
+  id                  BIGSERIAL PRIMARY KEY,
+  name                TEXT NOT NULL UNIQUE,
+  starts_at           TEXT NOT NULL,
+  ends_at             TEXT NOT NULL,
+  cabin               TEXT NOT NULL CHECK (cabin IN ('ECONOMY','BUSINESS','FIRST')),
+  quota               INTEGER NOT NULL CHECK (quota > 0), -- vouchers the campaign may issue
+  seat_strategy       TEXT,
+  voucher_expires_at  TEXT,                               -- default expiry of its vouchers
+  created_at          TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS campaign_flights(
+  campaign_id  BIGINT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+  flight_id    BIGINT NOT NULL REFERENCES flights(id) ON DELETE CASCADE,
+  PRIMARY KEY (campaign_id, flight_id)
+);
+
+ALTER TABLE vouchers ADD COLUMN campaign_id BIGINT REFERENCES campaigns(id);
+CREATE INDEX IF NOT EXISTS idx_vouchers_campaign ON vouchers(campaign_id);`,
+		Down: `
+DROP INDEX IF EXISTS idx_vouchers_campaign;
+ALTER TABLE vouchers DROP COLUMN campaign_id;
+DROP TABLE IF EXISTS campaign_flights;
+DROP TABLE IF EXISTS campaigns;`,
+	},
+	{
+		Version: 8,
+		Name:    "add_multi_use_vouchers",
+		Up: `
+ALTER TABLE vouchers ADD COLUMN max_redemptions INTEGER NOT NULL DEFAULT 1 CHECK (max_redemptions > 0);
+ALTER TABLE vouchers ADD COLUMN group_size INTEGER NOT NULL DEFAULT 1 CHECK (group_size > 0); -- seats per redemption
+
+ALTER TABLE seat_assignments DROP CONSTRAINT seat_assignments_pkey;
+ALTER TABLE seat_assignments ADD COLUMN id BIGSERIAL PRIMARY KEY;
+ALTER TABLE seat_assignments ADD COLUMN redemption INTEGER NOT NULL DEFAULT 1; -- the seats of a group share their redemption
+
+CREATE INDEX IF NOT EXISTS idx_seat_assignments_voucher ON seat_assignments(voucher_id);`,
+		Down: `
+DROP INDEX IF EXISTS idx_seat_assignments_voucher;
+
+-- only the first seat of every voucher survives, the others are freed
+UPDATE seats SET is_assigned=0 WHERE id IN (
+  SELECT seat_id FROM seat_assignments WHERE id NOT IN (SELECT MIN(id) FROM seat_assignments GROUP BY voucher_id));
+DELETE FROM seat_assignments WHERE id NOT IN (SELECT MIN(id) FROM seat_assignments GROUP BY voucher_id);
+
+ALTER TABLE seat_assignments DROP COLUMN redemption;
+ALTER TABLE seat_assignments DROP COLUMN id;
+ALTER TABLE seat_assignments ADD PRIMARY KEY (voucher_id);
+
+ALTER TABLE vouchers DROP COLUMN group_size;
+ALTER TABLE vouchers DROP COLUMN max_redemptions;`,
+	},
+	{
+		Version: 9,
+		Name:    "add_waitlist",
+		Up: `
+CREATE TABLE IF NOT EXISTS waitlist(
+  id           BIGSERIAL PRIMARY KEY,
+  voucher_id   BIGINT NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+  flight_id    BIGINT NOT NULL REFERENCES flights(id) ON DELETE CASCADE,
+  cabin        TEXT NOT NULL CHECK (cabin IN ('ECONOMY','BUSINESS','FIRST')),
+  status       TEXT NOT NULL DEFAULT 'WAITING' CHECK (status IN ('WAITING','ASSIGNED','EXPIRED')),
+  joined_at    TEXT NOT NULL,
+  assigned_at  TEXT,
+  redemption   INTEGER                                    -- the voucher's redemption that seated it
+);
+CREATE INDEX IF NOT EXISTS idx_waitlist_flight_cabin ON waitlist(flight_id, cabin, status);
+CREATE INDEX IF NOT EXISTS idx_waitlist_voucher ON waitlist(voucher_id);`,
+		Down: `
+DROP INDEX IF EXISTS idx_waitlist_voucher;
+DROP INDEX IF EXISTS idx_waitlist_flight_cabin;
+DROP TABLE IF EXISTS waitlist;`,
+	},
+	{
+		Version: 10,
+		Name:    "add_voucher_status",
+		Up: `
+ALTER TABLE vouchers ADD COLUMN status TEXT NOT NULL DEFAULT 'ACTIVE'
+  CHECK (status IN ('ISSUED','ACTIVE','REDEEMED','CHECKED_IN','REVOKED','EXPIRED','CANCELLED'));
+UPDATE vouchers SET status = 'REDEEMED' WHERE redeemed = 1;
+
+CREATE TABLE IF NOT EXISTS voucher_status_changes(
+  id           BIGSERIAL PRIMARY KEY,
+  voucher_id   BIGINT NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+  from_status  TEXT NOT NULL,
+  to_status    TEXT NOT NULL,
+  changed_by   TEXT,            -- empty for changes made by redemptions
+  reason       TEXT,
+  changed_at   TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_voucher_status_changes_voucher ON voucher_status_changes(voucher_id);`,
+		Down: `
+DROP INDEX IF EXISTS idx_voucher_status_changes_voucher;
+DROP TABLE IF EXISTS voucher_status_changes;
+ALTER TABLE vouchers DROP COLUMN status;`,
+	},
+	{
+		Version: 11,
+		Name:    "canonical_timestamps",
+		Up: `
+-- assigned_at is written from Go like every other timestamp, RFC3339 in UTC
+-- to the second
+ALTER TABLE seat_assignments ALTER COLUMN assigned_at DROP DEFAULT;
+UPDATE seat_assignments SET assigned_at = to_char(assigned_at::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  WHERE assigned_at LIKE '%.%';`,
+		Down: `
+ALTER TABLE seat_assignments ALTER COLUMN assigned_at SET DEFAULT (to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'));`,
+	},
+	{
+		Version: 12,
+		Name:    "add_flight_routes",
+		Up: `
+ALTER TABLE flights ADD COLUMN origin TEXT;        -- IATA code, the timezone comes from the airport table
+ALTER TABLE flights ADD COLUMN destination TEXT;
+ALTER TABLE flights ADD COLUMN departure_at TEXT;  -- scheduled, RFC3339 in UTC
+ALTER TABLE flights ADD COLUMN arrival_at TEXT;`,
+		Down: `
+ALTER TABLE flights DROP COLUMN arrival_at;
+ALTER TABLE flights DROP COLUMN departure_at;
+ALTER TABLE flights DROP COLUMN destination;
+ALTER TABLE flights DROP COLUMN origin;`,
+	},
+	{
+		Version: 13,
+		Name:    "add_relative_voucher_expiry",
+		Up: `
+-- seconds before the flight's departure, expires_at follows the departure
+ALTER TABLE vouchers ADD COLUMN expires_before_departure INTEGER;`,
+		Down: `
+ALTER TABLE vouchers DROP COLUMN expires_before_departure;`,
+	},
+	{
+		Version: 14,
+		Name:    "canonical_voucher_expiries",
+		Up: `
+-- expiries were stored with the offset they were sent with, they are now
+-- written in UTC like every other timestamp and compared as text
+UPDATE vouchers SET expires_at = to_char(expires_at::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  WHERE expires_at <> '';
+UPDATE campaigns SET voucher_expires_at = to_char(voucher_expires_at::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  WHERE voucher_expires_at <> '';`,
+		// the offsets sent are not kept, the UTC times stand for the same instants
+		Down: ``,
+	},
+	{
+		Version: 15,
+		Name:    "canonical_redemption_times",
+		Up: `
+-- redeemed_at was once written by SQLite as datetime('now'), data carried over
+-- from it is in UTC without the T and Z
+UPDATE vouchers SET redeemed_at = to_char(redeemed_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+  WHERE redeemed_at LIKE '% %';`,
+		Down: ``,
+	},
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2/log"
+	_ "github.com/lib/pq"
+)
+
+func NewPostgresConnection(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Info("Successfully connected to PostgreSQL database!")
+	return db, nil
+}
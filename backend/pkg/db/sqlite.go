@@ -2,13 +2,16 @@ package db
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/gofiber/fiber/v2/log"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// NewSQLiteConnection opens the database with foreign keys enforced, SQLite
+// ignores the ON DELETE CASCADE rules of the schema otherwise.
 func NewSQLiteConnection(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", SQLiteDSN(dbPath))
 	if err != nil {
 		return nil, err
 	}
@@ -16,3 +19,13 @@ func NewSQLiteConnection(dbPath string) (*sql.DB, error) {
 	log.Info("Successfully connected to SQLite database!")
 	return db, nil
 }
+
+// SQLiteDSN adds the connection parameters every SQLite connection needs to
+// the database path.
+func SQLiteDSN(dbPath string) string {
+	sep := "?"
+	if strings.Contains(dbPath, "?") {
+		sep = "&"
+	}
+	return dbPath + sep + "_foreign_keys=on"
+}
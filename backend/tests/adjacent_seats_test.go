@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/seating"
+	"net/http"
+	"testing"
+)
+
+func findAdjacent(t *testing.T, testApp *TestApp, query string) (*models.AdjacentSeats, int) {
+	resp, _ := testApp.makeRequest("GET", "/api/v1/flights/1/adjacent-seats?"+query, nil)
+	if resp.Code != http.StatusOK {
+		return nil, resp.Code
+	}
+
+	var result struct {
+		Data models.AdjacentSeats `json:"data"`
+	}
+	parseResponse(t, resp, &result)
+	return &result.Data, resp.Code
+}
+
+func optionLabels(option models.AdjacentSeatsOption) string {
+	seats := make(models.Seats, 0, len(option.Seats))
+	for _, s := range option.Seats {
+		seats = append(seats, models.Seat{Label: s.SeatLabel})
+	}
+	return labelsOf(seats)
+}
+
+func TestAdjacentSeatsDryRun(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1-2:ABCDEF"})
+
+	adjacent, code := findAdjacent(t, testApp, "cabin=ECONOMY&count=4&strategy=front_to_back&limit=2")
+	if adjacent == nil {
+		t.Fatalf("Failed to find adjacent seats: %d", code)
+	}
+	if len(adjacent.Options) != 2 || adjacent.Strategy != seating.StrategyFrontToBack {
+		t.Fatalf("Expected two options, got %+v", adjacent)
+	}
+	// a 3-3 row seats four only across the aisle
+	if got := optionLabels(adjacent.Options[0]); got != "1A,1B,1C,1D" || adjacent.Options[0].Arrangement != seating.ArrangementAcrossAisle {
+		t.Errorf("Expected 1A-1D across the aisle, got %s %s", got, adjacent.Options[0].Arrangement)
+	}
+
+	// the dry run assigns nothing, redeeming gets the seats it offered
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "FAMILY", "flight_id": 1, "cabin": "ECONOMY", "group_size": 4, "seat_strategy": seating.StrategyFrontToBack,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create voucher: %s", resp.Body.String())
+	}
+	family, msg := redeemSeats(t, testApp, "FAMILY")
+	if family == nil || family.Arrangement != seating.ArrangementAcrossAisle || family.Seats[3].SeatLabel != "1D" {
+		t.Fatalf("Expected the offered seats, got %+v %s", family, msg)
+	}
+
+	adjacent, _ = findAdjacent(t, testApp, "cabin=ECONOMY&count=3&strategy=front_to_back")
+	if adjacent == nil || len(adjacent.Options) == 0 || optionLabels(adjacent.Options[0]) != "2A,2B,2C" {
+		t.Errorf("Expected row 2 once row 1 is taken, got %+v", adjacent)
+	}
+	adjacent, _ = findAdjacent(t, testApp, "cabin=ECONOMY&count=4&strategy=front_to_back")
+	if adjacent == nil || len(adjacent.Options) == 0 || adjacent.Options[0].Arrangement != seating.ArrangementAcrossAisle {
+		t.Errorf("Expected row 2 across the aisle, got %+v", adjacent)
+	}
+	adjacent, _ = findAdjacent(t, testApp, "cabin=ECONOMY&count=8&strategy=front_to_back")
+	if adjacent == nil || len(adjacent.Options) != 1 || optionLabels(adjacent.Options[0]) != "1E,1F,2A,2B,2C,2D,2E,2F" {
+		t.Errorf("Expected eight seats over rows 1 and 2, got %+v", adjacent)
+	}
+	adjacent, _ = findAdjacent(t, testApp, "cabin=ECONOMY&count=9&strategy=front_to_back")
+	if adjacent == nil || len(adjacent.Options) != 0 {
+		t.Errorf("Expected no room for nine, got %+v", adjacent)
+	}
+
+	for _, query := range []string{"cabin=ECONOMY", "count=2", "cabin=ECONOMY&count=10", "cabin=ECONOMY&count=2&strategy=nope"} {
+		if _, code := findAdjacent(t, testApp, query); code != http.StatusBadRequest {
+			t.Errorf("Expected %q to be rejected, got %d", query, code)
+		}
+	}
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/99/adjacent-seats?cabin=ECONOMY&count=2", nil)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown flight to be rejected, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"backend/internal/aircraft"
+	"net/http"
+	"testing"
+)
+
+func TestAircraftTemplates(t *testing.T) {
+	for _, tmpl := range aircraft.Templates() {
+		if err := tmpl.Validate(); err != nil {
+			t.Errorf("Template %s is invalid: %v", tmpl.Type, err)
+		}
+	}
+
+	b777, err := aircraft.Lookup("b777")
+	if err != nil {
+		t.Fatalf("Expected B777 template, got %v", err)
+	}
+
+	seats := map[string]string{}
+	cabins := map[string]int{}
+	for _, s := range b777.Seats() {
+		seats[s.Label] = s.Position
+		cabins[s.Cabin]++
+	}
+
+	if cabins["FIRST"] != 8 || cabins["BUSINESS"] != 48 || cabins["ECONOMY"] != 340 {
+		t.Errorf("Unexpected seats per cabin: %v", cabins)
+	}
+
+	tests := map[string]string{
+		"1A":  "WINDOW",
+		"1D":  "AISLE",
+		"3C":  "AISLE",
+		"11A": "WINDOW",
+		"11B": "MIDDLE",
+		"11C": "AISLE",
+		"11E": "MIDDLE",
+		"11G": "AISLE",
+		"11K": "WINDOW",
+	}
+	for label, position := range tests {
+		if seats[label] != position {
+			t.Errorf("Expected %s to be %s, got %q", label, position, seats[label])
+		}
+	}
+
+	if _, ok := seats["13A"]; ok {
+		t.Error("Expected row 13 to be skipped")
+	}
+
+	invalid := aircraft.Template{Type: "X1", Cabins: []aircraft.CabinLayout{
+		{Cabin: "BUSINESS", Rows: aircraft.RowRange{From: 1, To: 5}, Layout: "AC-DF"},
+		{Cabin: "ECONOMY", Rows: aircraft.RowRange{From: 5, To: 20}, Layout: "ABC-DEF"},
+	}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected overlapping cabin rows to be invalid")
+	}
+}
+
+func TestApplyAircraftTemplate(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+	})
+
+	resp, _ := testApp.makeRequest("GET", "/api/v1/seats/aircraft", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.Code)
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/seats/aircraft", map[string]any{
+		"flight_id":     1,
+		"aircraft_type": "B737",
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to apply template: %s", resp.Body.String())
+	}
+
+	seats := getSeats(t, testApp)
+	if len(seats) != 174 {
+		t.Errorf("Expected 174 seats, got %d", len(seats))
+	}
+	if s := seats["1A"]; s.Cabin != "BUSINESS" || s.Position != "WINDOW" {
+		t.Errorf("Expected 1A to be a BUSINESS window seat, got %+v", s)
+	}
+	if s := seats["15C"]; s.Cabin != "ECONOMY" || !s.ExitRow || !s.ExtraLegroom || s.Position != "AISLE" {
+		t.Errorf("Expected 15C to be an ECONOMY exit row aisle seat, got %+v", s)
+	}
+	if s := seats["14A"]; s.Reclining {
+		t.Errorf("Expected 14A in front of the exit not to recline, got %+v", s)
+	}
+
+	tests := []struct {
+		name string
+		body map[string]any
+	}{
+		{name: "flight already has seats", body: map[string]any{"flight_id": 1, "aircraft_type": "A320"}},
+		{name: "unknown aircraft type", body: map[string]any{"flight_id": 1, "aircraft_type": "C919"}},
+		{name: "flight not found", body: map[string]any{"flight_id": 99, "aircraft_type": "A320"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testApp.makeRequest("POST", "/api/v1/seats/aircraft", tt.body)
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.Code)
+			}
+		})
+	}
+
+	if seats := getSeats(t, testApp); len(seats) != 174 {
+		t.Errorf("Expected failed applies to create no seats, got %d", len(seats))
+	}
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/seating"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// createCampaign opens a campaign on flight 1 for the ECONOMY cabin, from an
+// hour ago until a day from now unless fields override it.
+func createCampaign(t *testing.T, testApp *TestApp, fields map[string]any) models.Campaign {
+	body := map[string]any{
+		"name":       "Autumn sale",
+		"starts_at":  testApp.Clock.Now().Add(-time.Hour).Format(time.RFC3339),
+		"ends_at":    testApp.Clock.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"flight_ids": []int64{1},
+		"cabin":      "ECONOMY",
+		"quota":      3,
+	}
+	for k, v := range fields {
+		body[k] = v
+	}
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/campaigns", body)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create campaign: %s", resp.Body.String())
+	}
+
+	var created struct {
+		Data models.Campaign `json:"data"`
+	}
+	parseResponse(t, resp, &created)
+	return created.Data
+}
+
+func TestCampaignVouchers(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B", "1C"})
+	campaign := createCampaign(t, testApp, map[string]any{
+		"seat_strategy":      seating.StrategyFrontToBack,
+		"voucher_expires_at": "2030-01-01T00:00:00Z",
+	})
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "CAMP1", "flight_id": 1, "cabin": "ECONOMY", "campaign_id": campaign.ID,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create campaign voucher: %s", resp.Body.String())
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/batch", map[string]any{
+		"flight_id": 1, "cabin": "ECONOMY", "count": 2, "campaign_id": campaign.ID,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create campaign batch: %s", resp.Body.String())
+	}
+	var batch struct {
+		Data models.VoucherBatch `json:"data"`
+	}
+	parseResponse(t, resp, &batch)
+	if batch.Data.ExpiresAt != "2030-01-01T00:00:00Z" {
+		t.Errorf("Expected the campaign's default expiry, got %q", batch.Data.ExpiresAt)
+	}
+
+	// the quota of 3 is used up
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "CAMP2", "flight_id": 1, "cabin": "ECONOMY", "campaign_id": campaign.ID,
+	})
+	var result map[string]any
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != "campaign quota exceeded, 0 of 3 vouchers left!" {
+		t.Errorf("Expected the quota to be enforced, got %d %v", resp.Code, result["data"])
+	}
+
+	// the campaign's strategy seats front to back
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "CAMP1"})
+	var assigned struct {
+		Data models.VoucherAssigment `json:"data"`
+	}
+	parseResponse(t, resp, &assigned)
+	if resp.Code != http.StatusCreated || assigned.Data.Strategy != seating.StrategyFrontToBack {
+		t.Fatalf("Expected the campaign's strategy, got %d %+v", resp.Code, assigned.Data)
+	}
+
+	resp, _ = testApp.makeRequest("GET", fmt.Sprintf("/api/v1/campaigns/%d/report", campaign.ID), nil)
+	var report struct {
+		Data models.CampaignReport `json:"data"`
+	}
+	parseResponse(t, resp, &report)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to get campaign report: %d", resp.Code)
+	}
+	r := report.Data
+	if r.Issued != 3 || r.Redeemed != 1 || r.QuotaLeft != 0 || r.RedemptionRate < 0.33 || r.RedemptionRate > 0.34 {
+		t.Errorf("Unexpected report %+v", r)
+	}
+	if len(r.Flights) != 1 || r.Flights[0].FlightNo != "GA100" || r.Flights[0].Issued != 3 {
+		t.Errorf("Unexpected flight report %+v", r.Flights)
+	}
+}
+
+func TestCampaignVoucherRules(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"})
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA200"},
+		"dep_date":       "2025-10-10",
+	})
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": 2, "cabin": "ECONOMY", "labels": []string{"1A"}})
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": 1, "cabin": "BUSINESS", "labels": []string{"2A"}})
+
+	open := createCampaign(t, testApp, nil)
+	upcoming := createCampaign(t, testApp, map[string]any{
+		"name":      "Winter sale",
+		"starts_at": testApp.Clock.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"ends_at":   testApp.Clock.Now().Add(48 * time.Hour).Format(time.RFC3339),
+	})
+	ended := createCampaign(t, testApp, map[string]any{
+		"name":      "Summer sale",
+		"starts_at": testApp.Clock.Now().Add(-48 * time.Hour).Format(time.RFC3339),
+		"ends_at":   testApp.Clock.Now().Add(-24 * time.Hour).Format(time.RFC3339),
+	})
+
+	tests := []struct {
+		name       string
+		campaignID int64
+		flightID   int64
+		cabin      string
+		want       string
+	}{
+		{"unknown campaign", 99, 1, "ECONOMY", "campaign not found!"},
+		{"not started", upcoming.ID, 1, "ECONOMY", "campaign not started!"},
+		{"ended", ended.ID, 1, "ECONOMY", "campaign ended!"},
+		{"other flight", open.ID, 2, "ECONOMY", "flight not in campaign!"},
+		{"other cabin", open.ID, 1, "BUSINESS", "cabin not in campaign!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+				"code": "RULE1", "flight_id": tt.flightID, "cabin": tt.cabin, "campaign_id": tt.campaignID,
+			})
+			var result map[string]any
+			parseResponse(t, resp, &result)
+			if resp.Code != http.StatusBadRequest || result["data"] != tt.want {
+				t.Errorf("Expected %q, got %d %v", tt.want, resp.Code, result["data"])
+			}
+		})
+	}
+}
+
+func TestCreateCampaignErrors(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"})
+	createCampaign(t, testApp, nil)
+
+	now := testApp.Clock.Now()
+	tests := []struct {
+		name   string
+		fields map[string]any
+	}{
+		{"duplicate name", map[string]any{}},
+		{"ends before it starts", map[string]any{"name": "Backwards", "ends_at": now.Add(-2 * time.Hour).Format(time.RFC3339)}},
+		{"unknown flight", map[string]any{"name": "Nowhere", "flight_ids": []int64{1, 42}}},
+		{"no flights", map[string]any{"name": "Empty", "flight_ids": []int64{}}},
+		{"zero quota", map[string]any{"name": "None", "quota": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]any{
+				"name":       "Autumn sale",
+				"starts_at":  now.Add(-time.Hour).Format(time.RFC3339),
+				"ends_at":    now.Add(time.Hour).Format(time.RFC3339),
+				"flight_ids": []int64{1},
+				"cabin":      "ECONOMY",
+				"quota":      3,
+			}
+			for k, v := range tt.fields {
+				body[k] = v
+			}
+			if resp, _ := testApp.makeRequest("POST", "/api/v1/campaigns", body); resp.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", resp.Code)
+			}
+		})
+	}
+
+	resp, _ := testApp.makeRequest("GET", "/api/v1/campaigns", nil)
+	var campaigns struct {
+		Data models.Campaigns `json:"data"`
+	}
+	parseResponse(t, resp, &campaigns)
+	if len(campaigns.Data) != 1 || len(campaigns.Data[0].FlightIDs) != 1 {
+		t.Errorf("Expected only the first campaign, got %+v", campaigns.Data)
+	}
+}
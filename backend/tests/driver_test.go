@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"backend/pkg/db"
+	"testing"
+)
+
+func TestDriverRebind(t *testing.T) {
+	tests := []struct {
+		name     string
+		driver   db.Driver
+		query    string
+		expected string
+	}{
+		{
+			name:     "SQLite keeps placeholders",
+			driver:   db.SQLite,
+			query:    `SELECT id FROM seats WHERE flight_id=? AND cabin=?`,
+			expected: `SELECT id FROM seats WHERE flight_id=? AND cabin=?`,
+		},
+		{
+			name:     "Postgres numbers placeholders",
+			driver:   db.Postgres,
+			query:    `SELECT id FROM seats WHERE flight_id=? AND cabin=?`,
+			expected: `SELECT id FROM seats WHERE flight_id=$1 AND cabin=$2`,
+		},
+		{
+			name:     "Postgres ignores question marks in literals",
+			driver:   db.Postgres,
+			query:    `SELECT '?' FROM vouchers WHERE code=?`,
+			expected: `SELECT '?' FROM vouchers WHERE code=$1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.driver.Rebind(tt.query); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseDriver(t *testing.T) {
+	for name, expected := range map[string]db.Driver{
+		"sqlite":     db.SQLite,
+		"sqlite3":    db.SQLite,
+		"postgres":   db.Postgres,
+		"PostgreSQL": db.Postgres,
+	} {
+		driver, err := db.ParseDriver(name)
+		if err != nil || driver != expected {
+			t.Errorf("ParseDriver(%q) = %q, %v; expected %q", name, driver, err, expected)
+		}
+	}
+
+	if _, err := db.ParseDriver("mysql"); err == nil {
+		t.Error("Expected error for unsupported driver, got nil")
+	}
+}
@@ -1,8 +1,11 @@
 package tests
 
 import (
+	"backend/internal/airports"
+	"backend/internal/models"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestCreateFlights(t *testing.T) {
@@ -158,3 +161,204 @@ func TestGetAllFlightsEmpty(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.Code)
 	}
 }
+
+func TestUpdateFlight(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100", "GA200"},
+		"dep_date":       "2025-10-10",
+	})
+
+	var result struct {
+		Data models.Flight `json:"data"`
+	}
+	resp, _ := testApp.makeRequest("PATCH", "/api/v1/flights/1", map[string]any{"flight_no": " ga101 "})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to update flight: %s", resp.Body.String())
+	}
+	parseResponse(t, resp, &result)
+	if result.Data.FlightNo != "GA101" || result.Data.DepDate.Format("2006-01-02") != "2025-10-10" {
+		t.Errorf("Expected GA101 on the same date, got %+v", result.Data)
+	}
+
+	resp, _ = testApp.makeRequest("PATCH", "/api/v1/flights/1", map[string]any{"dep_date": "2025-10-11"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to update flight: %s", resp.Body.String())
+	}
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/1", nil)
+	parseResponse(t, resp, &result)
+	if result.Data.FlightNo != "GA101" || result.Data.DepDate.Format("2006-01-02") != "2025-10-11" {
+		t.Errorf("Expected GA101 moved a day later, got %+v", result.Data)
+	}
+
+	errorTests := []struct {
+		name     string
+		path     string
+		body     map[string]any
+		expected string
+	}{
+		{name: "clashes with another flight", path: "/api/v1/flights/2", body: map[string]any{"flight_no": "GA101", "dep_date": "2025-10-11"}, expected: "flight already exists on that date!"},
+		{name: "nothing to change", path: "/api/v1/flights/2", body: map[string]any{}, expected: "nothing to change on the flight!"},
+		{name: "invalid date", path: "/api/v1/flights/2", body: map[string]any{"dep_date": "11-10-2025"}, expected: "DepDate must be in format 2006-01-02"},
+		{name: "unknown flight", path: "/api/v1/flights/99", body: map[string]any{"flight_no": "GA999"}, expected: "flight not found"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testApp.makeRequest("PATCH", tt.path, tt.body)
+			var result map[string]any
+			parseResponse(t, resp, &result)
+			if resp.Code != http.StatusBadRequest || result["data"] != tt.expected {
+				t.Errorf("Expected %q, got %d %v", tt.expected, resp.Code, result["data"])
+			}
+		})
+	}
+}
+
+func TestDeleteFlight(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B"}, "V1", "V2")
+	seated, msg := redeemSeats(t, testApp, "V1")
+	if seated == nil {
+		t.Fatalf("Failed to redeem V1: %s", msg)
+	}
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{"flight_numbers": []string{"GA200"}, "dep_date": "2025-10-10"})
+
+	// a flight whose vouchers hold seats is only deleted on purpose
+	resp, _ := testApp.makeRequest("DELETE", "/api/v1/flights/1", nil)
+	var refused map[string]any
+	parseResponse(t, resp, &refused)
+	if resp.Code != http.StatusBadRequest || refused["data"] != "flight has redeemed vouchers, delete it with force!" {
+		t.Errorf("Expected the delete to be refused, got %d %v", resp.Code, refused["data"])
+	}
+
+	var result struct {
+		Data models.DeletedFlight `json:"data"`
+	}
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/flights/1?force=true", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to delete flight: %s", resp.Body.String())
+	}
+	parseResponse(t, resp, &result)
+	deleted := result.Data
+	if deleted.FlightNo != "GA100" || len(deleted.Vouchers) != 2 || deleted.Vouchers[0] != "V1" || deleted.Vouchers[1] != "V2" {
+		t.Errorf("Expected GA100 deleted with V1 and V2, got %+v", deleted)
+	}
+	if len(deleted.SeatAssignments) != 1 || deleted.SeatAssignments[0].VoucherCode != "V1" || deleted.SeatAssignments[0].SeatLabel != seated.SeatLabel {
+		t.Errorf("Expected V1's seat in the report, got %+v", deleted.SeatAssignments)
+	}
+
+	// everything cascaded with the flight
+	if resp, _ := testApp.makeRequest("GET", "/api/v1/flights/1", nil); resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected the flight to be gone, got %d", resp.Code)
+	}
+	if _, code := lookupVoucher(t, testApp, "V1"); code != http.StatusBadRequest {
+		t.Errorf("Expected V1 to be gone, got %d", code)
+	}
+	var seats struct {
+		Data models.Seats `json:"data"`
+	}
+	resp, _ = testApp.makeRequest("GET", "/api/v1/seats", nil)
+	parseResponse(t, resp, &seats)
+	if len(seats.Data) != 0 {
+		t.Errorf("Expected the seats to be gone, got %+v", seats.Data)
+	}
+
+	// nothing redeemed, nothing to force
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/flights/2", nil)
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusOK || result.Data.FlightNo != "GA200" || len(result.Data.Vouchers) != 0 {
+		t.Errorf("Expected GA200 to be deleted, got %d %+v", resp.Code, result.Data)
+	}
+	if resp, _ := testApp.makeRequest("DELETE", "/api/v1/flights/2", nil); resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected a second delete to fail, got %d", resp.Code)
+	}
+}
+
+func TestFlightSchedule(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA820"},
+		"origin":         "cgk",
+		"destination":    "SIN",
+		"departure_at":   "2025-10-10T08:30",
+		"arrival_at":     "2025-10-10T11:15",
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create flight: %s", resp.Body.String())
+	}
+
+	var result struct {
+		Data models.Flight `json:"data"`
+	}
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/1", nil)
+	parseResponse(t, resp, &result)
+	flight := result.Data
+	if flight.Origin != "CGK" || flight.Destination != "SIN" || flight.DepDate.Format("2006-01-02") != "2025-10-10" {
+		t.Errorf("Expected CGK to SIN on 2025-10-10, got %+v", flight)
+	}
+	if flight.DepartureAt == nil || flight.DepartureAt.Format(time.RFC3339) != "2025-10-10T08:30:00+07:00" || flight.DepartureTimezone != "Asia/Jakarta" {
+		t.Errorf("Expected a departure at 08:30 Jakarta time, got %v %s", flight.DepartureAt, flight.DepartureTimezone)
+	}
+	if flight.ArrivalAt == nil || flight.ArrivalAt.Format(time.RFC3339) != "2025-10-10T11:15:00+08:00" || flight.ArrivalTimezone != "Asia/Singapore" {
+		t.Errorf("Expected an arrival at 11:15 Singapore time, got %v %s", flight.ArrivalAt, flight.ArrivalTimezone)
+	}
+
+	// moving the date keeps the local times
+	resp, _ = testApp.makeRequest("PATCH", "/api/v1/flights/1", map[string]any{"dep_date": "2025-10-12"})
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusOK || result.Data.DepartureAt.Format(time.RFC3339) != "2025-10-12T08:30:00+07:00" || result.Data.ArrivalAt.Format(time.RFC3339) != "2025-10-12T11:15:00+08:00" {
+		t.Errorf("Expected the flight two days later, got %d %+v", resp.Code, result.Data)
+	}
+
+	// a red-eye lands the next day
+	resp, _ = testApp.makeRequest("PATCH", "/api/v1/flights/1", map[string]any{
+		"destination": "LHR", "departure_at": "2025-10-12T22:40", "arrival_at": "2025-10-13T05:25",
+	})
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusOK || result.Data.ArrivalAt.Format(time.RFC3339) != "2025-10-13T05:25:00+01:00" || result.Data.ArrivalTimezone != "Europe/London" {
+		t.Errorf("Expected an arrival in London the next morning, got %d %+v", resp.Code, result.Data)
+	}
+
+	errorTests := []struct {
+		name     string
+		method   string
+		path     string
+		body     map[string]any
+		expected string
+	}{
+		{name: "unknown airport", method: "POST", path: "/api/v1/flights", body: map[string]any{"flight_numbers": []string{"GA1"}, "dep_date": "2025-10-10", "origin": "XXX", "destination": "SIN"}, expected: "Origin must be a known IATA airport code, see /api/v1/flights/airports"},
+		{name: "route half given", method: "POST", path: "/api/v1/flights", body: map[string]any{"flight_numbers": []string{"GA1"}, "dep_date": "2025-10-10", "origin": "CGK"}, expected: "Destination is required with Origin"},
+		{name: "times without route", method: "POST", path: "/api/v1/flights", body: map[string]any{"flight_numbers": []string{"GA1"}, "departure_at": "2025-10-10T08:30", "arrival_at": "2025-10-10T10:30"}, expected: "flight times need an origin and a destination!"},
+		{name: "same airport", method: "POST", path: "/api/v1/flights", body: map[string]any{"flight_numbers": []string{"GA1"}, "dep_date": "2025-10-10", "origin": "CGK", "destination": "CGK"}, expected: "flight must arrive at another airport than it departs from!"},
+		// 10:30 in Singapore is 09:30 in Jakarta
+		{name: "arrives before departure", method: "POST", path: "/api/v1/flights", body: map[string]any{"flight_numbers": []string{"GA1"}, "origin": "CGK", "destination": "SIN", "departure_at": "2025-10-10T10:00", "arrival_at": "2025-10-10T10:30"}, expected: "flight must arrive after it departs!"},
+		{name: "date off the departure", method: "POST", path: "/api/v1/flights", body: map[string]any{"flight_numbers": []string{"GA1"}, "dep_date": "2025-10-11", "origin": "CGK", "destination": "SIN", "departure_at": "2025-10-10T08:30", "arrival_at": "2025-10-10T11:15"}, expected: "dep_date must be the local date of the departure!"},
+		{name: "arrival left out", method: "PATCH", path: "/api/v1/flights/2", body: map[string]any{"origin": "CGK", "destination": "DPS", "departure_at": "2025-10-10T08:30"}, expected: "flight needs both a departure and an arrival time!"},
+	}
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{"flight_numbers": []string{"GA400"}, "dep_date": "2025-10-10"})
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testApp.makeRequest(tt.method, tt.path, tt.body)
+			var result map[string]any
+			parseResponse(t, resp, &result)
+			if resp.Code != http.StatusBadRequest || result["data"] != tt.expected {
+				t.Errorf("Expected %q, got %d %v", tt.expected, resp.Code, result["data"])
+			}
+		})
+	}
+
+	var listed struct {
+		Data []airports.Airport `json:"data"`
+	}
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/airports", nil)
+	parseResponse(t, resp, &listed)
+	if len(listed.Data) == 0 || listed.Data[0].Code != "AMS" || listed.Data[0].Timezone != "Europe/Amsterdam" {
+		t.Errorf("Expected the airports ordered by code, got %+v", listed.Data)
+	}
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/seating"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRankGroups(t *testing.T) {
+	// row 1 has a gap at 1C, row 2 is free, both 2-2 with the aisle between B and C
+	free := seatsFromLabels("1A", "1B", "1D", "2A", "2B", "2C", "2D")
+	pool := seating.SeatPool{Free: free, Assigned: models.Seats{{ID: 20, Label: "1C"}}}
+	strategy, _ := seating.Lookup(seating.StrategyFrontToBack)
+
+	groups := seating.RankGroups(strategy, pool, nil, 3)
+	for _, group := range groups[:2] {
+		if len(group.Seats) != 3 || group.Seats[0].Label[0] != '2' || group.Arrangement != seating.ArrangementAcrossAisle {
+			t.Errorf("Unexpected group %v", group)
+		}
+	}
+	if groups[0].Seats[0].Label != "2A" {
+		t.Errorf("Expected the front most group first, got %v", groups[0])
+	}
+	for _, group := range groups[2:] {
+		if group.Arrangement != seating.ArrangementAdjacentRows {
+			t.Errorf("Expected rows 1 and 2 to be offered last, got %v", group)
+		}
+	}
+
+	if groups := seating.RankGroups(strategy, pool, nil, 2); groups[0].Seats[0].Label != "1A" || groups[0].Seats[1].Label != "1B" {
+		t.Errorf("Expected 1A and 1B as the first pair, got %v", groups[0])
+	}
+	if groups := seating.RankGroups(strategy, pool, nil, 5); len(groups) == 0 || groups[0].Arrangement != seating.ArrangementAdjacentRows {
+		t.Errorf("Expected a group of 5 over two rows, got %v", groups)
+	}
+	if groups := seating.RankGroups(strategy, pool, nil, 7); len(groups) != 0 {
+		t.Errorf("Expected no group of 7, got %v", groups)
+	}
+}
+
+func TestRankGroupsAvoidsAisle(t *testing.T) {
+	// a 3-3 row with the window seat taken, rows 3 and 5 are neighbours without a row 4
+	free := seatsFromLabels("1B", "1C", "1D", "1E", "1F", "3A", "3B", "5B", "5C")
+	pool := seating.SeatPool{Free: free, Assigned: models.Seats{
+		{ID: 20, Label: "1A"}, {ID: 21, Label: "3C"}, {ID: 22, Label: "5A"},
+	}}
+	strategy, _ := seating.Lookup(seating.StrategyFrontToBack)
+
+	groups := seating.RankGroups(strategy, pool, nil, 3)
+	if got := labelsOf(groups[0].Seats); got != "1D,1E,1F" || groups[0].Arrangement != seating.ArrangementSameRow {
+		t.Errorf("Expected the seats on one side of the aisle first, got %s %s", got, groups[0].Arrangement)
+	}
+	if got := labelsOf(groups[1].Seats); got != "1B,1C,1D" || groups[1].Arrangement != seating.ArrangementAcrossAisle {
+		t.Errorf("Expected the seats across the aisle next, got %s %s", got, groups[1].Arrangement)
+	}
+
+	groups = seating.RankGroups(strategy, pool, nil, 4)
+	for _, group := range groups {
+		if group.Arrangement == seating.ArrangementAdjacentRows && labelsOf(group.Seats) == "3A,3B,5B,5C" {
+			return
+		}
+	}
+	t.Errorf("Expected rows 3 and 5 to seat a group of 4 together, got %v", groups)
+}
+
+func labelsOf(seats models.Seats) string {
+	labels := make([]string, 0, len(seats))
+	for _, s := range seats {
+		labels = append(labels, s.Label)
+	}
+	return strings.Join(labels, ",")
+}
+
+func redeemSeats(t *testing.T, testApp *TestApp, code string) (*models.VoucherAssigment, string) {
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": code})
+	if resp.Code != http.StatusCreated {
+		var result map[string]any
+		parseResponse(t, resp, &result)
+		msg, _ := result["data"].(string)
+		return nil, msg
+	}
+
+	var assigned struct {
+		Data models.VoucherAssigment `json:"data"`
+	}
+	parseResponse(t, resp, &assigned)
+	return &assigned.Data, ""
+}
+
+func TestMultiUseVoucher(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B", "1C"})
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "TWICE", "flight_id": 1, "cabin": "ECONOMY", "max_redemptions": 2,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create voucher: %s", resp.Body.String())
+	}
+
+	first, msg := redeemSeats(t, testApp, "TWICE")
+	if first == nil || first.RedemptionsLeft != 1 {
+		t.Fatalf("Expected a redemption left, got %+v %s", first, msg)
+	}
+	second, msg := redeemSeats(t, testApp, "TWICE")
+	if second == nil || second.RedemptionsLeft != 0 || second.SeatID == first.SeatID {
+		t.Fatalf("Expected another seat and no redemptions left, got %+v %s", second, msg)
+	}
+	if _, msg := redeemSeats(t, testApp, "TWICE"); msg != "voucher already redeemed!" {
+		t.Errorf("Expected the voucher to be used up, got %q", msg)
+	}
+
+	// giving one seat back allows one more redemption
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/vouchers/TWICE/assignment", map[string]any{
+		"changed_by": "agent-7", "reason": "cancelled",
+	})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("Expected from_seat to be required, got %d", resp.Code)
+	}
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/vouchers/TWICE/assignment", map[string]any{
+		"changed_by": "agent-7", "reason": "cancelled", "from_seat": first.SeatLabel,
+	})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to unassign seat: %s", resp.Body.String())
+	}
+	if third, msg := redeemSeats(t, testApp, "TWICE"); third == nil || third.RedemptionsLeft != 0 {
+		t.Errorf("Expected the freed redemption to be usable, got %+v %s", third, msg)
+	}
+}
+
+func TestGroupVoucher(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B", "1C", "2A", "2B"}, "SINGLE1", "SINGLE2")
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/batch", map[string]any{
+		"flight_id": 1, "cabin": "ECONOMY", "count": 2, "group_size": 3, "seat_strategy": seating.StrategyBackToFront,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create group vouchers: %s", resp.Body.String())
+	}
+	var batch struct {
+		Data models.VoucherBatch `json:"data"`
+	}
+	parseResponse(t, resp, &batch)
+
+	// back to front would start in row 2, but only row 1 seats three together
+	family, msg := redeemSeats(t, testApp, batch.Data.Codes[0])
+	if family == nil || len(family.Seats) != 3 {
+		t.Fatalf("Expected three seats, got %+v %s", family, msg)
+	}
+	for i, want := range []string{"1A", "1B", "1C"} {
+		if family.Seats[i].SeatLabel != want {
+			t.Errorf("Expected seat %s, got %s", want, family.Seats[i].SeatLabel)
+		}
+	}
+
+	// no three adjacent seats are left, nothing is assigned
+	if _, msg := redeemSeats(t, testApp, batch.Data.Codes[1]); msg != "no 3 adjacent seats available in cabin!" {
+		t.Errorf("Expected the group to be rejected, got %q", msg)
+	}
+	for _, code := range []string{"SINGLE1", "SINGLE2"} {
+		if single, msg := redeemSeats(t, testApp, code); single == nil || len(single.Seats) != 1 {
+			t.Errorf("Expected row 2 to be left free, got %+v %s", single, msg)
+		}
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/"+batch.Data.Codes[1]+"/hold", nil)
+	var result map[string]any
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != repository.ErrGroupHold.Error() {
+		t.Errorf("Expected group holds to be rejected, got %d %v", resp.Code, result["data"])
+	}
+
+	// moving one member keeps the rest of the group
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/vouchers/"+batch.Data.Codes[0]+"/assignment", map[string]any{
+		"changed_by": "agent-7", "reason": "one passenger stays home", "from_seat": "1C",
+	})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to unassign group seat: %s", resp.Body.String())
+	}
+	if _, msg := redeemSeats(t, testApp, batch.Data.Codes[0]); msg != "voucher already redeemed!" {
+		t.Errorf("Expected the group voucher to stay redeemed, got %q", msg)
+	}
+
+	resp, _ = testApp.makeRequest("GET", "/api/v1/vouchers", nil)
+	var vouchers struct {
+		Data []map[string]any `json:"data"`
+	}
+	parseResponse(t, resp, &vouchers)
+	for _, v := range vouchers.Data {
+		if v["code"] == batch.Data.Codes[0] && (v["group_size"] != float64(3) || v["redemptions"] != float64(1)) {
+			t.Errorf("Unexpected group voucher %v", v)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/repository/memory"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryRepositoriesInvariants(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore(clock.NewFake(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)))
+	flights := memory.NewFlightsRepository(store)
+	seats := memory.NewSeatRepository(store)
+	vouchers := memory.NewVouchersRepository(store)
+
+	depDate := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+	if err := flights.Create(ctx, &models.CreateBulkFlight{FlightNumbers: []string{"GA100"}, DepDate: depDate}); err != nil {
+		t.Fatalf("Failed to create flight: %v", err)
+	}
+	if err := flights.Create(ctx, &models.CreateBulkFlight{FlightNumbers: []string{"ga100"}, DepDate: depDate}); err == nil {
+		t.Error("Expected duplicate flight number and date to fail")
+	}
+
+	if err := seats.Create(ctx, &models.CreateBulkSeat{FlightID: 1, Cabin: "ECONOMY", Labels: []string{"1A", "1B", "1C"}}); err != nil {
+		t.Fatalf("Failed to create seats: %v", err)
+	}
+	if err := seats.Create(ctx, &models.CreateBulkSeat{FlightID: 1, Cabin: "ECONOMY", Labels: []string{"2A", "1a"}}); err == nil {
+		t.Error("Expected duplicate seat label to fail")
+	}
+	all, _ := seats.GetAll(ctx)
+	if len(*all) != 3 {
+		t.Errorf("Expected failed batch to insert nothing, got %d seats", len(*all))
+	}
+
+	expired := "2000-01-01T00:00:00Z"
+	if err := vouchers.Create(ctx, &models.CreateNewVoucher{Code: "OLD", FlightID: 1, Cabin: "ECONOMY"}); err != nil {
+		t.Fatalf("Failed to create voucher: %v", err)
+	}
+	if err := vouchers.Create(ctx, &models.CreateNewVoucher{Code: "OLD", FlightID: 1, Cabin: "ECONOMY"}); err == nil {
+		t.Error("Expected duplicate voucher code to fail")
+	}
+	if err := vouchers.Create(ctx, &models.CreateNewVoucher{Code: "X", FlightID: 1, Cabin: "FIRST"}); err == nil {
+		t.Error("Expected voucher without seats in cabin to fail")
+	}
+
+	cnv := &models.CreateNewVoucher{Code: "EXPIRED", FlightID: 1, Cabin: "ECONOMY"}
+	cnv.ExpiresAt.String, cnv.ExpiresAt.Valid = expired, true
+	if err := vouchers.Create(ctx, cnv); err != nil {
+		t.Fatalf("Failed to create voucher: %v", err)
+	}
+	if _, err := vouchers.Assigns(ctx, &models.AssignsRandomVoucher{VoucherCode: "EXPIRED"}); err == nil {
+		t.Error("Expected expired voucher to be rejected")
+	}
+
+	if _, err := vouchers.Assigns(ctx, &models.AssignsRandomVoucher{VoucherCode: "OLD"}); err != nil {
+		t.Fatalf("Failed to assign voucher: %v", err)
+	}
+	if _, err := vouchers.Assigns(ctx, &models.AssignsRandomVoucher{VoucherCode: "OLD"}); err == nil {
+		t.Error("Expected already redeemed voucher to be rejected")
+	}
+}
+
+func TestMemoryConcurrentAssignments(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore(nil)
+	flights := memory.NewFlightsRepository(store)
+	seats := memory.NewSeatRepository(store)
+	vouchers := memory.NewVouchersRepository(store)
+
+	flights.Create(ctx, &models.CreateBulkFlight{FlightNumbers: []string{"GA100"}, DepDate: time.Now()})
+	seats.Create(ctx, &models.CreateBulkSeat{FlightID: 1, Cabin: "ECONOMY", Labels: []string{"1A", "1B", "1C", "1D", "1E"}})
+
+	const redeemers = 10
+	for i := 0; i < redeemers; i++ {
+		vouchers.Create(ctx, &models.CreateNewVoucher{Code: fmt.Sprintf("V%d", i), FlightID: 1, Cabin: "ECONOMY"})
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	taken := map[int64]bool{}
+	for i := 0; i < redeemers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assignment, err := vouchers.Assigns(ctx, &models.AssignsRandomVoucher{VoucherCode: fmt.Sprintf("V%d", i)})
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if taken[assignment.SeatID] {
+				t.Errorf("Seat %d assigned twice", assignment.SeatID)
+			}
+			taken[assignment.SeatID] = true
+		}(i)
+	}
+	wg.Wait()
+
+	if len(taken) != 5 {
+		t.Errorf("Expected all 5 seats assigned, got %d", len(taken))
+	}
+}
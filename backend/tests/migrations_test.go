@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"backend/internal/clock"
+	"backend/pkg/db"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openMigrationDB(t *testing.T) *sql.DB {
+	database, err := sql.Open("sqlite3", db.SQLiteDSN(":memory:"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	database.SetMaxOpenConns(1)
+	t.Cleanup(func() { database.Close() })
+
+	return database
+}
+
+func tableExists(t *testing.T, database *sql.DB, name string) bool {
+	var exists bool
+	if err := database.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type='table' AND name=?)`, name).Scan(&exists); err != nil {
+		t.Fatalf("Failed to query sqlite_master: %v", err)
+	}
+	return exists
+}
+
+func TestMigrateUpDownRedo(t *testing.T) {
+	ctx := context.Background()
+	database := openMigrationDB(t)
+
+	migrations := []db.Migration{
+		{Version: 2, Name: "second", Up: `CREATE TABLE second(id INTEGER);`, Down: `DROP TABLE second;`},
+		{Version: 1, Name: "first", Up: `CREATE TABLE first(id INTEGER);`, Down: `DROP TABLE first;`},
+	}
+	migrator := db.NewMigrator(database, db.SQLite, migrations, nil)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if len(applied) != 2 || applied[0].Version != 1 || applied[1].Version != 2 {
+		t.Fatalf("Expected versions 1 and 2 applied in order, got %+v", applied)
+	}
+
+	applied, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Second Up failed: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("Expected no pending migrations, got %d", len(applied))
+	}
+
+	rolled, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if len(rolled) != 1 || rolled[0].Version != 2 {
+		t.Fatalf("Expected version 2 rolled back, got %+v", rolled)
+	}
+	if tableExists(t, database, "second") {
+		t.Error("Expected table second to be dropped")
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Expected 1 applied and 2 pending, got %+v", statuses)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	redone, err := migrator.Redo(ctx)
+	if err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+	if redone.Version != 2 || !tableExists(t, database, "second") {
+		t.Errorf("Expected version 2 redone, got %+v", redone)
+	}
+}
+
+func TestMigrateDetectsModifiedMigration(t *testing.T) {
+	ctx := context.Background()
+	database := openMigrationDB(t)
+
+	original := []db.Migration{
+		{Version: 1, Name: "first", Up: `CREATE TABLE first(id INTEGER);`, Down: `DROP TABLE first;`},
+	}
+	if _, err := db.NewMigrator(database, db.SQLite, original, nil).Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	modified := []db.Migration{
+		{Version: 1, Name: "first", Up: `CREATE TABLE first(id INTEGER, name TEXT);`, Down: `DROP TABLE first;`},
+	}
+	migrator := db.NewMigrator(database, db.SQLite, modified, nil)
+	if _, err := migrator.Up(ctx); err == nil {
+		t.Error("Expected error for modified migration, got nil")
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !statuses[0].Modified {
+		t.Error("Expected status to flag the modified migration")
+	}
+}
+
+func TestMigrateAdoptsExistingSchema(t *testing.T) {
+	ctx := context.Background()
+	database := openMigrationDB(t)
+
+	// databases created before migrations existed already have the tables
+	if _, err := database.Exec(db.SCHEMA); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	if _, err := database.Exec(`INSERT INTO flights(flight_no, dep_date) VALUES('GA100', '2025-10-10T00:00:00Z')`); err != nil {
+		t.Fatalf("Failed to insert flight: %v", err)
+	}
+
+	if _, err := db.NewMigrator(database, db.SQLite, db.Migrations, nil).Up(ctx); err != nil {
+		t.Fatalf("Up failed on existing schema: %v", err)
+	}
+
+	var count int
+	if err := database.QueryRow(`SELECT count(*) FROM flights`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count flights: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected existing flight to be kept, got %d rows", count)
+	}
+}
+
+func TestMigrateCanonicalExpiries(t *testing.T) {
+	ctx := context.Background()
+	database := openMigrationDB(t)
+
+	// expiries stored before they were written in UTC keep their offset
+	if _, err := db.NewMigrator(database, db.SQLite, db.Migrations[:13], nil).Up(ctx); err != nil {
+		t.Fatalf("Up to version 13 failed: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO flights(flight_no, dep_date) VALUES('GA100', '2025-10-10T00:00:00Z')`,
+		`INSERT INTO vouchers(code, flight_id, cabin, expires_at) VALUES('OFFSET', 1, 'ECONOMY', '2030-01-01T07:00:00+07:00')`,
+		`INSERT INTO campaigns(name, starts_at, ends_at, cabin, quota, voucher_expires_at, created_at)
+			VALUES('spring', '2025-01-01T00:00:00Z', '2025-02-01T00:00:00Z', 'ECONOMY', 10, '2030-01-01T17:30:00+07:00', '2025-01-01T00:00:00Z')`,
+	} {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("Failed to insert legacy rows: %v", err)
+		}
+	}
+
+	if _, err := db.NewMigrator(database, db.SQLite, db.Migrations, nil).Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	var expiresAt, voucherExpiresAt string
+	if err := database.QueryRow(`SELECT expires_at FROM vouchers WHERE code='OFFSET'`).Scan(&expiresAt); err != nil {
+		t.Fatalf("Failed to query voucher: %v", err)
+	}
+	if err := database.QueryRow(`SELECT voucher_expires_at FROM campaigns WHERE name='spring'`).Scan(&voucherExpiresAt); err != nil {
+		t.Fatalf("Failed to query campaign: %v", err)
+	}
+	if expiresAt != "2030-01-01T00:00:00Z" || voucherExpiresAt != "2030-01-01T10:30:00Z" {
+		t.Errorf("Expected expiries rewritten in UTC, got %s and %s", expiresAt, voucherExpiresAt)
+	}
+}
+
+func TestMigrateCanonicalRedemptionTimes(t *testing.T) {
+	ctx := context.Background()
+	database := openMigrationDB(t)
+
+	if _, err := db.NewMigrator(database, db.SQLite, db.Migrations[:14], nil).Up(ctx); err != nil {
+		t.Fatalf("Up to version 14 failed: %v", err)
+	}
+	// what datetime('now') wrote before redemptions were timestamped from Go
+	for _, stmt := range []string{
+		`INSERT INTO flights(flight_no, dep_date) VALUES('GA100', '2025-10-10T00:00:00Z')`,
+		`INSERT INTO vouchers(code, flight_id, cabin, redeemed, redeemed_at) VALUES('LEGACY', 1, 'ECONOMY', 1, '2025-10-01 12:00:00')`,
+		`INSERT INTO vouchers(code, flight_id, cabin, redeemed, redeemed_at) VALUES('CURRENT', 1, 'ECONOMY', 1, '2025-10-02T08:00:00Z')`,
+	} {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("Failed to insert legacy rows: %v", err)
+		}
+	}
+
+	clk := clock.NewFake(time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC))
+	if _, err := db.NewMigrator(database, db.SQLite, db.Migrations, clk).Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	for code, expected := range map[string]string{"LEGACY": "2025-10-01T12:00:00Z", "CURRENT": "2025-10-02T08:00:00Z"} {
+		var redeemedAt string
+		if err := database.QueryRow(`SELECT redeemed_at FROM vouchers WHERE code=?`, code).Scan(&redeemedAt); err != nil {
+			t.Fatalf("Failed to query voucher: %v", err)
+		}
+		if redeemedAt != expected {
+			t.Errorf("Expected %s redeemed at %s, got %s", code, expected, redeemedAt)
+		}
+	}
+
+	var appliedAt string
+	if err := database.QueryRow(`SELECT applied_at FROM schema_migrations WHERE version=15`).Scan(&appliedAt); err != nil {
+		t.Fatalf("Failed to query schema_migrations: %v", err)
+	}
+	if appliedAt != "2030-01-01T09:00:00Z" {
+		t.Errorf("Expected the migration applied at the clock's time, got %s", appliedAt)
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestAssignVoucherWithPreferences(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+	})
+
+	var labels []string
+	for row := 1; row <= 4; row++ {
+		for _, col := range "ABCDEF" {
+			labels = append(labels, fmt.Sprintf("%d%c", row, col))
+		}
+	}
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    labels,
+	})
+
+	tests := []struct {
+		name                string
+		preferences         map[string]any
+		expectedStatus      int
+		expectedSeats       []string
+		expectedSatisfied   []string
+		expectedUnsatisfied []string
+	}{
+		{
+			name:              "Window seat at the rear",
+			preferences:       map[string]any{"position": "WINDOW", "zone": "REAR"},
+			expectedStatus:    http.StatusCreated,
+			expectedSeats:     []string{"3A", "3F", "4A", "4F"},
+			expectedSatisfied: []string{"position", "zone"},
+		},
+		{
+			name:              "Aisle seat near 1A",
+			preferences:       map[string]any{"position": "AISLE", "near_seat": "1A"},
+			expectedStatus:    http.StatusCreated,
+			expectedSeats:     []string{"1C"},
+			expectedSatisfied: []string{"position", "near_seat"},
+		},
+		{
+			name:                "Exit row opt-in falls back when none exists",
+			preferences:         map[string]any{"exit_row": true, "position": "MIDDLE"},
+			expectedStatus:      http.StatusCreated,
+			expectedSeats:       []string{"1B", "1E", "2B", "2E", "3B", "3E", "4B", "4E"},
+			expectedSatisfied:   []string{"position"},
+			expectedUnsatisfied: []string{"exit_row"},
+		},
+		{
+			name:           "Invalid position",
+			preferences:    map[string]any{"position": "STANDING"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid near seat label",
+			preferences:    map[string]any{"near_seat": "front"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := fmt.Sprintf("PREF%d", i)
+			testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+				"code": code, "flight_id": 1, "cabin": "ECONOMY",
+			})
+
+			resp, err := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{
+				"voucher_code": code,
+				"preferences":  tt.preferences,
+			})
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			if resp.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, resp.Code, resp.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var result struct {
+				Data struct {
+					SeatLabel              string   `json:"seat_label"`
+					SatisfiedPreferences   []string `json:"satisfied_preferences"`
+					UnsatisfiedPreferences []string `json:"unsatisfied_preferences"`
+				} `json:"data"`
+			}
+			parseResponse(t, resp, &result)
+
+			if !slices.Contains(tt.expectedSeats, result.Data.SeatLabel) {
+				t.Errorf("Expected one of %v, got %s", tt.expectedSeats, result.Data.SeatLabel)
+			}
+			if !slices.Equal(result.Data.SatisfiedPreferences, tt.expectedSatisfied) {
+				t.Errorf("Expected satisfied %v, got %v", tt.expectedSatisfied, result.Data.SatisfiedPreferences)
+			}
+			if !slices.Equal(result.Data.UnsatisfiedPreferences, tt.expectedUnsatisfied) {
+				t.Errorf("Expected unsatisfied %v, got %v", tt.expectedUnsatisfied, result.Data.UnsatisfiedPreferences)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+type seatResponse struct {
+	ID           int64  `json:"id"`
+	Label        string `json:"label"`
+	Cabin        string `json:"cabin"`
+	Row          int    `json:"row"`
+	Column       string `json:"column"`
+	Position     string `json:"position"`
+	ExitRow      bool   `json:"exit_row"`
+	ExtraLegroom bool   `json:"extra_legroom"`
+	Reclining    bool   `json:"reclining"`
+	Blocked      bool   `json:"blocked"`
+}
+
+func getSeats(t *testing.T, testApp *TestApp) map[string]seatResponse {
+	resp, err := testApp.makeRequest("GET", "/api/v1/seats", nil)
+	if err != nil {
+		t.Fatalf("Failed to get seats: %v", err)
+	}
+
+	var result struct {
+		Data []seatResponse `json:"data"`
+	}
+	parseResponse(t, resp, &result)
+
+	seats := map[string]seatResponse{}
+	for _, s := range result.Data {
+		seats[s.Label] = s
+	}
+	return seats
+}
+
+func TestCreateSeatsWithAttributes(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+	})
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    []string{"12A", "12B", "12C", "12D", "12E", "12F"},
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create seats: %s", resp.Body.String())
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"seats": []map[string]any{
+			{"label": "14A", "exit_row": true, "extra_legroom": true},
+			{"label": "14F", "exit_row": true, "extra_legroom": true, "blocked": true},
+			{"label": "13A", "reclining": false, "position": "WINDOW"},
+		},
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create seats with attributes: %s", resp.Body.String())
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"seats":     []map[string]any{{"label": "15A", "position": "CORRIDOR"}},
+	})
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid position, got %d", http.StatusBadRequest, resp.Code)
+	}
+
+	seats := getSeats(t, testApp)
+
+	tests := []struct {
+		label    string
+		row      int
+		column   string
+		position string
+	}{
+		{label: "12A", row: 12, column: "A", position: "WINDOW"},
+		{label: "12B", row: 12, column: "B", position: "MIDDLE"},
+		{label: "12C", row: 12, column: "C", position: "AISLE"},
+		{label: "12F", row: 12, column: "F", position: "WINDOW"},
+	}
+	for _, tt := range tests {
+		seat := seats[tt.label]
+		if seat.Row != tt.row || seat.Column != tt.column || seat.Position != tt.position {
+			t.Errorf("Expected %s at row %d column %s %s, got %+v", tt.label, tt.row, tt.column, tt.position, seat)
+		}
+		if !seat.Reclining || seat.ExitRow || seat.Blocked {
+			t.Errorf("Expected %s to default to a reclining, unblocked seat, got %+v", tt.label, seat)
+		}
+	}
+
+	if s := seats["14A"]; !s.ExitRow || !s.ExtraLegroom || s.Blocked {
+		t.Errorf("Expected 14A to be an exit row seat with extra legroom, got %+v", s)
+	}
+	if s := seats["14F"]; !s.Blocked {
+		t.Errorf("Expected 14F to be blocked, got %+v", s)
+	}
+	if s := seats["13A"]; s.Reclining {
+		t.Errorf("Expected 13A not to recline, got %+v", s)
+	}
+}
+
+func TestAssignVoucherSkipsBlockedSeatsAndHonoursExitRow(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+	})
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    []string{"1A", "1B"},
+		"seats": []map[string]any{
+			{"label": "2A", "exit_row": true},
+			{"label": "2B", "blocked": true},
+		},
+	})
+
+	for _, code := range []string{"V1", "V2", "V3", "V4"} {
+		testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+			"code": code, "flight_id": 1, "cabin": "ECONOMY",
+		})
+	}
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{
+		"voucher_code": "V1",
+		"preferences":  map[string]any{"exit_row": true},
+	})
+	var result map[string]any
+	parseResponse(t, resp, &result)
+	if data := result["data"].(map[string]any); data["seat_label"] != "2A" {
+		t.Errorf("Expected exit row seat 2A, got %v", data["seat_label"])
+	}
+
+	for _, code := range []string{"V2", "V3"} {
+		resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": code})
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("Expected assignment of %s to succeed, got %d", code, resp.Code)
+		}
+		var result map[string]any
+		parseResponse(t, resp, &result)
+		if data := result["data"].(map[string]any); data["seat_label"] == "2B" {
+			t.Errorf("Blocked seat 2B was assigned to %s", code)
+		}
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "V4"})
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected no seats left besides the blocked one, got status %d", resp.Code)
+	}
+}
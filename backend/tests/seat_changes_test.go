@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestVoucherSeatChanges(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+		"seat_strategy":  "front_to_back",
+	})
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    []string{"1A", "1B", "1C"},
+		"seats":     []map[string]any{{"label": "1D", "blocked": true}},
+	})
+	for _, code := range []string{"V1", "V2"} {
+		testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{"code": code, "flight_id": 1, "cabin": "ECONOMY"})
+		testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": code})
+	}
+
+	// V1 sits in 1A, V2 in 1B
+	change := map[string]any{"changed_by": "agent-7", "reason": "passenger request"}
+	with := func(extra map[string]any) map[string]any {
+		body := map[string]any{}
+		for k, v := range change {
+			body[k] = v
+		}
+		for k, v := range extra {
+			body[k] = v
+		}
+		return body
+	}
+
+	var assigned struct {
+		Data models.VoucherAssigment `json:"data"`
+	}
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/V1/assignment/move", with(map[string]any{"seat_label": "1C"}))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to move seat: %s", resp.Body.String())
+	}
+	parseResponse(t, resp, &assigned)
+	if assigned.Data.SeatLabel != "1C" || assigned.Data.Strategy != "front_to_back" {
+		t.Errorf("Expected V1 moved to 1C keeping its strategy, got %+v", assigned.Data)
+	}
+
+	errorTests := []struct {
+		name     string
+		path     string
+		method   string
+		body     map[string]any
+		expected string
+	}{
+		{name: "seat taken", method: "POST", path: "/api/v1/vouchers/V1/assignment/move", body: with(map[string]any{"seat_label": "1B"}), expected: "seat not available!"},
+		{name: "seat blocked", method: "POST", path: "/api/v1/vouchers/V1/assignment/move", body: with(map[string]any{"seat_label": "1D"}), expected: "seat not available!"},
+		{name: "seat unknown", method: "POST", path: "/api/v1/vouchers/V1/assignment/move", body: with(map[string]any{"seat_label": "9Z"}), expected: "seat not found in cabin!"},
+		{name: "same seat", method: "POST", path: "/api/v1/vouchers/V1/assignment/move", body: with(map[string]any{"seat_label": "1C"}), expected: "voucher already assigned to seat!"},
+		{name: "voucher unknown", method: "DELETE", path: "/api/v1/vouchers/NOPE/assignment", body: change, expected: "voucher not found!"},
+		{name: "reason missing", method: "DELETE", path: "/api/v1/vouchers/V1/assignment", body: map[string]any{"changed_by": "agent-7"}, expected: "Reason is required"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testApp.makeRequest(tt.method, tt.path, tt.body)
+			var result map[string]any
+			parseResponse(t, resp, &result)
+			if resp.Code != http.StatusBadRequest || result["data"] != tt.expected {
+				t.Errorf("Expected %q, got %d %v", tt.expected, resp.Code, result["data"])
+			}
+		})
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/V1/assignment/reshuffle", change)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to reshuffle seat: %s", resp.Body.String())
+	}
+	parseResponse(t, resp, &assigned)
+	if assigned.Data.SeatLabel != "1A" {
+		t.Errorf("Expected V1 reshuffled to the only free seat 1A, got %s", assigned.Data.SeatLabel)
+	}
+
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/vouchers/V1/assignment", change)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to unassign seat: %s", resp.Body.String())
+	}
+
+	var result map[string]any
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/vouchers/V1/assignment", change)
+	parseResponse(t, resp, &result)
+	if result["data"] != "voucher not assigned!" {
+		t.Errorf("Expected unassigning twice to fail, got %v", result["data"])
+	}
+
+	// the voucher is redeemable again
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "V1"})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Expected unassigned voucher to be redeemable, got %s", resp.Body.String())
+	}
+
+	resp, _ = testApp.makeRequest("GET", "/api/v1/vouchers/V1/assignment/changes", nil)
+	var changes struct {
+		Data []models.SeatChange `json:"data"`
+	}
+	parseResponse(t, resp, &changes)
+
+	expected := []struct{ action, from, to string }{
+		{action: "MOVE", from: "1A", to: "1C"},
+		{action: "RESHUFFLE", from: "1C", to: "1A"},
+		{action: "UNASSIGN", from: "1A"},
+	}
+	if len(changes.Data) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), changes.Data)
+	}
+	for i, e := range expected {
+		c := changes.Data[i]
+		if c.Action != e.action || c.FromSeat != e.from || c.ToSeat != e.to || c.ChangedBy != "agent-7" || c.Reason != "passenger request" || c.ChangedAt == "" {
+			t.Errorf("Expected change %d to be %+v, got %+v", i, e, c)
+		}
+	}
+}
+
+func TestReshuffleFullCabin(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"}, "V1")
+	redeemSeats(t, testApp, "V1")
+
+	_, err := testApp.Vouchers.Reshuffle(context.Background(), &models.ChangeVoucherSeat{VoucherCode: "V1", ChangedBy: "agent-7", Reason: "passenger request"})
+	if !errors.Is(err, repository.ErrNoSeats) {
+		t.Errorf("Expected ErrNoSeats, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func setupHoldFlight(t *testing.T, testApp *TestApp, labels []string, codes ...string) {
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+	})
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    labels,
+	})
+	for _, code := range codes {
+		resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+			"code": code, "flight_id": 1, "cabin": "ECONOMY",
+		})
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("Failed to create voucher %s: %s", code, resp.Body.String())
+		}
+	}
+}
+
+func TestSeatHoldConfirm(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B"}, "V1", "V2", "V3")
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/V1/hold", map[string]any{
+		"preferences": map[string]any{"position": "WINDOW"},
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to hold seat: %s", resp.Body.String())
+	}
+
+	var held struct {
+		Data models.VoucherHold `json:"data"`
+	}
+	parseResponse(t, resp, &held)
+	if held.Data.SeatLabel == "" || held.Data.ExpiresAt == "" {
+		t.Fatalf("Expected a held seat with an expiry, got %+v", held.Data)
+	}
+
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/1/seatmap", nil)
+	var seatMap struct {
+		Data seatMapResponse `json:"data"`
+	}
+	parseResponse(t, resp, &seatMap)
+	for _, s := range seatMap.Data.Cabins[0].Rows[0].Seats {
+		if s.Label == held.Data.SeatLabel && s.Status != "HELD" {
+			t.Errorf("Expected %s to show as held, got %s", s.Label, s.Status)
+		}
+	}
+
+	// the held seat is hidden from other redeemers
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "V2"})
+	var assigned map[string]any
+	parseResponse(t, resp, &assigned)
+	if label := assigned["data"].(map[string]any)["seat_label"]; label == held.Data.SeatLabel {
+		t.Errorf("Held seat %s was assigned to another voucher", label)
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/V3/hold", nil)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected no seat left to hold, got status %d", resp.Code)
+	}
+	if _, err := testApp.Vouchers.Hold(context.Background(), &models.HoldVoucherSeat{VoucherCode: "V3", TTL: time.Minute}); !errors.Is(err, repository.ErrNoSeats) {
+		t.Errorf("Expected ErrNoSeats, got %v", err)
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/V1/hold/confirm", nil)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to confirm hold: %s", resp.Body.String())
+	}
+	parseResponse(t, resp, &assigned)
+	if label := assigned["data"].(map[string]any)["seat_label"]; label != held.Data.SeatLabel {
+		t.Errorf("Expected confirmed seat %s, got %v", held.Data.SeatLabel, label)
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/V1/hold/confirm", nil)
+	parseResponse(t, resp, &assigned)
+	if resp.Code != http.StatusBadRequest || assigned["data"] != "voucher already redeemed!" {
+		t.Errorf("Expected confirming twice to fail, got %d %v", resp.Code, assigned["data"])
+	}
+}
+
+func TestSeatHoldReleaseAndReshuffle(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B", "1C"}, "V1", "V2")
+
+	hold := func(code string) string {
+		resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/"+code+"/hold", nil)
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("Failed to hold seat for %s: %s", code, resp.Body.String())
+		}
+		var held struct {
+			Data models.VoucherHold `json:"data"`
+		}
+		parseResponse(t, resp, &held)
+		return held.Data.SeatLabel
+	}
+
+	first := hold("V1")
+	if second := hold("V1"); second == first {
+		t.Errorf("Expected holding again to reshuffle away from %s", first)
+	}
+
+	resp, _ := testApp.makeRequest("DELETE", "/api/v1/vouchers/V1/hold", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to release hold: %s", resp.Body.String())
+	}
+
+	var result map[string]any
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/vouchers/V1/hold", nil)
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != "no seat held for voucher!" {
+		t.Errorf("Expected releasing twice to fail, got %d %v", resp.Code, result["data"])
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/V1/hold/confirm", nil)
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != "no seat held for voucher!" {
+		t.Errorf("Expected confirming a released hold to fail, got %d %v", resp.Code, result["data"])
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/UNKNOWN/hold", nil)
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != "voucher not found!" {
+		t.Errorf("Expected unknown voucher to fail, got %d %v", resp.Code, result["data"])
+	}
+
+	// assigning straight away drops the voucher's own hold
+	hold("V2")
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "V2"})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to assign voucher holding a seat: %s", resp.Body.String())
+	}
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/1/seatmap", nil)
+	var seatMap struct {
+		Data seatMapResponse `json:"data"`
+	}
+	parseResponse(t, resp, &seatMap)
+	for _, s := range seatMap.Data.Cabins[0].Rows[0].Seats {
+		if s.Status == "HELD" {
+			t.Errorf("Expected no seat held after assigning, %s still is", s.Label)
+		}
+	}
+}
+
+func TestSeatHoldExpiry(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"}, "V1", "V2")
+	ctx := context.Background()
+
+	// a hold that is already past its TTL
+	if _, err := testApp.Vouchers.Hold(ctx, &models.HoldVoucherSeat{VoucherCode: "V1", TTL: -time.Second}); err != nil {
+		t.Fatalf("Failed to hold seat: %v", err)
+	}
+
+	var result map[string]any
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/V1/hold/confirm", nil)
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != "seat hold expired!" {
+		t.Errorf("Expected expired hold to fail, got %d %v", resp.Code, result["data"])
+	}
+
+	// the expired hold does not keep the seat from other redeemers
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/V2/hold", nil)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Expected the expired hold to be taken over: %s", resp.Body.String())
+	}
+	resp, _ = testApp.makeRequest("DELETE", "/api/v1/vouchers/V2/hold", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to release hold: %s", resp.Body.String())
+	}
+
+	if _, err := testApp.Vouchers.Hold(ctx, &models.HoldVoucherSeat{VoucherCode: "V1", TTL: -time.Second}); err != nil {
+		t.Fatalf("Failed to hold seat: %v", err)
+	}
+	released, err := testApp.Vouchers.ReleaseExpiredHolds(ctx, testApp.Clock.Now())
+	if err != nil {
+		t.Fatalf("Failed to release expired holds: %v", err)
+	}
+	if len(released) != 1 || released[0].VoucherCode != "V1" || released[0].SeatLabel != "1A" {
+		t.Errorf("Expected V1's hold on 1A released, got %+v", released)
+	}
+}
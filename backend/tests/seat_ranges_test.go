@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"backend/internal/seating"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		specs       []string
+		skipRows    []int
+		skipColumns []string
+		expected    []string
+		wantErr     bool
+	}{
+		{name: "plain labels", specs: []string{"1a", "2B"}, expected: []string{"1A", "2B"}},
+		{name: "rows and columns", specs: []string{"10-11:ABC"}, expected: []string{"10A", "10B", "10C", "11A", "11B", "11C"}},
+		{name: "column range", specs: []string{"5:A-C"}, expected: []string{"5A", "5B", "5C"}},
+		{name: "corner to corner", specs: []string{"1A-2B"}, expected: []string{"1A", "1B", "2A", "2B"}},
+		{name: "skip row", specs: []string{"12-14:AB"}, skipRows: []int{13}, expected: []string{"12A", "12B", "14A", "14B"}},
+		{name: "skip column", specs: []string{"1:G-K"}, skipColumns: []string{"i"}, expected: []string{"1G", "1H", "1J", "1K"}},
+		{name: "skip rules ignore plain labels", specs: []string{"13A"}, skipRows: []int{13}, expected: []string{"13A"}},
+		{name: "reversed rows", specs: []string{"25-10:ABC"}, wantErr: true},
+		{name: "reversed columns", specs: []string{"1D-4A"}, wantErr: true},
+		{name: "missing columns", specs: []string{"1-4:"}, wantErr: true},
+		{name: "repeated column", specs: []string{"1-4:AAB"}, wantErr: true},
+		{name: "too many seats", specs: []string{"1-9999:A-Z"}, wantErr: true},
+		{name: "too many rows with every column skipped", specs: []string{"1-2000000000:A"}, skipColumns: []string{"A"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := seating.ExpandLabels(tt.specs, tt.skipRows, tt.skipColumns)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", labels)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !slices.Equal(labels, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, labels)
+			}
+		})
+	}
+}
+
+func TestCreateSeatsFromRanges(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+	})
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id":    1,
+		"cabin":        "ECONOMY",
+		"labels":       []string{"10-14:ABCDEF", "20A-20C"},
+		"skip_rows":    []int{13},
+		"skip_columns": []string{"B"},
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create seats from ranges: %s", resp.Body.String())
+	}
+
+	seats := getSeats(t, testApp)
+	if len(seats) != 22 {
+		t.Errorf("Expected 22 seats, got %d", len(seats))
+	}
+	for _, label := range []string{"13A", "10B", "20B"} {
+		if _, ok := seats[label]; ok {
+			t.Errorf("Expected %s to be skipped", label)
+		}
+	}
+	if s := seats["10A"]; s.Position != "WINDOW" {
+		t.Errorf("Expected 10A to be a window seat, got %+v", s)
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    []string{"14E-15F", "15F", "16A"},
+	})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d for duplicates, got %d", http.StatusBadRequest, resp.Code)
+	}
+
+	var result map[string]any
+	parseResponse(t, resp, &result)
+	if msg, _ := result["data"].(string); msg != "duplicate seat labels: 14E, 14F, 15F" {
+		t.Errorf("Expected every duplicate listed, got %q", msg)
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    []string{"30-20:ABC"},
+	})
+	parseResponse(t, resp, &result)
+	if msg, _ := result["data"].(string); resp.Code != http.StatusBadRequest || !strings.Contains(msg, "invalid seat range") {
+		t.Errorf("Expected invalid range error, got %d %q", resp.Code, msg)
+	}
+
+	if seats := getSeats(t, testApp); len(seats) != 22 {
+		t.Errorf("Expected rejected requests to create no seats, got %d", len(seats))
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+type seatMapResponse struct {
+	FlightID int64 `json:"flight_id"`
+	Cabins   []struct {
+		Cabin   string   `json:"cabin"`
+		Columns []string `json:"columns"`
+		Rows    []struct {
+			Row   int `json:"row"`
+			Seats []struct {
+				Label       string `json:"label"`
+				Position    string `json:"position"`
+				Status      string `json:"status"`
+				VoucherCode string `json:"voucher_code"`
+			} `json:"seats"`
+		} `json:"rows"`
+	} `json:"cabins"`
+}
+
+func TestFlightSeatMap(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+	})
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    []string{"11-12:ACDF"},
+		"seats":     []map[string]any{{"label": "10C", "blocked": true}},
+	})
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "BUSINESS",
+		"labels":    []string{"1A", "1D"},
+	})
+	testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "VBIZ2025", "flight_id": 1, "cabin": "BUSINESS",
+	})
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "VBIZ2025"})
+
+	var assigned map[string]any
+	parseResponse(t, resp, &assigned)
+	assignedLabel := assigned["data"].(map[string]any)["seat_label"]
+
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/1/seatmap", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	var result struct {
+		Data seatMapResponse `json:"data"`
+	}
+	parseResponse(t, resp, &result)
+	seatMap := result.Data
+
+	if len(seatMap.Cabins) != 2 || seatMap.Cabins[0].Cabin != "BUSINESS" || seatMap.Cabins[1].Cabin != "ECONOMY" {
+		t.Fatalf("Expected BUSINESS then ECONOMY cabins, got %+v", seatMap.Cabins)
+	}
+
+	economy := seatMap.Cabins[1]
+	if len(economy.Rows) != 3 || economy.Rows[0].Row != 10 || economy.Rows[2].Row != 12 {
+		t.Errorf("Expected rows 10 to 12, got %+v", economy.Rows)
+	}
+	if got := economy.Columns; len(got) != 4 || got[0] != "A" || got[3] != "F" {
+		t.Errorf("Expected columns A C D F, got %v", got)
+	}
+	if s := economy.Rows[0].Seats[0]; s.Label != "10C" || s.Status != "BLOCKED" {
+		t.Errorf("Expected 10C to be blocked, got %+v", s)
+	}
+	if s := economy.Rows[1].Seats[0]; s.Label != "11A" || s.Status != "FREE" || s.Position != "WINDOW" {
+		t.Errorf("Expected 11A to be a free window seat, got %+v", s)
+	}
+
+	for _, s := range seatMap.Cabins[0].Rows[0].Seats {
+		if s.Label == assignedLabel {
+			if s.Status != "ASSIGNED" || s.VoucherCode != "VB****25" {
+				t.Errorf("Expected %s assigned to masked voucher, got %+v", s.Label, s)
+			}
+		} else if s.Status != "FREE" || s.VoucherCode != "" {
+			t.Errorf("Expected %s to be free, got %+v", s.Label, s)
+		}
+	}
+
+	resp, _ = testApp.makeRequest("GET", "/api/v1/flights/99/seatmap", nil)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown flight, got %d", http.StatusBadRequest, resp.Code)
+	}
+}
@@ -3,46 +3,153 @@ package tests
 import (
 	"backend/delivery/http"
 	"backend/delivery/http/handler"
+	"backend/internal/clock"
 	"backend/internal/controller"
 	"backend/internal/repository"
+	"backend/internal/repository/memory"
+	"backend/internal/throttle"
+	"backend/internal/vouchercode"
 	"backend/pkg/db"
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"io"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type TestApp struct {
-	App *fiber.App
-	DB  *sql.DB
+	App      *fiber.App
+	DB       *sql.DB
+	Driver   db.Driver
+	Vouchers repository.VouchersRepository // for what the API cannot reach, e.g. expired holds
+	Clock    *clock.Fake                   // the time of every repository and controller, moved by hand
+
+	VouchersController controller.VouchersController // for the scheduler
 }
 
-func setupTestApp(t *testing.T) *TestApp {
-	database, err := sql.Open("sqlite3", ":memory:")
+// memoryDriver runs the suite against the pure-Go in-memory repositories.
+const memoryDriver = "memory"
+
+// testDriver is the storage picked by TEST_DB_DRIVER, sqlite by default.
+func testDriver() string {
+	if name := os.Getenv("TEST_DB_DRIVER"); name != "" {
+		return name
+	}
+	return string(db.SQLite)
+}
+
+// openTestDatabase returns a fresh, migrated database for the driver. PostgreSQL
+// runs need TEST_DATABASE_URL pointing at a disposable database, e.g. a local
+// container.
+func openTestDatabase(t *testing.T, name string, clk clock.Clock) (*sql.DB, db.Driver) {
+	driver, err := db.ParseDriver(name)
 	if err != nil {
-		t.Fatalf("Failed to create test database: %v", err)
+		t.Fatalf("Invalid TEST_DB_DRIVER: %v", err)
+	}
+
+	var database *sql.DB
+	switch driver {
+	case db.Postgres:
+		dsn := os.Getenv("TEST_DATABASE_URL")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+
+		database, err = sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatalf("Failed to create test database: %v", err)
+		}
+
+		if _, err := database.Exec(`DROP SCHEMA public CASCADE; CREATE SCHEMA public;`); err != nil {
+			t.Fatalf("Failed to reset test database: %v", err)
+		}
+	default:
+		database, err = sql.Open("sqlite3", db.SQLiteDSN(":memory:"))
+		if err != nil {
+			t.Fatalf("Failed to create test database: %v", err)
+		}
 	}
 
-	if _, err := database.Exec(db.SCHEMA); err != nil {
-		t.Fatalf("Failed to initialize schema: %v", err)
+	if _, err := db.NewMigrator(database, driver, driver.Migrations(), clk).Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate schema: %v", err)
 	}
 
-	flightsRepo := repository.NewFlightsRepository(database)
-	seatsRepo := repository.NewSeatRepository(database)
-	vouchersRepo := repository.NewVouchersRepository(database)
+	return database, driver
+}
+
+// appOptions turns on optional voucher features, the zero value leaves them off.
+type appOptions struct {
+	clock   *clock.Fake // stopped before the flights the tests create by default
+	signer  *vouchercode.Signer
+	limiter func(tr repository.ThrottleRepository) *throttle.Limiter // given the driver's throttle repository
+}
+
+func setupTestApp(t *testing.T) *TestApp {
+	return setupTestAppWith(t, appOptions{})
+}
+
+func setupTestAppWith(t *testing.T, opts appOptions) *TestApp {
+	var (
+		database      *sql.DB
+		driver        db.Driver
+		flightsRepo   repository.FlightsRepository
+		seatsRepo     repository.SeatRepository
+		vouchersRepo  repository.VouchersRepository
+		campaignsRepo repository.CampaignsRepository
+		throttleRepo  repository.ThrottleRepository
+	)
+
+	clk := opts.clock
+	if clk == nil {
+		// flights depart in late 2025, the wall clock has left them behind
+		clk = clock.NewFake(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+	}
+
+	switch name := testDriver(); name {
+	case memoryDriver:
+		store := memory.NewStore(clk)
+		flightsRepo = memory.NewFlightsRepository(store)
+		seatsRepo = memory.NewSeatRepository(store)
+		vouchersRepo = memory.NewVouchersRepository(store)
+		campaignsRepo = memory.NewCampaignsRepository(store)
+		throttleRepo = memory.NewThrottleRepository()
+	default:
+		database, driver = openTestDatabase(t, name, clk)
+		flightsRepo = repository.NewFlightsRepository(database)
+		seatsRepo = repository.NewSeatRepository(database, clk)
+		vouchersRepo = repository.NewVouchersRepository(database, clk)
+		campaignsRepo = repository.NewCampaignsRepository(database, clk)
+		throttleRepo = repository.NewThrottleRepository(database)
+		if driver == db.Postgres {
+			flightsRepo = repository.NewPostgresFlightsRepository(database)
+			seatsRepo = repository.NewPostgresSeatRepository(database, clk)
+			vouchersRepo = repository.NewPostgresVouchersRepository(database, clk)
+			campaignsRepo = repository.NewPostgresCampaignsRepository(database, clk)
+			throttleRepo = repository.NewPostgresThrottleRepository(database)
+		}
+	}
 
 	flightsController := controller.NewFlightsController(flightsRepo)
 	seatsController := controller.NewSeatController(seatsRepo)
-	vouchersController := controller.NewVouchersController(vouchersRepo)
+	var limiter *throttle.Limiter
+	if opts.limiter != nil {
+		limiter = opts.limiter(throttleRepo)
+	}
+	vouchersController := controller.NewVouchersController(vouchersRepo, time.Minute, opts.signer, limiter, clk)
+	campaignsController := controller.NewCampaignsController(campaignsRepo)
 
 	flightsHandler := handler.NewFlightsHandler(flightsController)
 	seatsHandler := handler.NewSeatsHandler(seatsController)
 	vouchersHandler := handler.NewVouchersHandler(vouchersController)
+	campaignsHandler := handler.NewCampaignsHandler(campaignsController)
 
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -52,11 +159,16 @@ func setupTestApp(t *testing.T) *TestApp {
 		},
 	})
 
-	http.Routes(app, flightsHandler, seatsHandler, vouchersHandler)
+	http.Routes(app, flightsHandler, seatsHandler, vouchersHandler, campaignsHandler)
 
 	return &TestApp{
-		App: app,
-		DB:  database,
+		App:      app,
+		DB:       database,
+		Driver:   driver,
+		Vouchers: vouchersRepo,
+		Clock:    clk,
+
+		VouchersController: vouchersController,
 	}
 }
 
@@ -66,6 +178,18 @@ func (ta *TestApp) cleanup() {
 	}
 }
 
+// requireDB skips tests that inspect tables directly when running on memory storage.
+func (ta *TestApp) requireDB(t *testing.T) {
+	if ta.DB == nil {
+		t.Skip("test inspects the SQL database directly")
+	}
+}
+
+// queryRow runs a `?` placeholder query against whichever driver the test uses.
+func (ta *TestApp) queryRow(query string, args ...any) *sql.Row {
+	return ta.DB.QueryRow(ta.Driver.Rebind(query), args...)
+}
+
 func (ta *TestApp) makeRequest(method, path string, body any) (*httptest.ResponseRecorder, error) {
 	var reqBody io.Reader
 	if body != nil {
@@ -85,6 +209,9 @@ func (ta *TestApp) makeRequest(method, path string, body any) (*httptest.Respons
 	}
 
 	recorder := httptest.NewRecorder()
+	for k, v := range resp.Header {
+		recorder.Header()[k] = v
+	}
 	recorder.WriteHeader(resp.StatusCode)
 	io.Copy(recorder, resp.Body)
 	resp.Body.Close()
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/vouchercode"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSigner(t *testing.T, keys map[string]string, activeID string) *vouchercode.Signer {
+	signer, err := vouchercode.NewSigner(keys, activeID)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func TestSignedCodeRoundTrip(t *testing.T) {
+	signer := newTestSigner(t, map[string]string{"K1": "0123456789abcdef"}, "K1")
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	code, err := signer.Sign(vouchercode.Claims{FlightID: 42, Cabin: "BUSINESS", ExpiresAt: expiresAt})
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if !strings.HasPrefix(code, "SV1-K1-") {
+		t.Fatalf("Unexpected signed code %s", code)
+	}
+
+	claims, err := signer.Verify(code)
+	if err != nil {
+		t.Fatalf("Failed to verify %s: %v", code, err)
+	}
+	if claims.FlightID != 42 || claims.Cabin != "BUSINESS" || !claims.ExpiresAt.Equal(expiresAt) || claims.Serial == 0 {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	// altering any character breaks the signature
+	for i := len("SV1-K1-"); i < len(code); i++ {
+		if code[i] == '-' {
+			continue
+		}
+		altered := []byte(code)
+		if altered[i] == 'A' {
+			altered[i] = 'B'
+		} else {
+			altered[i] = 'A'
+		}
+		if _, err := signer.Verify(string(altered)); err != vouchercode.ErrForged {
+			t.Fatalf("Expected altered code %s to be rejected, got %v", altered, err)
+		}
+	}
+
+	other := newTestSigner(t, map[string]string{"K1": "fedcba9876543210"}, "K1")
+	if _, err := other.Verify(code); err != vouchercode.ErrForged {
+		t.Errorf("Expected code signed with another key to be rejected, got %v", err)
+	}
+}
+
+func TestSignedCodeKeyRotation(t *testing.T) {
+	old := newTestSigner(t, map[string]string{"K1": "0123456789abcdef"}, "K1")
+	oldCode, _ := old.Sign(vouchercode.Claims{FlightID: 1, Cabin: "ECONOMY"})
+
+	rotated := newTestSigner(t, map[string]string{"K1": "0123456789abcdef", "K2": "another-secret-key"}, "K2")
+	newCode, _ := rotated.Sign(vouchercode.Claims{FlightID: 1, Cabin: "ECONOMY"})
+	if !strings.HasPrefix(newCode, "SV1-K2-") {
+		t.Fatalf("Expected new codes to use the active key, got %s", newCode)
+	}
+	if _, err := rotated.Verify(oldCode); err != nil {
+		t.Errorf("Expected code of the previous key to verify, got %v", err)
+	}
+
+	retired := newTestSigner(t, map[string]string{"K2": "another-secret-key"}, "K2")
+	if _, err := retired.Verify(oldCode); err != vouchercode.ErrForged {
+		t.Errorf("Expected code of a retired key to be rejected, got %v", err)
+	}
+	if _, err := retired.Verify(newCode); err != nil {
+		t.Errorf("Expected code of the active key to verify, got %v", err)
+	}
+}
+
+func TestNewSignerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string]string
+		activeID string
+	}{
+		{"no keys", map[string]string{}, ""},
+		{"short key", map[string]string{"K1": "short"}, "K1"},
+		{"bad key id", map[string]string{"K-1": "0123456789abcdef"}, "K-1"},
+		{"unknown active key", map[string]string{"K1": "0123456789abcdef"}, "K2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := vouchercode.NewSigner(tt.keys, tt.activeID); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestSignedVoucherRedemption(t *testing.T) {
+	signer := newTestSigner(t, map[string]string{"K1": "0123456789abcdef"}, "K1")
+	testApp := setupTestAppWith(t, appOptions{signer: signer})
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B"}, "PLAIN1")
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/batch", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"count":     2,
+		"signed":    true,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create signed batch: %s", resp.Body.String())
+	}
+	var batch struct {
+		Data models.VoucherBatch `json:"data"`
+	}
+	parseResponse(t, resp, &batch)
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": batch.Data.Codes[0]})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to redeem signed voucher: %s", resp.Body.String())
+	}
+
+	// plain codes still work unless signing is required
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "PLAIN1"})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to redeem plain voucher: %s", resp.Body.String())
+	}
+
+	forged, _ := newTestSigner(t, map[string]string{"K1": "guessed-secret-key"}, "K1").
+		Sign(vouchercode.Claims{FlightID: 1, Cabin: "ECONOMY"})
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": forged})
+	var result map[string]any
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != vouchercode.ErrForged.Error() {
+		t.Errorf("Expected forged code to be rejected, got %d %v", resp.Code, result["data"])
+	}
+
+	// a signed code can't be stored for another cabin than it was signed for
+	code, _ := signer.Sign(vouchercode.Claims{FlightID: 1, Cabin: "FIRST"})
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{"code": code, "flight_id": 1, "cabin": "ECONOMY"})
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != vouchercode.ErrSignedClaim.Error() {
+		t.Errorf("Expected mismatched signed code to be rejected, got %d %v", resp.Code, result["data"])
+	}
+}
+
+func TestSignedOnlyVouchers(t *testing.T) {
+	signer := newTestSigner(t, map[string]string{"K1": "0123456789abcdef"}, "K1")
+	signer.RequireSigned = true
+	testApp := setupTestAppWith(t, appOptions{signer: signer})
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"})
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": "GUESS123"})
+	var result map[string]any
+	parseResponse(t, resp, &result)
+	if resp.Code != http.StatusBadRequest || result["data"] != vouchercode.ErrNotSigned.Error() {
+		t.Errorf("Expected plain code to be rejected, got %d %v", resp.Code, result["data"])
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/batch", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "count": 1})
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected plain batch to be rejected, got %d", resp.Code)
+	}
+}
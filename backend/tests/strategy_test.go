@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/seating"
+	"net/http"
+	"testing"
+)
+
+func seatsFromLabels(labels ...string) models.Seats {
+	var seats models.Seats
+	for i, l := range labels {
+		seats = append(seats, models.Seat{ID: int64(i + 1), FlightID: 1, Label: l, Cabin: "ECONOMY"})
+	}
+	return seats
+}
+
+func TestSeatAssignmentStrategies(t *testing.T) {
+	// rows 1-3 of a 3-3 cabin, row 2 partly assigned
+	free := seatsFromLabels("1A", "1B", "1C", "1D", "1E", "1F", "2A", "2B", "3A", "3B", "3C", "3D", "3E", "3F")
+	assigned := models.Seats{
+		{ID: 20, Label: "2C"}, {ID: 21, Label: "2D"}, {ID: 22, Label: "2E"}, {ID: 23, Label: "2F"},
+	}
+	pool := seating.SeatPool{Free: free, Assigned: assigned}
+
+	tests := []struct {
+		strategy string
+		expected string
+	}{
+		{strategy: seating.StrategyFrontToBack, expected: "1A"},
+		{strategy: seating.StrategyBackToFront, expected: "3A"},
+		{strategy: seating.StrategyWindowFirst, expected: "1A"},
+		{strategy: seating.StrategyAisleFirst, expected: "1C"},
+		{strategy: seating.StrategyFillBalanced, expected: "1A"},
+		{strategy: seating.StrategyCluster, expected: "2B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			strategy, err := seating.Lookup(tt.strategy)
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+
+			ranked := strategy.Rank(pool)
+			if len(ranked) != len(free) {
+				t.Fatalf("Expected %d ranked seats, got %d", len(free), len(ranked))
+			}
+			if ranked[0].Label != tt.expected {
+				t.Errorf("Expected %s first, got %s", tt.expected, ranked[0].Label)
+			}
+		})
+	}
+
+	t.Run("fill_balanced prefers emptier rows", func(t *testing.T) {
+		strategy, _ := seating.Lookup(seating.StrategyFillBalanced)
+		ranked := strategy.Rank(pool)
+		for _, seat := range ranked[:len(ranked)-2] {
+			if seat.Label == "2A" || seat.Label == "2B" {
+				t.Errorf("Expected row 2 seats ranked last, got %s early", seat.Label)
+			}
+		}
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		if _, err := seating.Lookup("alphabetical"); err == nil {
+			t.Error("Expected error for unknown strategy")
+		}
+	})
+
+	t.Run("resolve falls back to flight then default", func(t *testing.T) {
+		s, _ := seating.Resolve("", seating.StrategyCluster)
+		if s.Name() != seating.StrategyCluster {
+			t.Errorf("Expected flight strategy, got %s", s.Name())
+		}
+		s, _ = seating.Resolve("", "")
+		if s.Name() != seating.DefaultStrategy {
+			t.Errorf("Expected default strategy, got %s", s.Name())
+		}
+	})
+}
+
+func TestAssignVoucherWithStrategy(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA100"},
+		"dep_date":       "2025-10-10",
+		"seat_strategy":  "front_to_back",
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create flight: %s", resp.Body.String())
+	}
+
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{
+		"flight_id": 1,
+		"cabin":     "ECONOMY",
+		"labels":    []string{"1A", "1B", "2A", "2B", "3A", "3B"},
+	})
+
+	testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "FLIGHT_DEFAULT", "flight_id": 1, "cabin": "ECONOMY",
+	})
+	testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "VOUCHER_OVERRIDE", "flight_id": 1, "cabin": "ECONOMY", "seat_strategy": "back_to_front",
+	})
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "INVALID_STRATEGY", "flight_id": 1, "cabin": "ECONOMY", "seat_strategy": "alphabetical",
+	})
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown strategy, got %d", http.StatusBadRequest, resp.Code)
+	}
+
+	tests := []struct {
+		code             string
+		expectedLabel    string
+		expectedStrategy string
+	}{
+		{code: "FLIGHT_DEFAULT", expectedLabel: "1A", expectedStrategy: "front_to_back"},
+		{code: "VOUCHER_OVERRIDE", expectedLabel: "3A", expectedStrategy: "back_to_front"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			resp, err := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": tt.code})
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			if resp.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, resp.Code, resp.Body.String())
+			}
+
+			var result map[string]any
+			parseResponse(t, resp, &result)
+			data := result["data"].(map[string]any)
+
+			if data["seat_label"] != tt.expectedLabel {
+				t.Errorf("Expected seat %s, got %v", tt.expectedLabel, data["seat_label"])
+			}
+			if data["strategy"] != tt.expectedStrategy {
+				t.Errorf("Expected strategy %s, got %v", tt.expectedStrategy, data["strategy"])
+			}
+
+			if testApp.DB != nil {
+				var recorded string
+				err := testApp.queryRow(`SELECT sa.strategy FROM seat_assignments sa JOIN vouchers v ON v.id = sa.voucher_id WHERE v.code=?`, tt.code).Scan(&recorded)
+				if err != nil {
+					t.Fatalf("Failed to query assignment: %v", err)
+				}
+				if recorded != tt.expectedStrategy {
+					t.Errorf("Expected recorded strategy %s, got %s", tt.expectedStrategy, recorded)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"backend/internal/clock"
+	"backend/internal/repository"
+	"backend/internal/throttle"
+	"backend/pkg/db"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func setupThrottledApp(t *testing.T, cfg throttle.Config, clk *clock.Fake) *TestApp {
+	testApp := setupTestAppWith(t, appOptions{
+		clock: clk,
+		limiter: func(tr repository.ThrottleRepository) *throttle.Limiter {
+			return throttle.New(cfg, tr, clk)
+		},
+	})
+	setupHoldFlight(t, testApp, []string{"1A", "1B"}, "VALID1", "VALID2")
+	return testApp
+}
+
+func redeem(testApp *TestApp, code string) (int, string) {
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": code})
+	return resp.Code, resp.Header().Get("Retry-After")
+}
+
+func TestRedeemIPRateLimit(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	testApp := setupThrottledApp(t, throttle.Config{Window: time.Minute, IPLimit: 3, LockoutMax: time.Hour}, clk)
+	defer testApp.cleanup()
+
+	for i := 0; i < 3; i++ {
+		if code, _ := redeem(testApp, "NOPE"); code != http.StatusBadRequest {
+			t.Fatalf("Attempt %d: expected status 400, got %d", i+1, code)
+		}
+	}
+
+	clk.Advance(20 * time.Second)
+	code, retryAfter := redeem(testApp, "VALID1")
+	if code != http.StatusTooManyRequests || retryAfter != "40" {
+		t.Fatalf("Expected 429 with Retry-After 40, got %d %q", code, retryAfter)
+	}
+
+	clk.Advance(41 * time.Second)
+	if code, _ := redeem(testApp, "VALID1"); code != http.StatusCreated {
+		t.Errorf("Expected a new window to allow the attempt, got %d", code)
+	}
+}
+
+func TestRedeemPrefixRateLimit(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	testApp := setupThrottledApp(t, throttle.Config{Window: time.Minute, PrefixLimit: 2, PrefixLength: 4, LockoutMax: time.Hour}, clk)
+	defer testApp.cleanup()
+
+	redeem(testApp, "GUESS1")
+	redeem(testApp, "GUESS2")
+	if code, _ := redeem(testApp, "GUESS3"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the prefix to be limited, got %d", code)
+	}
+
+	if code, _ := redeem(testApp, "VALID1"); code != http.StatusCreated {
+		t.Errorf("Expected other prefixes to be allowed, got %d", code)
+	}
+}
+
+func TestRedeemProgressiveLockout(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	testApp := setupThrottledApp(t, throttle.Config{
+		Window:          time.Hour,
+		LockoutFailures: 3,
+		LockoutDuration: time.Minute,
+		LockoutMax:      time.Hour,
+	}, clk)
+	defer testApp.cleanup()
+
+	for _, want := range []string{"60", "120", "240"} {
+		for i := 0; i < 3; i++ {
+			if code, _ := redeem(testApp, "NOPE"); code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400 before the lockout, got %d", code)
+			}
+		}
+
+		code, retryAfter := redeem(testApp, "VALID1")
+		if code != http.StatusTooManyRequests || retryAfter != want {
+			t.Fatalf("Expected 429 with Retry-After %s, got %d %q", want, code, retryAfter)
+		}
+
+		clk.Advance(4 * time.Minute)
+	}
+
+	// a success clears the failures, the next unknown code starts over
+	if code, _ := redeem(testApp, "VALID1"); code != http.StatusCreated {
+		t.Fatalf("Expected the lockout to be over, got %d", code)
+	}
+	redeem(testApp, "NOPE")
+	redeem(testApp, "NOPE")
+	if code, _ := redeem(testApp, "VALID2"); code != http.StatusCreated {
+		t.Errorf("Expected no lockout after a success, got %d", code)
+	}
+}
+
+func TestRedeemThrottleSharedState(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+	testApp.requireDB(t)
+
+	tr := repository.NewThrottleRepository(testApp.DB)
+	if testApp.Driver == db.Postgres {
+		tr = repository.NewPostgresThrottleRepository(testApp.DB)
+	}
+
+	clk := clock.NewFake(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	cfg := throttle.Config{Window: time.Minute, IPLimit: 5, LockoutFailures: 2, LockoutDuration: time.Minute, LockoutMax: time.Hour}
+	first := throttle.New(cfg, tr, clk)
+	second := throttle.New(cfg, tr, clk)
+
+	ctx := context.Background()
+	first.Fail(ctx, "10.0.0.1")
+	second.Fail(ctx, "10.0.0.1")
+
+	var limited *throttle.LimitedError
+	if err := first.Allow(ctx, "10.0.0.1", "ANY"); !errors.As(err, &limited) || limited.RetryAfter != time.Minute {
+		t.Fatalf("Expected the instances to share the lockout, got %v", err)
+	}
+	if err := second.Allow(ctx, "10.0.0.2", "ANY"); err != nil {
+		t.Errorf("Expected another IP to be allowed, got %v", err)
+	}
+
+	clk.Advance(2 * time.Hour)
+	pruned, err := first.Prune(ctx)
+	if err != nil || pruned != 2 {
+		t.Errorf("Expected both IPs to be pruned, got %d %v", pruned, err)
+	}
+}
+
+// byCodeEndpoints act on a voucher named only by its code, each is a way to
+// guess codes unless throttled.
+var byCodeEndpoints = []struct {
+	name   string
+	method string
+	path   string
+	body   map[string]any
+}{
+	{name: "confirm hold", method: "POST", path: "/hold/confirm"},
+	{name: "release hold", method: "DELETE", path: "/hold"},
+	{name: "unassign", method: "DELETE", path: "/assignment", body: map[string]any{"changed_by": "agent-7", "reason": "desk"}},
+	{name: "move", method: "POST", path: "/assignment/move", body: map[string]any{"seat_label": "1B", "changed_by": "agent-7", "reason": "desk"}},
+	{name: "reshuffle", method: "POST", path: "/assignment/reshuffle", body: map[string]any{"changed_by": "agent-7", "reason": "desk"}},
+	{name: "seat changes", method: "GET", path: "/assignment/changes"},
+	{name: "waitlist", method: "GET", path: "/waitlist"},
+	{name: "revoke", method: "POST", path: "/revoke", body: map[string]any{"changed_by": "agent-7", "reason": "fraud"}},
+	{name: "change status", method: "POST", path: "/status", body: map[string]any{"status": "CANCELLED", "changed_by": "agent-7", "reason": "desk"}},
+	{name: "status changes", method: "GET", path: "/status/changes"},
+}
+
+func TestByCodeEndpointsThrottled(t *testing.T) {
+	for _, tt := range byCodeEndpoints {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+			testApp := setupThrottledApp(t, throttle.Config{
+				Window:          time.Hour,
+				LockoutFailures: 3,
+				LockoutDuration: time.Minute,
+				LockoutMax:      time.Hour,
+			}, clk)
+			defer testApp.cleanup()
+
+			for i := 0; i < 3; i++ {
+				resp, _ := testApp.makeRequest(tt.method, "/api/v1/vouchers/NOPE"+tt.path, tt.body)
+				if resp.Code != http.StatusBadRequest {
+					t.Fatalf("Expected status 400 before the lockout, got %d %s", resp.Code, resp.Body.String())
+				}
+			}
+
+			resp, _ := testApp.makeRequest(tt.method, "/api/v1/vouchers/VALID1"+tt.path, tt.body)
+			if resp.Code != http.StatusTooManyRequests || resp.Header().Get("Retry-After") != "60" {
+				t.Errorf("Expected 429 with Retry-After 60, got %d %q", resp.Code, resp.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"backend/internal/vouchercode"
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVoucherCodeGenerate(t *testing.T) {
+	pattern, err := vouchercode.Normalize(models.VoucherCodePattern{Prefix: "ga-", CheckDigit: true})
+	if err != nil {
+		t.Fatalf("Failed to normalize pattern: %v", err)
+	}
+	if pattern.Prefix != "GA-" || pattern.Length != vouchercode.DefaultLength || pattern.Alphabet != vouchercode.DefaultAlphabet {
+		t.Fatalf("Expected defaults to be filled in, got %+v", pattern)
+	}
+
+	for i := 0; i < 100; i++ {
+		code, err := vouchercode.Generate(pattern)
+		if err != nil {
+			t.Fatalf("Failed to generate code: %v", err)
+		}
+		if len(code) != len("GA-")+vouchercode.DefaultLength+1 {
+			t.Fatalf("Unexpected code length: %s", code)
+		}
+		if strings.ContainsAny(code[3:], "01OI") {
+			t.Fatalf("Code %s has ambiguous characters", code)
+		}
+		if !vouchercode.Verify(code, pattern) {
+			t.Fatalf("Generated code %s does not verify", code)
+		}
+
+		// a single typo is caught by the check character
+		typo := []byte(code)
+		if typo[4] == 'A' {
+			typo[4] = 'B'
+		} else {
+			typo[4] = 'A'
+		}
+		if vouchercode.Verify(string(typo), pattern) {
+			t.Fatalf("Typo %s of %s passed verification", typo, code)
+		}
+	}
+}
+
+func TestVoucherCodeNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern models.VoucherCodePattern
+	}{
+		{"too short", models.VoucherCodePattern{Length: 3}},
+		{"too long", models.VoucherCodePattern{Length: 33}},
+		{"bad prefix", models.VoucherCodePattern{Prefix: "GA_"}},
+		{"repeated alphabet", models.VoucherCodePattern{Alphabet: "AAB"}},
+		{"single character alphabet", models.VoucherCodePattern{Alphabet: "A"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := vouchercode.Normalize(tt.pattern); err == nil {
+				t.Errorf("Expected %+v to be rejected", tt.pattern)
+			}
+		})
+	}
+}
+
+func TestCreateVoucherBatch(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B", "1C"})
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/batch", map[string]any{
+		"flight_id":   1,
+		"cabin":       "ECONOMY",
+		"count":       50,
+		"prefix":      "GA",
+		"length":      6,
+		"check_digit": true,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create batch: %s", resp.Body.String())
+	}
+
+	var batch struct {
+		Data models.VoucherBatch `json:"data"`
+	}
+	parseResponse(t, resp, &batch)
+	if len(batch.Data.Codes) != 50 {
+		t.Fatalf("Expected 50 codes, got %d", len(batch.Data.Codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range batch.Data.Codes {
+		if seen[code] {
+			t.Errorf("Code %s generated twice", code)
+		}
+		seen[code] = true
+		if !strings.HasPrefix(code, "GA") || len(code) != 9 {
+			t.Errorf("Code %s does not match the pattern", code)
+		}
+	}
+
+	resp, _ = testApp.makeRequest("GET", "/api/v1/vouchers", nil)
+	var vouchers map[string]any
+	parseResponse(t, resp, &vouchers)
+	if n := len(vouchers["data"].([]any)); n != 50 {
+		t.Errorf("Expected 50 vouchers stored, got %d", n)
+	}
+
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": batch.Data.Codes[0]})
+	if resp.Code != http.StatusCreated {
+		t.Errorf("Failed to redeem generated voucher: %s", resp.Body.String())
+	}
+}
+
+func TestCreateVoucherBatchCSV(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"})
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/batch", map[string]any{
+		"flight_id":  1,
+		"cabin":      "ECONOMY",
+		"count":      3,
+		"expires_at": "2030-01-01T00:00:00Z",
+		"format":     "csv",
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create batch: %s", resp.Body.String())
+	}
+	if ct := resp.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Expected a CSV response, got %s", ct)
+	}
+	if cd := resp.Header().Get("Content-Disposition"); !strings.Contains(cd, "attachment") {
+		t.Errorf("Expected an attachment, got %q", cd)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected a header and 3 vouchers, got %d lines", len(records))
+	}
+	if strings.Join(records[0], ",") != "code,flight_id,cabin,expires_at" {
+		t.Errorf("Unexpected header %v", records[0])
+	}
+	for _, r := range records[1:] {
+		if r[1] != "1" || r[2] != "ECONOMY" || r[3] != "2030-01-01T00:00:00Z" {
+			t.Errorf("Unexpected row %v", r)
+		}
+	}
+}
+
+func TestCreateVoucherBatchErrors(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"})
+
+	tests := []struct {
+		name string
+		body map[string]any
+	}{
+		{"no count", map[string]any{"flight_id": 1, "cabin": "ECONOMY"}},
+		{"too many", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "count": 10001}},
+		{"no seats in cabin", map[string]any{"flight_id": 1, "cabin": "FIRST", "count": 1}},
+		{"bad alphabet", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "count": 1, "alphabet": "A"}},
+		{"bad format", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "count": 1, "format": "xml"}},
+		// 2^4 codes can't fit 20 vouchers
+		{"code space exhausted", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "count": 20, "length": 4, "alphabet": "AB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/batch", tt.body)
+			if resp.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", resp.Code, resp.Body.String())
+			}
+		})
+	}
+
+	// nothing is left behind by the failed batches
+	resp, _ := testApp.makeRequest("GET", "/api/v1/vouchers", nil)
+	var vouchers map[string]any
+	parseResponse(t, resp, &vouchers)
+	if data, _ := vouchers["data"].([]any); len(data) != 0 {
+		t.Errorf("Expected no vouchers, got %d", len(data))
+	}
+}
@@ -1,13 +1,19 @@
 package tests
 
 import (
+	"backend/internal/controller"
+	"backend/internal/models"
+	"backend/internal/scheduler"
+	"context"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestVoucherExpiresAtDatabase(t *testing.T) {
 	testApp := setupTestApp(t)
 	defer testApp.cleanup()
+	testApp.requireDB(t)
 
 	flightBody := map[string]any{
 		"flight_numbers": []string{"GA100"},
@@ -43,7 +49,7 @@ func TestVoucherExpiresAtDatabase(t *testing.T) {
 		}
 
 		var expiresAt *string
-		err = testApp.DB.QueryRow("SELECT expires_at FROM vouchers WHERE code = ?", "VOUCHER_NO_EXPIRY").Scan(&expiresAt)
+		err = testApp.queryRow("SELECT expires_at FROM vouchers WHERE code = ?", "VOUCHER_NO_EXPIRY").Scan(&expiresAt)
 		if err != nil {
 			t.Fatalf("Failed to query database: %v", err)
 		}
@@ -69,7 +75,7 @@ func TestVoucherExpiresAtDatabase(t *testing.T) {
 		}
 
 		var expiresAt *string
-		err = testApp.DB.QueryRow("SELECT expires_at FROM vouchers WHERE code = ?", "VOUCHER_WITH_EXPIRY").Scan(&expiresAt)
+		err = testApp.queryRow("SELECT expires_at FROM vouchers WHERE code = ?", "VOUCHER_WITH_EXPIRY").Scan(&expiresAt)
 		if err != nil {
 			t.Fatalf("Failed to query database: %v", err)
 		}
@@ -81,3 +87,247 @@ func TestVoucherExpiresAtDatabase(t *testing.T) {
 		}
 	})
 }
+
+func TestExpiryScheduler(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B"}, "NEVER")
+	for _, v := range []map[string]any{
+		{"code": "SOON", "flight_id": 1, "cabin": "ECONOMY", "expires_at": "2030-01-01T00:00:00Z"},
+		{"code": "DORMANT", "flight_id": 1, "cabin": "ECONOMY", "expires_at": "2030-01-01T07:00:00+07:00", "status": models.VoucherIssued},
+		{"code": "LATER", "flight_id": 1, "cabin": "ECONOMY", "expires_at": "2031-01-01T00:00:00Z"},
+	} {
+		if resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", v); resp.Code != http.StatusCreated {
+			t.Fatalf("Failed to create voucher %v: %s", v["code"], resp.Body.String())
+		}
+	}
+
+	// a hold already past its TTL
+	ctx := context.Background()
+	if _, err := testApp.Vouchers.Hold(ctx, &models.HoldVoucherSeat{VoucherCode: "NEVER", TTL: -time.Second}); err != nil {
+		t.Fatalf("Failed to hold seat: %v", err)
+	}
+
+	testApp.Clock.Set(time.Date(2029, 12, 31, 0, 0, 0, 0, time.UTC))
+	var emitted []scheduler.Event
+	s := scheduler.New(testApp.VouchersController, time.Minute, testApp.Clock, func(e scheduler.Event) { emitted = append(emitted, e) })
+
+	events, err := s.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("Failed to run the scheduler: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != scheduler.EventHoldReleased || events[0].VoucherCode != "NEVER" || events[0].SeatLabel == "" {
+		t.Errorf("Expected only NEVER's hold released, got %+v", events)
+	}
+
+	testApp.Clock.Set(time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC))
+	events, err = s.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("Failed to run the scheduler: %v", err)
+	}
+	var expired []string
+	for _, e := range events {
+		if e.Type != scheduler.EventVoucherExpired || !e.At.Equal(testApp.Clock.Now()) {
+			t.Errorf("Unexpected event %+v", e)
+		}
+		expired = append(expired, e.VoucherCode)
+	}
+	if len(expired) != 2 || expired[0] != "SOON" || expired[1] != "DORMANT" {
+		t.Errorf("Expected SOON and DORMANT to expire, got %v", expired)
+	}
+	if len(emitted) != 3 {
+		t.Errorf("Expected every event emitted, got %+v", emitted)
+	}
+
+	// expiry sticks, even once the clock is turned back
+	testApp.Clock.Set(time.Date(2029, 12, 31, 0, 0, 0, 0, time.UTC))
+	if _, msg := redeemSeats(t, testApp, "SOON"); msg != "voucher expired!" {
+		t.Errorf("Expected SOON to be expired, got %q", msg)
+	}
+	if status, _ := lookupVoucher(t, testApp, "LATER"); status == nil || status.Status != models.VoucherActive {
+		t.Errorf("Expected LATER to stay active, got %+v", status)
+	}
+
+	changes, err := testApp.VouchersController.GetStatusChanges(ctx, &models.AssignsRandomVoucher{VoucherCode: "DORMANT"})
+	if err != nil || len(changes) != 1 || changes[0].FromStatus != models.VoucherIssued || changes[0].ToStatus != models.VoucherExpired || changes[0].ChangedBy != controller.ExpirySweeper {
+		t.Errorf("Expected the expiry in DORMANT's history, got %+v %v", changes, err)
+	}
+
+	if events, err := s.RunOnce(ctx); err != nil || len(events) != 0 {
+		t.Errorf("Expected nothing left to do, got %+v %v", events, err)
+	}
+}
+
+func TestFakeClockTimestamps(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.Clock.Set(time.Date(2025, 10, 1, 10, 0, 0, 0, time.UTC))
+	setupHoldFlight(t, testApp, []string{"1A"}, "V1", "V2")
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "HALFHOUR", "flight_id": 1, "cabin": "ECONOMY", "expires_at": "2025-10-01T17:30:00+07:00",
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create voucher: %s", resp.Body.String())
+	}
+	if status, _ := lookupVoucher(t, testApp, "HALFHOUR"); status == nil || status.ExpiresAt != "2025-10-01T10:30:00Z" {
+		t.Errorf("Expected the expiry stored in UTC, got %+v", status)
+	}
+
+	redeemSeats(t, testApp, "V1")
+	changes, err := testApp.VouchersController.GetStatusChanges(context.Background(), &models.AssignsRandomVoucher{VoucherCode: "V1"})
+	if err != nil || len(changes) != 1 || changes[0].ChangedAt != "2025-10-01T10:00:00Z" {
+		t.Errorf("Expected the redemption at the clock's time, got %+v %v", changes, err)
+	}
+
+	testApp.Clock.Advance(5 * time.Minute)
+	if entry := joinWaitlist(t, testApp, "V2"); entry.JoinedAt != "2025-10-01T10:05:00Z" {
+		t.Errorf("Expected V2 to join at the clock's time, got %q", entry.JoinedAt)
+	}
+
+	testApp.Clock.Advance(time.Hour)
+	if status, _ := lookupVoucher(t, testApp, "HALFHOUR"); status == nil || status.Status != models.VoucherExpired {
+		t.Errorf("Expected HALFHOUR to have expired, got %+v", status)
+	}
+	if _, msg := redeemSeats(t, testApp, "HALFHOUR"); msg != "voucher expired!" {
+		t.Errorf("Expected HALFHOUR to be expired, got %q", msg)
+	}
+}
+
+func TestExpiryRelativeToDeparture(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.Clock.Set(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	// 08:30 in Jakarta is 01:30 UTC
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{
+		"flight_numbers": []string{"GA820"}, "origin": "CGK", "destination": "SIN",
+		"departure_at": "2030-01-10T08:30", "arrival_at": "2030-01-10T11:15",
+	})
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{"flight_numbers": []string{"GA100"}, "dep_date": "2030-01-10"})
+	for _, flightID := range []int{1, 2} {
+		testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": flightID, "cabin": "ECONOMY", "labels": []string{"1A", "1B", "1C"}})
+	}
+	for _, v := range []map[string]any{
+		{"code": "TWOHOURS", "flight_id": 1, "cabin": "ECONOMY", "expires_before_departure": "2h"},
+		{"code": "EARLY", "flight_id": 1, "cabin": "ECONOMY"},
+		{"code": "PLAIN", "flight_id": 1, "cabin": "ECONOMY"},
+	} {
+		if resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", v); resp.Code != http.StatusCreated {
+			t.Fatalf("Failed to create voucher %v: %s", v["code"], resp.Body.String())
+		}
+	}
+
+	expiry := func() (string, string) {
+		resp, _ := testApp.makeRequest("GET", "/api/v1/vouchers", nil)
+		var result struct {
+			Data []struct {
+				Code                   string  `json:"code"`
+				ExpiresAt              *string `json:"expires_at"`
+				ExpiresBeforeDeparture *string `json:"expires_before_departure"`
+			} `json:"data"`
+		}
+		parseResponse(t, resp, &result)
+		for _, v := range result.Data {
+			if v.Code == "TWOHOURS" && v.ExpiresAt != nil && v.ExpiresBeforeDeparture != nil {
+				return *v.ExpiresAt, *v.ExpiresBeforeDeparture
+			}
+		}
+		return "", ""
+	}
+	if expiresAt, before := expiry(); expiresAt != "2030-01-09T23:30:00Z" || before != "2h0m0s" {
+		t.Errorf("Expected TWOHOURS to expire two hours before departure, got %q %q", expiresAt, before)
+	}
+
+	// the expiry follows the departure
+	resp, _ := testApp.makeRequest("PATCH", "/api/v1/flights/1", map[string]any{"dep_date": "2030-01-11"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to move the flight: %s", resp.Body.String())
+	}
+	if expiresAt, _ := expiry(); expiresAt != "2030-01-10T23:30:00Z" {
+		t.Errorf("Expected TWOHOURS to expire two hours before the new departure, got %q", expiresAt)
+	}
+
+	errorTests := []struct {
+		name     string
+		body     map[string]any
+		expected string
+	}{
+		{name: "no departure", body: map[string]any{"code": "X1", "flight_id": 2, "cabin": "ECONOMY", "expires_before_departure": "2h"}, expected: "flight has no scheduled departure!"},
+		{name: "both expiries", body: map[string]any{"code": "X2", "flight_id": 1, "cabin": "ECONOMY", "expires_before_departure": "2h", "expires_at": "2030-01-05T00:00:00Z"}, expected: "ExpiresBeforeDeparture can not be given with ExpiresAt"},
+		{name: "not a duration", body: map[string]any{"code": "X3", "flight_id": 1, "cabin": "ECONOMY", "expires_before_departure": "soon"}, expected: "ExpiresBeforeDeparture must be a duration like 2h or 90m"},
+		{name: "negative", body: map[string]any{"code": "X4", "flight_id": 1, "cabin": "ECONOMY", "expires_before_departure": "-2h"}, expected: "ExpiresBeforeDeparture must be a duration like 2h or 90m"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", tt.body)
+			var result map[string]any
+			parseResponse(t, resp, &result)
+			if resp.Code != http.StatusBadRequest || result["data"] != tt.expected {
+				t.Errorf("Expected %q, got %d %v", tt.expected, resp.Code, result["data"])
+			}
+		})
+	}
+
+	// half an hour before departure TWOHOURS has expired, the others still fly
+	testApp.Clock.Set(time.Date(2030, 1, 11, 1, 0, 0, 0, time.UTC))
+	if _, msg := redeemSeats(t, testApp, "TWOHOURS"); msg != "voucher expired!" {
+		t.Errorf("Expected TWOHOURS to be expired, got %q", msg)
+	}
+	if seated, msg := redeemSeats(t, testApp, "EARLY"); seated == nil {
+		t.Errorf("Expected EARLY to be seated before departure, got %q", msg)
+	}
+
+	// without any expiry no seat is given once the flight has left
+	testApp.Clock.Set(time.Date(2030, 1, 11, 1, 30, 0, 0, time.UTC))
+	if _, msg := redeemSeats(t, testApp, "PLAIN"); msg != "flight already departed!" {
+		t.Errorf("Expected PLAIN to be refused after departure, got %q", msg)
+	}
+}
+
+func TestDateOnlyFlightDeparts(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	testApp.Clock.Set(time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC))
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{"flight_numbers": []string{"GA100"}, "dep_date": "2030-01-10"})
+	testApp.makeRequest("POST", "/api/v1/flights", map[string]any{"flight_numbers": []string{"GA200"}, "dep_date": "2030-01-10", "origin": "CGK", "destination": "SIN"})
+	for _, flightID := range []int{1, 2} {
+		testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": flightID, "cabin": "ECONOMY", "labels": []string{"1A", "1B", "1C"}})
+	}
+	for _, v := range []map[string]any{
+		{"code": "UTC1", "flight_id": 1, "cabin": "ECONOMY"},
+		{"code": "UTC2", "flight_id": 1, "cabin": "ECONOMY"},
+		{"code": "CGK1", "flight_id": 2, "cabin": "ECONOMY"},
+		{"code": "CGK2", "flight_id": 2, "cabin": "ECONOMY"},
+	} {
+		if resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", v); resp.Code != http.StatusCreated {
+			t.Fatalf("Failed to create voucher %v: %s", v["code"], resp.Body.String())
+		}
+	}
+
+	for _, code := range []string{"UTC1", "CGK1"} {
+		if seated, msg := redeemSeats(t, testApp, code); seated == nil {
+			t.Errorf("Expected %s to be seated on its dep_date, got %q", code, msg)
+		}
+	}
+
+	// midnight in Jakarta is 17:00 UTC, the flight without an origin waits for UTC midnight
+	testApp.Clock.Set(time.Date(2030, 1, 10, 17, 0, 0, 0, time.UTC))
+	if _, msg := redeemSeats(t, testApp, "CGK2"); msg != "flight already departed!" {
+		t.Errorf("Expected CGK2 to be refused after its dep_date in Jakarta, got %q", msg)
+	}
+	if seated, msg := redeemSeats(t, testApp, "UTC2"); seated == nil {
+		t.Errorf("Expected UTC2 to be seated before midnight UTC, got %q", msg)
+	}
+
+	testApp.Clock.Set(time.Date(2030, 1, 11, 0, 0, 0, 0, time.UTC))
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{"code": "LATE", "flight_id": 1, "cabin": "ECONOMY"})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create voucher LATE: %s", resp.Body.String())
+	}
+	if _, msg := redeemSeats(t, testApp, "LATE"); msg != "flight already departed!" {
+		t.Errorf("Expected LATE to be refused once the dep_date is over, got %q", msg)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"backend/internal/clock"
+	"backend/internal/models"
+	"backend/internal/throttle"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func lookupVoucher(t *testing.T, testApp *TestApp, code string) (*models.VoucherStatus, int) {
+	resp, _ := testApp.makeRequest("GET", "/api/v1/vouchers/"+code, nil)
+	if resp.Code != http.StatusOK {
+		return nil, resp.Code
+	}
+
+	var result struct {
+		Data models.VoucherStatus `json:"data"`
+	}
+	parseResponse(t, resp, &result)
+	return &result.Data, resp.Code
+}
+
+func TestVoucherLookup(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B", "1C"}, "SINGLE")
+	for _, v := range []map[string]any{
+		{"code": "TWICE", "flight_id": 1, "cabin": "ECONOMY", "max_redemptions": 2},
+		{"code": "LAPSED", "flight_id": 1, "cabin": "ECONOMY", "expires_at": "2020-01-01T00:00:00Z"},
+	} {
+		if resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", v); resp.Code != http.StatusCreated {
+			t.Fatalf("Failed to create voucher %v: %s", v["code"], resp.Body.String())
+		}
+	}
+
+	status, code := lookupVoucher(t, testApp, "SINGLE")
+	if status == nil {
+		t.Fatalf("Failed to look the voucher up: %d", code)
+	}
+	if status.Status != models.VoucherActive || status.FlightNo != "GA100" || status.Cabin != "ECONOMY" || status.SeatLabel != "" || status.RedemptionsLeft != 1 {
+		t.Errorf("Unexpected active voucher %+v", status)
+	}
+
+	seated, _ := redeemSeats(t, testApp, "SINGLE")
+	if status, _ := lookupVoucher(t, testApp, "SINGLE"); status == nil || status.Status != models.VoucherRedeemed || status.SeatLabel != seated.SeatLabel {
+		t.Errorf("Expected the redeemed voucher with its seat, got %+v", status)
+	}
+
+	// a multi-use voucher stays active until it is used up
+	redeemSeats(t, testApp, "TWICE")
+	if status, _ := lookupVoucher(t, testApp, "TWICE"); status == nil || status.Status != models.VoucherActive || status.RedemptionsLeft != 1 || len(status.Seats) != 1 {
+		t.Errorf("Expected an active voucher with a redemption left, got %+v", status)
+	}
+
+	if status, _ := lookupVoucher(t, testApp, "LAPSED"); status == nil || status.Status != models.VoucherExpired || status.RedemptionsLeft != 0 {
+		t.Errorf("Expected an expired voucher, got %+v", status)
+	}
+
+	if _, code := lookupVoucher(t, testApp, "NOPE"); code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown voucher to be rejected, got %d", code)
+	}
+}
+
+func TestVoucherLookupThrottled(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	testApp := setupThrottledApp(t, throttle.Config{Window: time.Minute, IPLimit: 3, LockoutMax: time.Hour}, clk)
+	defer testApp.cleanup()
+
+	for _, code := range []string{"NOPE1", "NOPE2", "NOPE3"} {
+		lookupVoucher(t, testApp, code)
+	}
+	if _, code := lookupVoucher(t, testApp, "VALID1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected lookups to count against the limit, got %d", code)
+	}
+}
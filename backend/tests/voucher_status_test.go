@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"net/http"
+	"testing"
+)
+
+func changeStatus(t *testing.T, testApp *TestApp, code, status string) (*models.VoucherStatusChange, string) {
+	path, body := "/api/v1/vouchers/"+code+"/status", map[string]any{"status": status, "changed_by": "agent-7", "reason": "desk"}
+	if status == models.VoucherRevoked {
+		path, body = "/api/v1/vouchers/"+code+"/revoke", map[string]any{"changed_by": "agent-7", "reason": "fraud"}
+	}
+
+	resp, _ := testApp.makeRequest("POST", path, body)
+	if resp.Code != http.StatusOK {
+		var result map[string]any
+		parseResponse(t, resp, &result)
+		msg, _ := result["data"].(string)
+		return nil, msg
+	}
+
+	var result struct {
+		Data models.VoucherStatusChange `json:"data"`
+	}
+	parseResponse(t, resp, &result)
+	return &result.Data, ""
+}
+
+func TestVoucherLifecycle(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A", "1B"})
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "LATER", "flight_id": 1, "cabin": "ECONOMY", "status": models.VoucherIssued,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create voucher: %s", resp.Body.String())
+	}
+
+	if _, msg := redeemSeats(t, testApp, "LATER"); msg != "voucher not active yet!" {
+		t.Errorf("Expected an issued voucher to be rejected, got %q", msg)
+	}
+	if status, _ := lookupVoucher(t, testApp, "LATER"); status == nil || status.Status != models.VoucherIssued {
+		t.Errorf("Expected an issued voucher, got %+v", status)
+	}
+
+	if change, msg := changeStatus(t, testApp, "LATER", models.VoucherActive); change == nil || change.FromStatus != models.VoucherIssued || change.ToStatus != models.VoucherActive {
+		t.Fatalf("Failed to activate the voucher: %+v %s", change, msg)
+	}
+	if seated, msg := redeemSeats(t, testApp, "LATER"); seated == nil {
+		t.Fatalf("Failed to redeem the activated voucher: %s", msg)
+	}
+
+	if change, msg := changeStatus(t, testApp, "LATER", models.VoucherCheckedIn); change == nil || change.FromStatus != models.VoucherRedeemed {
+		t.Fatalf("Failed to check the voucher in: %+v %s", change, msg)
+	}
+	resp, _ = testApp.makeRequest("POST", "/api/v1/vouchers/LATER/assignment/move", map[string]any{
+		"changed_by": "agent-7", "reason": "upgrade", "seat_label": "1B",
+	})
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected a checked in voucher to keep its seat, got %d", resp.Code)
+	}
+	if _, msg := changeStatus(t, testApp, "LATER", models.VoucherRevoked); msg != "voucher can not go from CHECKED_IN to REVOKED!" {
+		t.Errorf("Expected a checked in voucher not to be revoked, got %q", msg)
+	}
+
+	resp, _ = testApp.makeRequest("GET", "/api/v1/vouchers/LATER/status/changes", nil)
+	var history struct {
+		Data []models.VoucherStatusChange `json:"data"`
+	}
+	parseResponse(t, resp, &history)
+	var got []string
+	for _, c := range history.Data {
+		got = append(got, c.FromStatus+">"+c.ToStatus)
+	}
+	want := []string{"ISSUED>ACTIVE", "ACTIVE>REDEEMED", "REDEEMED>CHECKED_IN"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected history %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected history %v, got %v", want, got)
+			break
+		}
+	}
+
+	// redeeming is not a status set by hand
+	if _, msg := changeStatus(t, testApp, "LATER", models.VoucherRedeemed); msg == "" {
+		t.Errorf("Expected REDEEMED to be refused")
+	}
+}
+
+func TestRevokeFreesSeat(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"}, "V1", "V2")
+	seated, msg := redeemSeats(t, testApp, "V1")
+	if seated == nil {
+		t.Fatalf("Failed to redeem V1: %s", msg)
+	}
+	joinWaitlist(t, testApp, "V2")
+
+	change, msg := changeStatus(t, testApp, "V1", models.VoucherRevoked)
+	if change == nil || change.ToStatus != models.VoucherRevoked || len(change.FreedSeats) != 1 || change.FreedSeats[0] != "1A" {
+		t.Fatalf("Expected the revocation to free 1A, got %+v %s", change, msg)
+	}
+
+	// the freed seat goes to the waitlist
+	if entry, _ := waitlistStatus(t, testApp, "V2"); entry == nil || entry.Status != models.WaitlistAssigned || entry.Seats[0].SeatLabel != "1A" {
+		t.Errorf("Expected V2 to get 1A, got %+v", entry)
+	}
+
+	if _, msg := redeemSeats(t, testApp, "V1"); msg != "voucher revoked!" {
+		t.Errorf("Expected a revoked voucher to be rejected, got %q", msg)
+	}
+	if status, _ := lookupVoucher(t, testApp, "V1"); status == nil || status.Status != models.VoucherRevoked || len(status.Seats) != 0 {
+		t.Errorf("Expected a revoked voucher without seats, got %+v", status)
+	}
+	if _, msg := changeStatus(t, testApp, "V1", models.VoucherRevoked); msg != "voucher can not go from REVOKED to REVOKED!" {
+		t.Errorf("Expected a second revocation to be refused, got %q", msg)
+	}
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/V2/revoke", map[string]any{"reason": "fraud"})
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected a revocation without changed_by to be rejected, got %d", resp.Code)
+	}
+	if _, msg := changeStatus(t, testApp, "NOPE", models.VoucherRevoked); msg != "voucher not found!" {
+		t.Errorf("Expected an unknown voucher, got %q", msg)
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"backend/internal/models"
+	"net/http"
+	"testing"
+)
+
+func joinWaitlist(t *testing.T, testApp *TestApp, code string) *models.WaitlistEntry {
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers/assigns", map[string]any{"voucher_code": code, "waitlist": true})
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("Expected %s to be waitlisted, got %d %s", code, resp.Code, resp.Body.String())
+	}
+
+	var result struct {
+		Data models.WaitlistEntry `json:"data"`
+	}
+	parseResponse(t, resp, &result)
+	return &result.Data
+}
+
+func waitlistStatus(t *testing.T, testApp *TestApp, code string) (*models.WaitlistEntry, string) {
+	resp, _ := testApp.makeRequest("GET", "/api/v1/vouchers/"+code+"/waitlist", nil)
+	if resp.Code != http.StatusOK {
+		var result map[string]any
+		parseResponse(t, resp, &result)
+		msg, _ := result["data"].(string)
+		return nil, msg
+	}
+
+	var result struct {
+		Data models.WaitlistEntry `json:"data"`
+	}
+	parseResponse(t, resp, &result)
+	return &result.Data, ""
+}
+
+func TestWaitlist(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"}, "V1", "V2", "V3")
+	if seated, msg := redeemSeats(t, testApp, "V1"); seated == nil {
+		t.Fatalf("Failed to redeem V1: %s", msg)
+	}
+
+	// without asking for it the cabin is just full
+	if _, msg := redeemSeats(t, testApp, "V2"); msg != "no available seats in cabin!" {
+		t.Errorf("Expected the cabin to be full, got %q", msg)
+	}
+
+	if entry := joinWaitlist(t, testApp, "V2"); entry.Position != 1 || entry.Status != models.WaitlistWaiting || entry.Cabin != "ECONOMY" {
+		t.Errorf("Expected V2 first in line, got %+v", entry)
+	}
+	if entry := joinWaitlist(t, testApp, "V3"); entry.Position != 2 {
+		t.Errorf("Expected V3 second in line, got %+v", entry)
+	}
+	if entry := joinWaitlist(t, testApp, "V2"); entry.Position != 1 {
+		t.Errorf("Expected V2 to keep its place, got %+v", entry)
+	}
+
+	// a released seat goes to the head of the line
+	resp, _ := testApp.makeRequest("DELETE", "/api/v1/vouchers/V1/assignment", map[string]any{
+		"changed_by": "agent-7", "reason": "cancelled",
+	})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Failed to unassign V1: %s", resp.Body.String())
+	}
+	if entry, msg := waitlistStatus(t, testApp, "V2"); entry == nil || entry.Status != models.WaitlistAssigned || len(entry.Seats) != 1 || entry.Seats[0].SeatLabel != "1A" {
+		t.Errorf("Expected V2 to get 1A, got %+v %s", entry, msg)
+	}
+	if entry, msg := waitlistStatus(t, testApp, "V3"); entry == nil || entry.Status != models.WaitlistWaiting || entry.Position != 1 {
+		t.Errorf("Expected V3 to move up, got %+v %s", entry, msg)
+	}
+
+	// so does a seat added to the cabin
+	resp, _ = testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "labels": []string{"1B"}})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to add a seat: %s", resp.Body.String())
+	}
+	if entry, msg := waitlistStatus(t, testApp, "V3"); entry == nil || entry.Status != models.WaitlistAssigned || len(entry.Seats) != 1 || entry.Seats[0].SeatLabel != "1B" {
+		t.Errorf("Expected V3 to get 1B, got %+v %s", entry, msg)
+	}
+	if _, msg := redeemSeats(t, testApp, "V3"); msg != "voucher already redeemed!" {
+		t.Errorf("Expected V3 to be redeemed from the waitlist, got %q", msg)
+	}
+
+	if _, msg := waitlistStatus(t, testApp, "V1"); msg != "voucher not on the waitlist!" {
+		t.Errorf("Expected V1 to have never waited, got %q", msg)
+	}
+	if _, msg := waitlistStatus(t, testApp, "NOPE"); msg != "voucher not found!" {
+		t.Errorf("Expected an unknown voucher, got %q", msg)
+	}
+}
+
+func TestWaitlistGroupKeepsPlace(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+
+	setupHoldFlight(t, testApp, []string{"1A"}, "V1", "SINGLE")
+	resp, _ := testApp.makeRequest("POST", "/api/v1/vouchers", map[string]any{
+		"code": "PAIR", "flight_id": 1, "cabin": "ECONOMY", "group_size": 2,
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Failed to create voucher: %s", resp.Body.String())
+	}
+	redeemSeats(t, testApp, "V1")
+
+	joinWaitlist(t, testApp, "PAIR")
+	joinWaitlist(t, testApp, "SINGLE")
+
+	// one seat is not enough for the pair, the single behind it is seated
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "labels": []string{"5A"}})
+	if entry, _ := waitlistStatus(t, testApp, "SINGLE"); entry == nil || entry.Status != models.WaitlistAssigned {
+		t.Errorf("Expected the single to be seated, got %+v", entry)
+	}
+	if entry, _ := waitlistStatus(t, testApp, "PAIR"); entry == nil || entry.Status != models.WaitlistWaiting || entry.Position != 1 {
+		t.Errorf("Expected the pair to keep waiting first in line, got %+v", entry)
+	}
+
+	testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "labels": []string{"7A", "7B"}})
+	if entry, _ := waitlistStatus(t, testApp, "PAIR"); entry == nil || entry.Status != models.WaitlistAssigned || len(entry.Seats) != 2 {
+		t.Errorf("Expected the pair to be seated together, got %+v", entry)
+	}
+}
+
+func TestWaitlistPassesOverInactiveVoucher(t *testing.T) {
+	testApp := setupTestApp(t)
+	defer testApp.cleanup()
+	testApp.requireDB(t)
+
+	setupHoldFlight(t, testApp, []string{"1A"}, "V1", "STUCK", "NEXT")
+	redeemSeats(t, testApp, "V1")
+	joinWaitlist(t, testApp, "STUCK")
+	joinWaitlist(t, testApp, "NEXT")
+
+	// no status change leads back to ISSUED, the row is edited to get there
+	if _, err := testApp.DB.Exec(testApp.Driver.Rebind(`UPDATE vouchers SET status=? WHERE code=?`), models.VoucherIssued, "STUCK"); err != nil {
+		t.Fatalf("Failed to update voucher: %v", err)
+	}
+
+	resp, _ := testApp.makeRequest("POST", "/api/v1/seats", map[string]any{"flight_id": 1, "cabin": "ECONOMY", "labels": []string{"5A"}})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Expected seats to be added despite the waitlist, got %d %s", resp.Code, resp.Body.String())
+	}
+	if entry, _ := waitlistStatus(t, testApp, "NEXT"); entry == nil || entry.Status != models.WaitlistAssigned {
+		t.Errorf("Expected NEXT to be seated, got %+v", entry)
+	}
+	if entry, _ := waitlistStatus(t, testApp, "STUCK"); entry == nil || entry.Status != models.WaitlistWaiting || entry.Position != 1 {
+		t.Errorf("Expected STUCK to keep its place, got %+v", entry)
+	}
+}